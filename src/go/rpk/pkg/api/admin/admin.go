@@ -545,11 +545,17 @@ func (a *AdminAPI) sendAndReceive(
 
 	// Issue request to the appropriate client, depending on retry behaviour
 	var res *http.Response
+	start := time.Now()
 	if retryable {
 		res, err = a.retryClient.Do(req) //nolint:contextcheck // False positive in v1.0.9, will be fixed in next release.
 	} else {
 		res, err = a.oneshotClient.Do(req)
 	}
+	if err != nil {
+		log.Debugf("admin API %s %s failed after %s: %v", method, url, time.Since(start), err)
+	} else {
+		log.Debugf("admin API %s %s: %s in %s", method, url, res.Status, time.Since(start))
+	}
 
 	if err != nil {
 		// When the server expects a TLS connection, but the TLS config isn't
@@ -559,7 +565,7 @@ func (a *AdminAPI) sendAndReceive(
 		if errors.Is(err, io.EOF) {
 			return nil, fmt.Errorf("%s to server %s expected a tls connection: %w", method, url, err)
 		}
-		return nil, err
+		return nil, config.ExplainTLSError(err, config.FlagAdminTLSServerName)
 	}
 
 	if res.StatusCode/100 != 2 {
@@ -32,6 +32,7 @@ func NewCommand(fs afero.Fs) *cobra.Command {
 		adminAPICertFile       string
 		adminAPIKeyFile        string
 		adminAPITruststoreFile string
+		format                 string
 
 		helpOperations bool
 	)
@@ -70,9 +71,14 @@ func NewCommand(fs afero.Fs) *cobra.Command {
 		&adminAPIKeyFile,
 		&adminAPITruststoreFile,
 	)
+	common.AddFormatFlag(command, &format)
+	common.AddRequestTimeoutFlag(command)
 
+	command.AddCommand(newCopyCommand(fs))
 	command.AddCommand(newCreateCommand(fs))
 	command.AddCommand(newDeleteCommand(fs))
+	command.AddCommand(newDescribeCommand(fs))
+	command.AddCommand(newExportCommand(fs))
 	command.AddCommand(newListCommand(fs))
 	command.AddCommand(newUserCommand(fs))
 	return command
@@ -85,7 +91,8 @@ users. The help text below is specific to ACLs. To learn about SASL users,
 check the help text under the "user" command.
 
 When using SASL, ACLs allow or deny you access to certain requests. The
-"create", "delete", and "list" commands help you manage your ACLs.
+"create", "delete", and "list" commands help you manage your ACLs, and the
+"describe" command shows the effective permissions of a principal.
 
 An ACL is made up of five components:
 
@@ -115,9 +122,24 @@ authorizing by Group or anything else.
 When you create a user, you need to add ACLs for it before it can be used. You
 can create / delete / list ACLs for that user with either "User:bar" or "bar"
 in the --allow-principal and --deny-principal flags. This command will add the
-"User:" prefix for you if it is missing. The wildcard '*' matches any user.
+"User:" prefix for you if it is missing. Principals with any other type, such
+as "Group:bar" or a lowercase "user:bar", are rejected, since ACLs for them
+would never match. The wildcard principal User:* (or '*') matches any user.
 Creating an ACL with user '*' grants or denies the permission for all users.
 
+Redpanda only enforces User principals: every authenticated client, whether
+through SASL or mTLS, is a User principal, and ACLs for any other type are
+stored but never match a client. Types are case sensitive.
+
+For clusters with an authorizer that supports other principal types, the
+create, list, and delete commands share two flags. --principal-type sets the
+type added to principals without one (default User), and principals of that
+type are accepted alongside User ones: with --principal-type Role, "admins"
+becomes "Role:admins". --raw-principals accepts principals of any type
+verbatim, without validating the type; principals without a type still get
+the --principal-type prefix. The same normalization applies to principals in
+'rpk acl create --from-file' and --principal-file.
+
 HOSTS
 
 Hosts can be seen as an extension of the principal, and effectively gate where
@@ -130,9 +152,15 @@ flag with the --allow-principal flag, and the --deny-host flag with the
 
 RESOURCES
 
-A resource is what an ACL allows or denies access to. There are four resources
-within Redpanda: topics, groups, the cluster itself, and transactional IDs.
-Names for each of these resources can be specified with their respective flags.
+A resource is what an ACL allows or denies access to. There are five resources
+within Redpanda: topics, groups, the cluster itself, transactional IDs, and
+delegation tokens. Names for each of these resources can be specified with
+their respective flags.
+
+Delegation token ACLs are named by token ID (--delegation-token [token.id]),
+not by the principal that owns the token, and DESCRIBE is the only operation
+that applies to them. The owner of a token can always describe it; an ACL is
+only needed to let other principals describe the token.
 
 Resources combine with the operation that is allowed or denied on that
 resource. The next section describes which operations are required for which
@@ -145,6 +173,11 @@ The --resource-pattern-type flag can be used to specify that a resource name is
 match both "foo-bar" and "foo-baz". The special wildcard resource name '*'
 matches any name of the given resource type (--topic '*' matches all topics).
 
+Brokers that only support v0 of the ACL APIs do not know pattern types and
+would treat every ACL as literal. rpk checks the broker's API versions first,
+and commands that use the prefixed or match pattern type with such a broker
+fail before anything is created, listed, or deleted.
+
 OPERATIONS
 
 Pairing with resources, operations are the actions that are allowed or denied.
@@ -187,6 +220,24 @@ listing, and deleting can be seen in each of the commands.
 Using SASL requires setting "enable_sasl: true" in the redpanda section of your
 redpanda.yaml. User management is a separate, simpler concept that is
 described in the user command.
+
+EXIT CODES
+
+ACL commands exit 1 for usage and validation errors, 2 if rpk cannot connect
+or authenticate to the cluster, 3 if the cluster rejects the request, and 4 if
+some, but not all, ACLs in a create or filters in a list or delete failed.
+
+With --format json or jsonl, errors are also JSON: rpk writes one line to
+stderr with the exit code, its category (error, connection, server, partial,
+or interrupted), the message, the Kafka error name if the cluster returned
+one, and, for partial or server failures of a batch, every failed ACL or
+filter with its error:
+
+    {"error":{"code":4,"category":"partial","message":"1 of 2 ACLs failed to be created",
+      "details":[{"item":{"principal":"User:bar",...},"error":"POLICY_VIOLATION"}]}}
+
+Fields may be added to this object in later versions, but are never renamed or
+removed.
 `
 
 const helpACLOperations = `Brokers support many operations for many resources:
@@ -284,4 +335,6 @@ ADMIN
     CreateACLs        ALTER on CLUSTER for kafka-cluster
     DeleteACLs        ALTER on CLUSTER for kafka-cluster
     DescribeACLs      DESCRIBE on CLUSTER for kafka-cluster
+
+    DescribeDelegationToken  DESCRIBE on DELEGATION_TOKEN for token IDs (not needed by the token owner)
 `
@@ -0,0 +1,213 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+const (
+	affectingResourceFlag = "affecting-resource"
+	wildcardPrincipal     = "User:*"
+)
+
+type (
+	// principalDecisions are the effective permissions of one principal
+	// connecting from one host on the resource of 'acl list
+	// --affecting-resource'. The wildcard host "*" stands for every host
+	// that has no ACLs of its own.
+	principalDecisions struct {
+		Principal string     `json:"principal" yaml:"principal"`
+		Host      string     `json:"host" yaml:"host"`
+		Decisions []decision `json:"decisions" yaml:"decisions"`
+	}
+
+	// resourceGrants is what 'acl list --affecting-resource' prints.
+	resourceGrants struct {
+		ResourceType kmsg.ACLResourceType `json:"resourceType" yaml:"resourceType"`
+		ResourceName string               `json:"resourceName" yaml:"resourceName"`
+		Principals   []principalDecisions `json:"principals" yaml:"principals"`
+	}
+)
+
+// validateAffectingResource returns an error if the flags do not specify
+// exactly one resource, or if they filter the ACLs to consider: computing
+// effective permissions requires every ACL that affects the resource. This
+// must be called after createDeletionsAndDescribes, which folds the
+// deprecated flags into the current ones.
+func (a *acls) validateAffectingResource(patternChanged bool) error {
+	n := len(a.topics) + len(a.groups) + len(a.txnIDs) + len(a.tokens)
+	if a.cluster {
+		n++
+	}
+	if n != 1 {
+		return errors.New("--affecting-resource requires exactly one resource: one --topic, --group, --transactional-id, or --delegation-token, or --cluster")
+	}
+	if (patternChanged || a.oldResourcePatternType != "") && a.parsed.pattern != kmsg.ACLResourcePatternTypeMatch {
+		return errors.New("--affecting-resource always uses --resource-pattern-type match")
+	}
+	if len(a.operations) > 0 ||
+		len(a.allowPrincipals) > 0 ||
+		len(a.allowHosts) > 0 ||
+		len(a.denyPrincipals) > 0 ||
+		len(a.denyHosts) > 0 ||
+		a.nameFilter != "" {
+		return errors.New("--affecting-resource considers every ACL for the resource and cannot be used with operation, principal, host, or name filters; use 'rpk acl describe' for the permissions of a single principal or host")
+	}
+	return nil
+}
+
+// effectiveGrants returns the effective permissions of every principal that
+// has an ACL in acls, which must be every ACL that affects a single resource
+// of type rt: its literal ACLs, the prefixed ACLs matching its name, and the
+// wildcard ACLs. Principals are sorted.
+//
+// ACLs for the wildcard principal User:* apply to every principal, so they
+// are considered for every principal, and User:* is its own row for the
+// principals that have no ACLs of their own.
+//
+// A principal has a row per host that the ACLs applying to it are for, so
+// that a DENY from one host does not read as denying every host. See
+// hostACLs for which ACLs apply to a host.
+func effectiveGrants(rt kmsg.ACLResourceType, acls []acl) []principalDecisions {
+	var (
+		principals  []string
+		byPrincipal = make(map[string][]acl)
+	)
+	for _, a := range acls {
+		if _, exists := byPrincipal[a.Principal]; !exists {
+			principals = append(principals, a.Principal)
+		}
+		byPrincipal[a.Principal] = append(byPrincipal[a.Principal], a)
+	}
+	sort.Strings(principals)
+
+	wildcard := byPrincipal[wildcardPrincipal]
+	pds := make([]principalDecisions, 0, len(principals))
+	for _, principal := range principals {
+		applied := byPrincipal[principal]
+		if principal != wildcardPrincipal {
+			applied = append(append([]acl(nil), applied...), wildcard...)
+		}
+		for _, host := range aclHosts(applied) {
+			// effectivePermissions keeps pointers into the
+			// slice, so every row gets its own.
+			pds = append(pds, principalDecisions{
+				Principal: principal,
+				Host:      host,
+				Decisions: effectivePermissions(rt, hostACLs(applied, host)),
+			})
+		}
+	}
+	return pds
+}
+
+// aclHosts returns the distinct hosts of acls, sorted with the wildcard host
+// first.
+func aclHosts(acls []acl) []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, a := range acls {
+		if !seen[a.Host] {
+			seen[a.Host] = true
+			hosts = append(hosts, a.Host)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if (hosts[i] == "*") != (hosts[j] == "*") {
+			return hosts[i] == "*"
+		}
+		return hosts[i] < hosts[j]
+	})
+	return hosts
+}
+
+// hostACLs returns a new slice of the ACLs in acls that apply to connections
+// from host: the ACLs for host and for the wildcard host. For the wildcard
+// host, these are only the wildcard host ACLs, which decide for every host
+// that has no ACLs of its own.
+func hostACLs(acls []acl, host string) []acl {
+	var applied []acl
+	for _, a := range acls {
+		if a.Host == host || a.Host == "*" {
+			applied = append(applied, a)
+		}
+	}
+	return applied
+}
+
+// describeReqRespAffecting is describeReqResp for --affecting-resource: we
+// print the effective permissions of every principal on the single resource
+// rather than the ACLs. Failed filters are printed to stderr.
+func describeReqRespAffecting(
+	cl *kgo.Client,
+	p *config.Params,
+	a *acls,
+	f kafka.ACLFilter,
+) {
+	ctx, cancel := kafka.RequestContext(p)
+	defer cancel()
+	results, err := kafka.ListACLs(ctx, cl, f)
+	err = kafka.RequestErr(ctx, err)
+	out.MaybeDie(err, "unable to list ACLs: %v", err)
+	printFailedDescribeFilters(results)
+
+	rt, name := a.singleResource()
+	g := resourceGrants{
+		ResourceType: rt,
+		ResourceName: name,
+		Principals:   effectiveGrants(rt, describedACLs(results)),
+	}
+	switch {
+	case !p.Formatter.IsText():
+		err = p.Formatter.Print(g)
+		out.MaybeDie(err, "unable to print permissions: %v", err)
+	case len(g.Principals) == 0:
+		if failedFilters(results) == 0 {
+			out.Infof("No ACLs affect %s %q; all operations are denied.", rt, name)
+		}
+	default:
+		printResourceGrants(os.Stdout, g)
+	}
+	printSecurityDisabledHint(filterErrs(results)...)
+	exitIfFiltersFailed(results)
+}
+
+// printResourceGrants prints one row per principal and host and one column
+// per operation. Unspecified operations, which are denied, are printed as "-" so
+// that grants and explicit denials stand out.
+func printResourceGrants(w io.Writer, g resourceGrants) {
+	headers := []string{"Principal", "Host"}
+	for _, op := range resourceOperations[g.ResourceType] {
+		headers = append(headers, op.String())
+	}
+	tw := out.NewTableTo(w, headers...)
+	defer tw.Flush()
+	for _, pd := range g.Principals {
+		row := []interface{}{pd.Principal, pd.Host}
+		for _, d := range pd.Decisions {
+			cell := d.Decision
+			if cell == decisionUnspecified {
+				cell = "-"
+			}
+			row = append(row, cell)
+		}
+		tw.Print(row...)
+	}
+}
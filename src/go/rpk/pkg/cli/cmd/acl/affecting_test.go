@@ -0,0 +1,187 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestEffectiveGrants(t *testing.T) {
+	binding := func(principal, name string, pattern kmsg.ACLResourcePatternType, op kmsg.ACLOperation, perm kmsg.ACLPermissionType) acl {
+		return acl{
+			Principal:           principal,
+			Host:                "*",
+			ResourceType:        kmsg.ACLResourceTypeTopic,
+			ResourceName:        name,
+			ResourcePatternType: pattern,
+			Operation:           op,
+			Permission:          perm,
+		}
+	}
+	var (
+		literal  = kmsg.ACLResourcePatternTypeLiteral
+		prefixed = kmsg.ACLResourcePatternTypePrefixed
+
+		allow = kmsg.ACLPermissionTypeAllow
+		deny  = kmsg.ACLPermissionTypeDeny
+
+		read     = kmsg.ACLOperationRead
+		write    = kmsg.ACLOperationWrite
+		describe = kmsg.ACLOperationDescribe
+		all      = kmsg.ACLOperationAll
+	)
+
+	// Everything a MATCH filter for the topic orders-2024 returns.
+	acls := []acl{
+		binding("User:writer", "orders-2024", literal, write, allow),
+		binding("User:reader", "orders-", prefixed, read, allow),
+		binding("User:reader", "orders-2024", literal, read, deny),
+		binding("User:admin", "*", literal, all, allow),
+		binding("User:*", "orders-", prefixed, describe, allow),
+		binding("User:*", "*", literal, write, deny),
+	}
+
+	grants := effectiveGrants(kmsg.ACLResourceTypeTopic, acls)
+	got := make(map[string]map[kmsg.ACLOperation]string)
+	var principals []string
+	for _, pd := range grants {
+		principals = append(principals, pd.Principal)
+		got[pd.Principal] = make(map[kmsg.ACLOperation]string)
+		for _, d := range pd.Decisions {
+			got[pd.Principal][d.Operation] = d.Decision
+		}
+	}
+	require.Equal(t, []string{"User:*", "User:admin", "User:reader", "User:writer"}, principals)
+
+	for _, test := range []struct {
+		principal string
+		op        kmsg.ACLOperation
+		exp       string
+	}{
+		// The wildcard principal's ACLs only.
+		{"User:*", describe, decisionAllowed},
+		{"User:*", write, decisionDenied},
+		{"User:*", read, decisionUnspecified},
+
+		// The wildcard DENY of WRITE overrides the literal ALLOW.
+		{"User:writer", write, decisionDenied},
+		{"User:writer", describe, decisionAllowed},
+
+		// The literal DENY overrides the prefixed ALLOW.
+		{"User:reader", read, decisionDenied},
+		{"User:reader", describe, decisionAllowed},
+
+		// ALLOW ALL on the wildcard topic allows everything but the
+		// wildcard principal's DENY.
+		{"User:admin", read, decisionAllowed},
+		{"User:admin", kmsg.ACLOperationAlterConfigs, decisionAllowed},
+		{"User:admin", write, decisionDenied},
+	} {
+		require.Equal(t, test.exp, got[test.principal][test.op], "%s %s", test.principal, test.op)
+	}
+
+	// Deciding ACLs are per principal, not shared.
+	for _, pd := range grants {
+		for _, d := range pd.Decisions {
+			if d.ACL != nil && d.ACL.Principal != wildcardPrincipal {
+				require.Equal(t, pd.Principal, d.ACL.Principal)
+			}
+		}
+	}
+
+	require.Empty(t, effectiveGrants(kmsg.ACLResourceTypeTopic, nil))
+
+	// A DENY from one host only denies that host.
+	fromHost := binding("User:reader", "orders-2024", literal, read, deny)
+	fromHost.Host = "10.0.0.1"
+	grants = effectiveGrants(kmsg.ACLResourceTypeTopic, []acl{
+		binding("User:reader", "orders-", prefixed, read, allow),
+		fromHost,
+	})
+	require.Len(t, grants, 2)
+	hostReads := make(map[string]string)
+	for _, pd := range grants {
+		require.Equal(t, "User:reader", pd.Principal)
+		for _, d := range pd.Decisions {
+			if d.Operation == read {
+				hostReads[pd.Host] = d.Decision
+			}
+		}
+	}
+	require.Equal(t, map[string]string{"*": decisionAllowed, "10.0.0.1": decisionDenied}, hostReads)
+	require.Equal(t, "*", grants[0].Host, "the wildcard host sorts first")
+}
+
+func TestValidateAffectingResource(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		a              acls
+		patternChanged bool
+		expErr         bool
+	}{
+		{name: "one topic", a: acls{topics: []string{"foo"}}},
+		{name: "cluster", a: acls{cluster: true}},
+		{name: "no resource", expErr: true},
+		{name: "two topics", a: acls{topics: []string{"foo", "bar"}}, expErr: true},
+		{name: "topic and group", a: acls{topics: []string{"foo"}, groups: []string{"g"}}, expErr: true},
+		{name: "topic and cluster", a: acls{topics: []string{"foo"}, cluster: true}, expErr: true},
+		{
+			name:           "explicit match",
+			a:              acls{topics: []string{"foo"}, parsed: parsed{pattern: kmsg.ACLResourcePatternTypeMatch}},
+			patternChanged: true,
+		},
+		{
+			name:           "explicit literal",
+			a:              acls{topics: []string{"foo"}, parsed: parsed{pattern: kmsg.ACLResourcePatternTypeLiteral}},
+			patternChanged: true,
+			expErr:         true,
+		},
+		{name: "operation", a: acls{topics: []string{"foo"}, operations: []string{"read"}}, expErr: true},
+		{name: "principal", a: acls{topics: []string{"foo"}, allowPrincipals: []string{"User:a"}}, expErr: true},
+		{name: "host", a: acls{topics: []string{"foo"}, denyHosts: []string{"1.2.3.4"}}, expErr: true},
+		{name: "name filter", a: acls{topics: []string{"foo"}, nameFilter: "f*"}, expErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.a.validateAffectingResource(test.patternChanged)
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPrintResourceGrants(t *testing.T) {
+	g := resourceGrants{
+		ResourceType: kmsg.ACLResourceTypeTransactionalId,
+		ResourceName: "txn",
+		Principals: []principalDecisions{
+			{"User:a", "*", []decision{
+				{Operation: kmsg.ACLOperationWrite, Decision: decisionAllowed},
+				{Operation: kmsg.ACLOperationDescribe, Decision: decisionAllowed},
+			}},
+			{"User:b", "10.0.0.1", []decision{
+				{Operation: kmsg.ACLOperationWrite, Decision: decisionDenied},
+				{Operation: kmsg.ACLOperationDescribe, Decision: decisionUnspecified},
+			}},
+		},
+	}
+	var b bytes.Buffer
+	printResourceGrants(&b, g)
+	require.Equal(t, `PRINCIPAL  HOST      WRITE    DESCRIBE
+User:a     *         allowed  allowed
+User:b     10.0.0.1  denied   -
+`, b.String())
+}
@@ -12,10 +12,16 @@ package acl
 import (
 	"errors"
 	"fmt"
+	"net"
+	"os"
 	"strings"
 
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	vnet "github.com/redpanda-data/redpanda/src/go/rpk/pkg/net"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
 	"github.com/spf13/cobra"
 	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
@@ -28,12 +34,17 @@ const (
 	groupFlag          = "group"
 	clusterFlag        = "cluster"
 	txnIDFlag          = "transactional-id"
+	tokenFlag          = "delegation-token"
 	patternFlag        = "resource-pattern-type"
 	allowPrincipalFlag = "allow-principal"
 	allowHostFlag      = "allow-host"
 	denyPrincipalFlag  = "deny-principal"
 	denyHostFlag       = "deny-host"
 	operationFlag      = "operation"
+	nameFilterFlag     = "name-filter"
+	principalFileFlag  = "principal-file"
+	principalTypeFlag  = "principal-type"
+	rawPrincipalsFlag  = "raw-principals"
 
 	kafkaCluster = "kafka-cluster"
 )
@@ -55,16 +66,10 @@ var (
 
 type (
 	// Corresponding to the above, acl and aclWithMessage are the rows
-	// for PrintStructFields.
-	acl struct {
-		Principal           string
-		Host                string
-		ResourceType        kmsg.ACLResourceType
-		ResourceName        string
-		ResourcePatternType kmsg.ACLResourcePatternType
-		Operation           kmsg.ACLOperation
-		Permission          kmsg.ACLPermissionType
-	}
+	// for PrintStructFields. The acl struct is also what we print with
+	// --format json or yaml, so kafka.ACL's field names must remain
+	// stable.
+	acl            kafka.ACL
 	aclWithMessage struct {
 		Principal           string
 		Host                string
@@ -85,6 +90,19 @@ func unptr(str *string) string {
 	return *str
 }
 
+// filterACL returns the fields of a filter as an acl, for the details of a
+// failed filter; nil fields, which match anything, are empty.
+func filterACL(
+	principal, host *string,
+	rt kmsg.ACLResourceType,
+	name *string,
+	pattern kmsg.ACLResourcePatternType,
+	op kmsg.ACLOperation,
+	perm kmsg.ACLPermissionType,
+) acl {
+	return acl{unptr(principal), unptr(host), rt, unptr(name), pattern, op, perm}
+}
+
 // The acls struct contains everything we receive from flags, and one field
 // that stores anything from those flags that needs parsing.
 type acls struct {
@@ -103,15 +121,25 @@ type acls struct {
 	groups          []string
 	cluster         bool
 	txnIDs          []string
+	tokens          []string
 	allowPrincipals []string
 	allowHosts      []string
 	denyPrincipals  []string
 	denyHosts       []string
+	principalType   string
+	rawPrincipals   bool
 
 	// create & delete & list flags, to be parsed
 	resourcePatternType string
 	operations          []string
 
+	// list & delete flags
+	nameFilter string
+
+	// create flags
+	force         bool
+	principalFile string
+
 	parsed parsed
 }
 
@@ -125,8 +153,8 @@ func (a *acls) addDeprecatedFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&a.resourceType, resourceFlag, "", "")
 	cmd.Flags().StringVar(&a.resourceName, resourceNameFlag, "", "")
 	cmd.Flags().StringVar(&a.oldResourcePatternType, namePatternFlag, "", "")
-	cmd.Flags().MarkDeprecated(resourceFlag, "use --topic, --group, --transactional-id, or --cluster")
-	cmd.Flags().MarkDeprecated(resourceNameFlag, "use --topic, --group, --transactional-id, or --cluster")
+	cmd.Flags().MarkDeprecated(resourceFlag, "use --topic, --group, --transactional-id, --delegation-token, or --cluster")
+	cmd.Flags().MarkDeprecated(resourceNameFlag, "use --topic, --group, --transactional-id, --delegation-token, or --cluster")
 	cmd.Flags().MarkDeprecated(namePatternFlag, "use --resource-pattern-type")
 }
 
@@ -212,7 +240,13 @@ func (a *acls) backcompat(list bool) error {
 	if a.resourceType == "" && a.resourceName == "" {
 		return nil
 	}
-	parsedType, err := kmsg.ParseACLResourceType(a.resourceType)
+	if a.resourceType == "" {
+		if a.cluster {
+			return fmt.Errorf("--%s cannot be used with --%s: the cluster resource has no name", resourceNameFlag, clusterFlag)
+		}
+		return fmt.Errorf("--%s requires --%s", resourceNameFlag, resourceFlag)
+	}
+	parsedType, err := parseResourceType(a.resourceType)
 	if err != nil {
 		return fmt.Errorf("unable to parse %s: %v", resourceFlag, err)
 	}
@@ -234,6 +268,8 @@ func (a *acls) backcompat(list bool) error {
 		a.groups = append(a.groups, a.resourceName)
 	case kmsg.ACLResourceTypeTransactionalId:
 		a.txnIDs = append(a.txnIDs, a.resourceName)
+	case kmsg.ACLResourceTypeDelegationToken:
+		a.tokens = append(a.tokens, a.resourceName)
 	case kmsg.ACLResourceTypeCluster:
 		a.cluster = true
 	}
@@ -241,6 +277,191 @@ func (a *acls) backcompat(list bool) error {
 	return nil
 }
 
+// resourceTypes are the resource types that can be used in ACLs, in the
+// order we print them in errors.
+var resourceTypes = []string{
+	"topic",
+	"group",
+	"cluster",
+	"transactional-id",
+	"delegation-token",
+}
+
+// resourceOperations are the operations that are applicable to each resource
+// type, excluding ALL which applies to every resource type.
+var resourceOperations = map[kmsg.ACLResourceType][]kmsg.ACLOperation{
+	kmsg.ACLResourceTypeTopic: {
+		kmsg.ACLOperationRead,
+		kmsg.ACLOperationWrite,
+		kmsg.ACLOperationCreate,
+		kmsg.ACLOperationDelete,
+		kmsg.ACLOperationAlter,
+		kmsg.ACLOperationDescribe,
+		kmsg.ACLOperationDescribeConfigs,
+		kmsg.ACLOperationAlterConfigs,
+	},
+	kmsg.ACLResourceTypeGroup: {
+		kmsg.ACLOperationRead,
+		kmsg.ACLOperationDelete,
+		kmsg.ACLOperationDescribe,
+	},
+	kmsg.ACLResourceTypeCluster: {
+		kmsg.ACLOperationCreate,
+		kmsg.ACLOperationAlter,
+		kmsg.ACLOperationDescribe,
+		kmsg.ACLOperationClusterAction,
+		kmsg.ACLOperationDescribeConfigs,
+		kmsg.ACLOperationAlterConfigs,
+		kmsg.ACLOperationIdempotentWrite,
+	},
+	kmsg.ACLResourceTypeTransactionalId: {
+		kmsg.ACLOperationWrite,
+		kmsg.ACLOperationDescribe,
+	},
+	kmsg.ACLResourceTypeDelegationToken: {
+		kmsg.ACLOperationDescribe,
+	},
+}
+
+// usedResourceTypes returns the resource types that the resource flags
+// specify, in the order that we create ACLs for them.
+func (a *acls) usedResourceTypes() []kmsg.ACLResourceType {
+	var rts []kmsg.ACLResourceType
+	for _, r := range []struct {
+		t   kmsg.ACLResourceType
+		use bool
+	}{
+		{kmsg.ACLResourceTypeTopic, len(a.topics) > 0},
+		{kmsg.ACLResourceTypeGroup, len(a.groups) > 0},
+		{kmsg.ACLResourceTypeCluster, a.cluster},
+		{kmsg.ACLResourceTypeTransactionalId, len(a.txnIDs) > 0},
+		{kmsg.ACLResourceTypeDelegationToken, len(a.tokens) > 0},
+	} {
+		if r.use {
+			rts = append(rts, r.t)
+		}
+	}
+	return rts
+}
+
+// validateOperation returns an error if the operation does not apply to the
+// resource type, per resourceOperations. Such an ACL is accepted by brokers
+// but is never checked, so it has no effect. ALL applies to every resource
+// type.
+func validateOperation(rt kmsg.ACLResourceType, op kmsg.ACLOperation) error {
+	if op == kmsg.ACLOperationAll {
+		return nil
+	}
+	var valid []string
+	for _, v := range resourceOperations[rt] {
+		if v == op {
+			return nil
+		}
+		valid = append(valid, v.String())
+	}
+	return fmt.Errorf("operation %s does not apply to %s resources, valid operations: %s, ALL",
+		op, rt, strings.Join(valid, ", "))
+}
+
+// parseResourceType parses a resource type case insensitively, also allowing
+// dashes or underscores (transactional-id, TRANSACTIONAL_ID). The "any" and
+// "user" types are valid in the protocol but cannot be used in ACLs.
+func parseResourceType(t string) (kmsg.ACLResourceType, error) {
+	parsed, err := kmsg.ParseACLResourceType(t)
+	switch {
+	case err != nil,
+		parsed == kmsg.ACLResourceTypeAny,
+		parsed == kmsg.ACLResourceTypeUser:
+		return 0, fmt.Errorf("unknown resource type %q, valid types: %s", t, strings.Join(resourceTypes, ", "))
+	}
+	return parsed, nil
+}
+
+// validateHost returns an error if host is not the wildcard '*', an IP
+// address, or a hostname.
+func validateHost(host string) error {
+	switch {
+	case host == "*":
+	case net.ParseIP(host) != nil:
+	case vnet.IsDomain(host):
+	default:
+		return fmt.Errorf("invalid host %q, must be '*', an IP address, or a hostname", host)
+	}
+	return nil
+}
+
+// validateHosts validates every allow and deny host, including the deprecated
+// list --host.
+func (a *acls) validateHosts() error {
+	for _, hosts := range [][]string{a.allowHosts, a.denyHosts, a.listHosts} {
+		for _, host := range hosts {
+			if err := validateHost(host); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// warnHostnames warns about hosts that are hostnames: brokers match ACL hosts
+// against the IP address of the client, so an ACL for a hostname never
+// matches any client.
+func (a *acls) warnHostnames() {
+	for _, hosts := range [][]string{a.allowHosts, a.denyHosts} {
+		for _, host := range hosts {
+			if host != "*" && net.ParseIP(host) == nil {
+				out.Warnf("warning: host %q is not an IP address; brokers match ACL hosts against client IP addresses, so this ACL will not match any client", host)
+			}
+		}
+	}
+}
+
+// validateResources rejects empty resource names and removes duplicate names,
+// so that every named resource gets exactly one binding per operation. Any
+// number of topics, groups, transactional IDs, and delegation tokens can be
+// combined with the cluster in one request.
+func (a *acls) validateResources() error {
+	for _, token := range a.tokens {
+		if err := validateTokenName(token); err != nil {
+			return err
+		}
+	}
+	for _, resource := range []struct {
+		flag  string
+		names *[]string
+	}{
+		{topicFlag, &a.topics},
+		{groupFlag, &a.groups},
+		{txnIDFlag, &a.txnIDs},
+		{tokenFlag, &a.tokens},
+	} {
+		seen := make(map[string]bool)
+		var keep []string
+		for _, name := range *resource.names {
+			if name == "" {
+				return fmt.Errorf("invalid empty --%s", resource.flag)
+			}
+			if !seen[name] {
+				seen[name] = true
+				keep = append(keep, name)
+			}
+		}
+		*resource.names = keep
+	}
+	return nil
+}
+
+// validateTokenName rejects delegation token resource names that are
+// principals. Brokers authorize DELEGATION_TOKEN resources by token ID: the
+// owner of a token can always describe it without an ACL, and an ACL named
+// after the owner principal would never match any token.
+func validateTokenName(name string) error {
+	if typ, _, hasType := strings.Cut(name, ":"); hasType && strings.EqualFold(typ, "User") {
+		return fmt.Errorf("invalid delegation token %q: delegation token ACLs are named by token ID, not by the token owner principal", name)
+	}
+	return nil
+}
+
 func (a *acls) parseCommon() error {
 	for _, op := range a.operations {
 		parsed, err := kmsg.ParseACLOperation(op)
@@ -260,76 +481,230 @@ func (a *acls) parseCommon() error {
 	return nil
 }
 
-func (a *acls) createCreations() (*kadm.ACLBuilder, error) {
+// defaultPrincipalType is the principal type that Redpanda's authorizer
+// enforces, and the type added to principals without one by default.
+const defaultPrincipalType = "User"
+
+// principalNorm is how principals are normalized: principals without a type
+// get typ, and, if raw, principals with a type are accepted verbatim
+// whatever the type.
+type principalNorm struct {
+	typ string
+	raw bool
+}
+
+// normalizePrincipal normalizes a principal the default way; see
+// principalNorm.normalize.
+func normalizePrincipal(principal string) (string, error) {
+	return principalNorm{typ: defaultPrincipalType}.normalize(principal)
+}
+
+// normalize validates a principal, adding the n.typ prefix if it is missing.
+// Redpanda only supports the User principal type, so unless n.raw, any type
+// other than User or n.typ, including a lowercase "user:", is rejected: an
+// ACL for such a principal would never match with Redpanda's authorizer. The
+// wildcard principal is "User:*", and "*" is normalized to it (or to n.typ
+// with a '*' name).
+func (n principalNorm) normalize(principal string) (string, error) {
+	typ, name, hasType := strings.Cut(principal, ":")
+	switch {
+	case principal == "":
+		return "", errors.New("invalid empty principal")
+	case !hasType:
+		return n.typ + ":" + principal, nil
+	case typ == "":
+		return "", fmt.Errorf("invalid principal %q: missing type before the ':'", principal)
+	case name == "":
+		return "", fmt.Errorf("invalid principal %q: missing name after the %s: prefix", principal, typ)
+	case n.raw, typ == defaultPrincipalType, typ == n.typ:
+		return principal, nil
+	case strings.EqualFold(typ, defaultPrincipalType):
+		return "", fmt.Errorf("invalid principal %q: the principal type is case sensitive, did you mean %q?", principal, defaultPrincipalType+":"+name)
+	case strings.EqualFold(typ, n.typ):
+		return "", fmt.Errorf("invalid principal %q: the principal type is case sensitive, did you mean %q?", principal, n.typ+":"+name)
+	default:
+		supported := defaultPrincipalType
+		if n.typ != defaultPrincipalType {
+			supported += " and " + n.typ
+		}
+		return "", fmt.Errorf("invalid principal %q: unknown principal type %q, only %s is supported (e.g. %s:%s); use --%s to use other types verbatim", principal, typ, supported, n.typ, name, rawPrincipalsFlag)
+	}
+}
+
+// addPrincipalTypeFlags adds the flags that configure how the commands that
+// share acls normalize principals.
+func (a *acls) addPrincipalTypeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&a.principalType, principalTypeFlag, defaultPrincipalType, "Principal type to add to principals that do not have one")
+	cmd.Flags().BoolVar(&a.rawPrincipals, rawPrincipalsFlag, false, "Accept principals of any type verbatim, rather than only User and --principal-type principals")
+}
+
+// principalNorm returns how to normalize principals per the flags.
+func (a *acls) principalNorm() (principalNorm, error) {
+	typ := a.principalType
+	if typ == "" {
+		typ = defaultPrincipalType
+	}
+	if strings.ContainsAny(typ, ": \t") {
+		return principalNorm{}, fmt.Errorf("invalid --%s %q, must be a type name such as %s, without a ':'", principalTypeFlag, a.principalType, defaultPrincipalType)
+	}
+	return principalNorm{typ: typ, raw: a.rawPrincipals}, nil
+}
+
+// normalizePrincipals normalizes the allow and deny principals in place.
+func (a *acls) normalizePrincipals() error {
+	n, err := a.principalNorm()
+	if err != nil {
+		return err
+	}
+	for _, ps := range [][]string{a.allowPrincipals, a.denyPrincipals} {
+		for i, p := range ps {
+			normalized, err := n.normalize(p)
+			if err != nil {
+				return err
+			}
+			ps[i] = normalized
+		}
+	}
+	return nil
+}
+
+// validateCreate validates and parses the flags for creating ACLs, which are
+// then expanded with creations.
+func (a *acls) validateCreate() error {
 	if err := a.backcompat(false); err != nil {
-		return nil, err
+		return err
+	}
+	if err := a.validateResources(); err != nil {
+		return err
+	}
+	if err := a.validateHosts(); err != nil {
+		return err
+	}
+	if err := a.normalizePrincipals(); err != nil {
+		return err
 	}
 	if err := a.parseCommon(); err != nil {
-		return nil, err
-	}
-
-	// Using empty lists / non-Maybe functions when building create ACLs is
-	// fine, since creation does not opt in to "any" when things are empty.
-	b := kadm.NewACLs().
-		ResourcePatternType(a.parsed.pattern).
-		MaybeOperations(a.parsed.operations...). // avoid defaulting to "any" if none are provided
-		Topics(a.topics...).
-		Groups(a.groups...).
-		MaybeClusters(a.cluster). // avoid opting in to all clusters by default
-		TransactionalIDs(a.txnIDs...).
-		Allow(a.allowPrincipals...).
-		AllowHosts(a.allowHosts...).
-		Deny(a.denyPrincipals...).
-		DenyHosts(a.denyHosts...)
-
-	b.PrefixUserExcept() // add "User:" prefix to everything if needed
-
-	return b, b.ValidateCreate()
+		return err
+	}
+	for _, op := range a.parsed.operations {
+		if op == kmsg.ACLOperationAny || op == kmsg.ACLOperationUnknown {
+			return fmt.Errorf("invalid operation %s for creating ACLs", op)
+		}
+	}
+	switch a.parsed.pattern {
+	case kadm.ACLPatternLiteral, kadm.ACLPatternPrefixed:
+	default:
+		return fmt.Errorf("invalid %s %q for creating ACLs, must be literal or prefixed", patternFlag, a.resourcePatternType)
+	}
+	// The only cluster resource is the literal "kafka-cluster"; a prefixed
+	// cluster ACL would never match anything.
+	if a.cluster && a.parsed.pattern == kadm.ACLPatternPrefixed {
+		return fmt.Errorf("--%s cannot be used with --%s prefixed, the cluster resource is always literal", clusterFlag, patternFlag)
+	}
+	if !a.force {
+		for _, rt := range a.usedResourceTypes() {
+			for _, op := range a.parsed.operations {
+				if err := validateOperation(rt, op); err != nil {
+					return fmt.Errorf("%v (use --force to create the ACL anyway)", err)
+				}
+			}
+		}
+	}
+	if len(a.allowHosts) > 0 && len(a.allowPrincipals) == 0 {
+		return fmt.Errorf("--%s requires --%s", allowHostFlag, allowPrincipalFlag)
+	}
+	if len(a.denyHosts) > 0 && len(a.denyPrincipals) == 0 {
+		return fmt.Errorf("--%s requires --%s", denyHostFlag, denyPrincipalFlag)
+	}
+	return nil
 }
 
+// createDeletionsAndDescribes validates and parses the flags into the
+// filter to list, describe, or delete ACLs with.
 func (a *acls) createDeletionsAndDescribes(
 	list bool,
-) (*kadm.ACLBuilder, error) {
+) (kafka.ACLFilter, error) {
 	if err := a.backcompat(list); err != nil {
-		return nil, err
+		return kafka.ACLFilter{}, err
+	}
+	if err := a.validateResources(); err != nil {
+		return kafka.ACLFilter{}, err
+	}
+	if err := a.validateHosts(); err != nil {
+		return kafka.ACLFilter{}, err
+	}
+	if err := a.normalizePrincipals(); err != nil {
+		return kafka.ACLFilter{}, err
 	}
 	if err := a.parseCommon(); err != nil {
-		return nil, err
-	}
-
-	// Deleting & describing works on a filter basis: empty matches all.
-	// The builder opts in to all when using functions if the input slice
-	// is empty, but we can use the Maybe functions to avoid optin into all
-	// by default.
-	b := kadm.NewACLs().
-		ResourcePatternType(a.parsed.pattern).
-		Operations(a.parsed.operations...).
-		MaybeTopics(a.topics...).
-		MaybeGroups(a.groups...).
-		MaybeClusters(a.cluster).
-		MaybeTransactionalIDs(a.txnIDs...).
-		MaybeAllow(a.allowPrincipals...).
-		MaybeAllowHosts(a.allowHosts...).
-		MaybeDeny(a.denyPrincipals...).
-		MaybeDenyHosts(a.denyHosts...)
-
-	// Resources: if no resources are specified, we use all resources.
-	if !b.HasResource() {
-		b.AnyResource()
-	}
-	// User & host: when unspecified, we default to everything. This means
-	// that if a user wants to specifically filter for allowed or denied,
-	// they must either allow or deny flags.
-	if !b.HasPrincipals() {
-		b.Allow()
-		b.Deny()
-	}
-	if !b.HasHosts() {
-		b.AllowHosts()
-		b.DenyHosts()
-	}
-
-	b.PrefixUserExcept() // add "User:" prefix to everything if needed
-
-	return b, b.ValidateFilter()
+		return kafka.ACLFilter{}, err
+	}
+	f := kafka.ACLFilter{
+		Topics:           a.topics,
+		Groups:           a.groups,
+		Cluster:          a.cluster,
+		TransactionalIDs: a.txnIDs,
+		DelegationTokens: a.tokens,
+		PatternType:      a.parsed.pattern,
+		Operations:       a.parsed.operations,
+		AllowPrincipals:  a.allowPrincipals,
+		AllowHosts:       a.allowHosts,
+		DenyPrincipals:   a.denyPrincipals,
+		DenyHosts:        a.denyHosts,
+		NameGlob:         a.nameFilter,
+	}
+	_, err := f.Builder() // validate the filter before connecting
+	return f, err
+}
+
+// exitIfFailed exits if any of the total ACL operations in a batch failed,
+// with out.ExitPartial if some succeeded or out.ExitServer if all failed.
+// The details are the failed operations, which are part of the error with
+// --format json. Any output must be flushed before calling this.
+func exitIfFailed(msg string, details []out.ErrorDetail, total int) {
+	switch {
+	case len(details) == 0:
+	case len(details) < total:
+		out.ExitFailed(out.ExitPartial, msg, details)
+	default:
+		out.ExitFailed(out.ExitServer, msg, details)
+	}
+}
+
+// securityDisabledHint explains the SECURITY_DISABLED error code, which the
+// broker returns for ACL requests if authorization is not enabled. The error
+// message itself ("Security features are disabled") is easily misread.
+const securityDisabledHint = `ACLs are not enabled on the cluster: the broker responded with SECURITY_DISABLED.
+ACLs must be enabled in the broker config before they can be created, listed,
+or deleted. Enable authorization with:
+    rpk cluster config set kafka_enable_authorization true
+or, on older versions, with enable_sasl.`
+
+// securityDisabled returns whether any of the errors is the SECURITY_DISABLED
+// error code.
+func securityDisabled(errs ...error) bool {
+	for _, err := range errs {
+		if errors.Is(err, kerr.SecurityDisabled) {
+			return true
+		}
+	}
+	return false
+}
+
+// printSecurityDisabledHint prints securityDisabledHint to stderr if any of
+// the errors is SECURITY_DISABLED. Any output must be flushed before calling
+// this.
+func printSecurityDisabledHint(errs ...error) {
+	if securityDisabled(errs...) {
+		fmt.Fprintf(os.Stderr, "\n%s\n", securityDisabledHint)
+	}
+}
+
+// withSecurityDisabledHint returns err with securityDisabledHint appended if
+// err is SECURITY_DISABLED, for errors that we die with.
+func withSecurityDisabledHint(err error) error {
+	if !securityDisabled(err) {
+		return err
+	}
+	return fmt.Errorf("%w\n\n%s", err, securityDisabledHint)
 }
@@ -10,10 +10,21 @@
 package acl
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"testing"
 
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka/kafkatest"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
 	"github.com/stretchr/testify/require"
 	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
@@ -227,6 +238,19 @@ func TestBackcompat(t *testing.T) {
 			},
 		},
 
+		{
+			name: "resource type delegation token added appropriately",
+			in: acls{
+				resourceType: "Delegation-Token",
+				resourceName: "User:tok",
+			},
+			exp: acls{
+				resourceType: "Delegation-Token",
+				resourceName: "User:tok",
+				tokens:       []string{"User:tok"},
+			},
+		},
+
 		{
 			name: "empty name fails",
 			in: acls{
@@ -333,3 +357,442 @@ func TestParseCommon(t *testing.T) {
 		})
 	}
 }
+
+func TestParseResourceType(t *testing.T) {
+	for _, test := range []struct {
+		in     string
+		exp    kmsg.ACLResourceType
+		expErr bool
+	}{
+		{in: "topic", exp: kmsg.ACLResourceTypeTopic},
+		{in: "GROUP", exp: kmsg.ACLResourceTypeGroup},
+		{in: "Cluster", exp: kmsg.ACLResourceTypeCluster},
+		{in: "transactional-id", exp: kmsg.ACLResourceTypeTransactionalId},
+		{in: "TRANSACTIONAL_ID", exp: kmsg.ACLResourceTypeTransactionalId},
+		{in: "delegation-token", exp: kmsg.ACLResourceTypeDelegationToken},
+		{in: "any", expErr: true},
+		{in: "user", expErr: true},
+		{in: "topics", expErr: true},
+		{in: "", expErr: true},
+	} {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := parseResourceType(test.in)
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+			if test.expErr {
+				return
+			}
+			require.Equal(t, test.exp, got, "parse mismatch!")
+		})
+	}
+}
+
+func TestACLFormatFieldNames(t *testing.T) {
+	b, err := json.Marshal(acl{
+		Principal:           "User:foo",
+		Host:                "*",
+		ResourceType:        kmsg.ACLResourceTypeTopic,
+		ResourceName:        "bar",
+		ResourcePatternType: kmsg.ACLResourcePatternTypePrefixed,
+		Operation:           kmsg.ACLOperationRead,
+		Permission:          kmsg.ACLPermissionTypeAllow,
+	})
+	require.NoError(t, err)
+	exp := `{"principal":"User:foo","host":"*","resourceType":"TOPIC","resourceName":"bar","patternType":"PREFIXED","operation":"READ","permission":"ALLOW"}`
+	require.Equal(t, exp, string(b), "json field names must remain stable")
+}
+
+func TestCreateCreationsPattern(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		expErr  bool
+	}{
+		{"", false},
+		{"literal", false},
+		{"PREFIXED", false},
+		{"match", true},
+		{"any", true},
+	} {
+		t.Run(test.pattern, func(t *testing.T) {
+			a := acls{
+				topics:              []string{"orders."},
+				operations:          []string{"read"},
+				allowPrincipals:     []string{"foo"},
+				denyPrincipals:      []string{"bar"},
+				resourcePatternType: test.pattern,
+			}
+			err := a.validateCreate()
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+		})
+	}
+}
+
+func TestValidateResources(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		in     acls
+		exp    acls
+		expErr bool
+	}{
+		{
+			name: "nothing is fine",
+		},
+		{
+			name: "duplicates are removed, order is kept",
+			in: acls{
+				topics:  []string{"b", "a", "b"},
+				groups:  []string{"g", "g"},
+				txnIDs:  []string{"t"},
+				cluster: true,
+			},
+			exp: acls{
+				topics:  []string{"b", "a"},
+				groups:  []string{"g"},
+				txnIDs:  []string{"t"},
+				cluster: true,
+			},
+		},
+		{
+			name:   "empty topic",
+			in:     acls{topics: []string{"a", ""}},
+			expErr: true,
+		},
+		{
+			name:   "empty group",
+			in:     acls{groups: []string{""}},
+			expErr: true,
+		},
+		{
+			name:   "empty transactional id",
+			in:     acls{txnIDs: []string{""}},
+			expErr: true,
+		},
+		{
+			name: "delegation tokens are deduplicated",
+			in:   acls{tokens: []string{"tok", "tok", "*"}},
+			exp:  acls{tokens: []string{"tok", "*"}},
+		},
+		{
+			name:   "empty delegation token",
+			in:     acls{tokens: []string{""}},
+			expErr: true,
+		},
+		{
+			name:   "delegation token named by owner principal",
+			in:     acls{tokens: []string{"User:alice"}},
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.in.validateResources()
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, test.in)
+		})
+	}
+}
+
+func TestCreateCreationsResources(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		in     acls
+		expErr bool
+	}{
+		{
+			name: "many resources of every kind",
+			in: acls{
+				topics:  []string{"a", "b"},
+				groups:  []string{"g"},
+				txnIDs:  []string{"t1", "t2"},
+				cluster: true,
+			},
+		},
+		{
+			name:   "cluster with a resource name",
+			in:     acls{cluster: true, resourceName: "foo"},
+			expErr: true,
+		},
+		{
+			name:   "resource name without a resource type",
+			in:     acls{resourceName: "foo"},
+			expErr: true,
+		},
+		{
+			name:   "prefixed cluster",
+			in:     acls{cluster: true, resourcePatternType: "prefixed"},
+			expErr: true,
+		},
+		{
+			name: "prefixed topics",
+			in:   acls{topics: []string{"orders."}, resourcePatternType: "prefixed"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a := test.in
+			a.operations = []string{"describe"} // applies to every resource type
+			a.allowPrincipals = []string{"foo"}
+			err := a.validateCreate()
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+		})
+	}
+}
+
+func TestNormalizePrincipal(t *testing.T) {
+	for _, test := range []struct {
+		in     string
+		exp    string
+		expErr bool
+	}{
+		{in: "alice", exp: "User:alice"},
+		{in: "User:alice", exp: "User:alice"},
+		{in: "*", exp: "User:*"},
+		{in: "User:*", exp: "User:*"},
+		{in: "", expErr: true},
+		{in: "User:", expErr: true},
+		{in: "user:alice", expErr: true},
+		{in: "Group:admins", expErr: true},
+	} {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := normalizePrincipal(test.in)
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+			require.Equal(t, test.exp, got)
+		})
+	}
+}
+
+func TestPrincipalNorm(t *testing.T) {
+	var (
+		role = principalNorm{typ: "Role"}
+		raw  = principalNorm{typ: defaultPrincipalType, raw: true}
+	)
+	for _, test := range []struct {
+		name   string
+		n      principalNorm
+		in     string
+		exp    string
+		expErr bool
+	}{
+		{name: "type for bare names", n: role, in: "admins", exp: "Role:admins"},
+		{name: "type for the wildcard", n: role, in: "*", exp: "Role:*"},
+		{name: "principal type accepted", n: role, in: "Role:admins", exp: "Role:admins"},
+		{name: "User still accepted", n: role, in: "User:alice", exp: "User:alice"},
+		{name: "principal type is case sensitive", n: role, in: "role:admins", expErr: true},
+		{name: "other types rejected", n: role, in: "Group:admins", expErr: true},
+
+		{name: "raw keeps any type", n: raw, in: "Group:admins", exp: "Group:admins"},
+		{name: "raw keeps case", n: raw, in: "user:alice", exp: "user:alice"},
+		{name: "raw still prefixes bare names", n: raw, in: "alice", exp: "User:alice"},
+		{name: "raw still needs a name", n: raw, in: "Group:", expErr: true},
+		{name: "raw still needs a type", n: raw, in: ":alice", expErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.n.normalize(test.in)
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+			require.Equal(t, test.exp, got)
+		})
+	}
+
+	for _, typ := range []string{"Role:", "Ro le"} {
+		_, err := (&acls{principalType: typ}).principalNorm()
+		require.Error(t, err, "--principal-type %q", typ)
+	}
+}
+
+func TestSecurityDisabled(t *testing.T) {
+	// SECURITY_DISABLED is error code 54, which is what brokers without
+	// authorization enabled return for every ACL request.
+	const securityDisabledCode = 54
+	disabled := kerr.ErrorForCode(securityDisabledCode)
+
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		switch req := req.(type) {
+		case *kmsg.CreateACLsRequest:
+			resp := req.ResponseKind().(*kmsg.CreateACLsResponse)
+			for range req.Creations {
+				resp.Results = append(resp.Results, kmsg.CreateACLsResponseResult{ErrorCode: securityDisabledCode})
+			}
+			return resp
+		case *kmsg.DescribeACLsRequest:
+			resp := req.ResponseKind().(*kmsg.DescribeACLsResponse)
+			resp.ErrorCode = securityDisabledCode
+			return resp
+		case *kmsg.DeleteACLsRequest:
+			resp := req.ResponseKind().(*kmsg.DeleteACLsResponse)
+			for range req.Filters {
+				resp.Results = append(resp.Results, kmsg.DeleteACLsResponseResult{ErrorCode: securityDisabledCode})
+			}
+			return resp
+		default:
+			t.Errorf("unexpected %s request", kmsg.NameForKey(req.Key()))
+			return req.ResponseKind()
+		}
+	})
+	cl := b.Client()
+	f := kafka.ACLFilter{Topics: []string{"foo"}}
+
+	// list and describe go through describeReqResp, which must print the
+	// hint and leave exiting to the caller.
+	var results []kafka.ListACLsResult
+	stderr := captureStderr(t, func() {
+		_, results = describeReqResp(cl, &config.Params{}, false, false, f, aclSort{})
+	})
+	require.NotEmpty(t, results)
+	require.True(t, securityDisabled(filterErrs(results)...), "list and describe filters")
+	require.Contains(t, stderr, securityDisabledHint)
+
+	deleted, err := kafka.DeleteACLs(context.Background(), cl, f)
+	require.NoError(t, err)
+	var deleteErrs []error
+	for _, d := range deleted {
+		deleteErrs = append(deleteErrs, d.Err)
+	}
+	require.True(t, securityDisabled(deleteErrs...), "delete filters")
+
+	created, err := kafka.CreateACLs(context.Background(), cl, []kmsg.CreateACLsRequestCreation{kmsg.NewCreateACLsRequestCreation()})
+	require.NoError(t, err)
+	require.True(t, securityDisabled(created[0].Err), "create results")
+	require.True(t, securityDisabled(fmt.Errorf("wrapped: %w", disabled)), "wrapped errors")
+
+	require.False(t, securityDisabled(), "no errors")
+	require.False(t, securityDisabled(nil, kerr.ClusterAuthorizationFailed), "other error codes")
+	require.False(t, securityDisabled(errors.New(disabled.Error())), "detection must not rely on the message")
+
+	err = withSecurityDisabledHint(disabled)
+	require.ErrorIs(t, err, kerr.SecurityDisabled)
+	require.Contains(t, err.Error(), "kafka_enable_authorization")
+	require.Equal(t, out.ExitServer, out.ExitCode(err), "the hint must keep the server exit code")
+
+	other := kerr.ClusterAuthorizationFailed
+	require.Equal(t, other, withSecurityDisabledHint(other))
+	require.NoError(t, withSecurityDisabledHint(nil))
+}
+
+// captureStderr returns what fn writes to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	orig := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = orig }()
+
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestValidateHost(t *testing.T) {
+	for _, test := range []struct {
+		host   string
+		expErr bool
+	}{
+		{"*", false},
+		{"10.0.0.1", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"client.example.com", false},
+		{"localhost", false},
+
+		{"", true},
+		{"10.0.0.1:9092", true},
+		{"[::1]", true},
+		{"10.0.0.0/8", true},
+		{"foo bar", true},
+		{"**", true},
+	} {
+		t.Run(test.host, func(t *testing.T) {
+			err := validateHost(test.host)
+			require.Equal(t, test.expErr, err != nil, "got err %v", err)
+		})
+	}
+
+	t.Run("create and filter flags", func(t *testing.T) {
+		a := acls{
+			topics:          []string{"foo"},
+			operations:      []string{"read"},
+			allowPrincipals: []string{"bar"},
+			allowHosts:      []string{"10.0.0.1", "10.0.0.1:9092"},
+		}
+		err := a.validateCreate()
+		require.Error(t, err)
+
+		a = acls{denyHosts: []string{"10.0.0.0/8"}}
+		_, err = a.createDeletionsAndDescribes(false)
+		require.Error(t, err)
+
+		a = acls{listHosts: []string{"10.0.0.0/8"}}
+		_, err = a.createDeletionsAndDescribes(true)
+		require.Error(t, err, "the deprecated list --host must be validated")
+	})
+}
+
+func TestValidateOperation(t *testing.T) {
+	var (
+		read       = kmsg.ACLOperationRead
+		write      = kmsg.ACLOperationWrite
+		create     = kmsg.ACLOperationCreate
+		del        = kmsg.ACLOperationDelete
+		alter      = kmsg.ACLOperationAlter
+		describe   = kmsg.ACLOperationDescribe
+		clusterAct = kmsg.ACLOperationClusterAction
+		descConfs  = kmsg.ACLOperationDescribeConfigs
+		altConfs   = kmsg.ACLOperationAlterConfigs
+		idempotent = kmsg.ACLOperationIdempotentWrite
+	)
+	all := []kmsg.ACLOperation{read, write, create, del, alter, describe, clusterAct, descConfs, altConfs, idempotent}
+	for _, test := range []struct {
+		rt    kmsg.ACLResourceType
+		valid []kmsg.ACLOperation
+	}{
+		{kmsg.ACLResourceTypeTopic, []kmsg.ACLOperation{read, write, create, del, alter, describe, descConfs, altConfs}},
+		{kmsg.ACLResourceTypeGroup, []kmsg.ACLOperation{read, del, describe}},
+		{kmsg.ACLResourceTypeCluster, []kmsg.ACLOperation{create, alter, describe, clusterAct, descConfs, altConfs, idempotent}},
+		{kmsg.ACLResourceTypeTransactionalId, []kmsg.ACLOperation{write, describe}},
+		{kmsg.ACLResourceTypeDelegationToken, []kmsg.ACLOperation{describe}},
+	} {
+		t.Run(test.rt.String(), func(t *testing.T) {
+			require.NoError(t, validateOperation(test.rt, kmsg.ACLOperationAll), "ALL applies to every resource type")
+			require.Error(t, validateOperation(test.rt, kmsg.ACLOperationAny), "ANY is a filter, not an operation")
+			for _, op := range all {
+				isValid := false
+				for _, v := range test.valid {
+					isValid = isValid || v == op
+				}
+				err := validateOperation(test.rt, op)
+				require.Equal(t, isValid, err == nil, "operation %s: got err %v", op, err)
+			}
+		})
+	}
+}
+
+func TestCreateCreationsOperations(t *testing.T) {
+	a := acls{
+		groups:          []string{"g"},
+		operations:      []string{"alter_configs"},
+		allowPrincipals: []string{"foo"},
+	}
+	err := a.validateCreate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "READ, DELETE, DESCRIBE, ALL", "the error should list the valid operations")
+
+	a = acls{
+		groups:          []string{"g"},
+		operations:      []string{"alter_configs"},
+		allowPrincipals: []string{"foo"},
+		force:           true,
+	}
+	err = a.validateCreate()
+	require.NoError(t, err, "--force should allow any operation")
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// The resource pattern types that can be used when creating ACLs, and when
+// filtering ACLs to list or delete.
+var (
+	createPatterns = []string{"literal", "prefixed"}
+	filterPatterns = []string{"any", "match", "literal", "prefixed"}
+)
+
+// completeTopicsTimeout bounds how long topic completion waits for the
+// cluster, so that completion stays responsive if brokers are unreachable.
+const completeTopicsTimeout = 2 * time.Second
+
+// registerCompletions registers shell completion for the operation, resource
+// pattern type, and topic flags of cmd. If filter is true, cmd filters ACLs
+// (list, delete) and "any" and "match" are also valid. Completing --topic
+// queries the cluster for existing topic names.
+func registerCompletions(fs afero.Fs, cmd *cobra.Command, filter bool) {
+	patterns := createPatterns
+	if filter {
+		patterns = filterPatterns
+	}
+	cmd.RegisterFlagCompletionFunc(operationFlag, completeValues(operationNames(filter)))
+	cmd.RegisterFlagCompletionFunc(patternFlag, completeValues(patterns))
+	cmd.RegisterFlagCompletionFunc(topicFlag, func(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return completeTopics(fs, cmd), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func completeValues(values []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// operationNames returns the valid operation names. The "any" operation is
+// only valid when filtering.
+func operationNames(filter bool) []string {
+	var names []string
+	if filter {
+		names = append(names, "any")
+	}
+	for _, op := range []kmsg.ACLOperation{
+		kmsg.ACLOperationAll,
+		kmsg.ACLOperationRead,
+		kmsg.ACLOperationWrite,
+		kmsg.ACLOperationCreate,
+		kmsg.ACLOperationDelete,
+		kmsg.ACLOperationAlter,
+		kmsg.ACLOperationDescribe,
+		kmsg.ACLOperationClusterAction,
+		kmsg.ACLOperationDescribeConfigs,
+		kmsg.ACLOperationAlterConfigs,
+		kmsg.ACLOperationIdempotentWrite,
+	} {
+		names = append(names, strings.ToLower(op.String()))
+	}
+	return names
+}
+
+// completeTopics returns the topics in the cluster, or nothing if the cluster
+// cannot be reached quickly. We never prompt for a SASL password while
+// completing.
+func completeTopics(fs afero.Fs, cmd *cobra.Command) []string {
+	p := config.ParamsFromCommand(cmd)
+	cfg, err := p.Load(fs)
+	if err != nil {
+		return nil
+	}
+	if sasl := cfg.Rpk.KafkaAPI.SASL; sasl != nil && sasl.User != "" && sasl.Password == "" {
+		return nil
+	}
+	cl, err := kafka.NewFranzClient(fs, p, cfg, kgo.DialTimeout(completeTopicsTimeout))
+	if err != nil {
+		return nil
+	}
+	defer cl.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), completeTopicsTimeout)
+	defer cancel()
+	topics, err := kadm.NewClient(cl).ListTopics(ctx)
+	if err != nil {
+		return nil
+	}
+	return topics.Names()
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCompletionValuesParse(t *testing.T) {
+	// Every value we complete must be accepted by the flag it completes.
+	for _, filter := range []bool{false, true} {
+		for _, name := range operationNames(filter) {
+			_, err := kmsg.ParseACLOperation(name)
+			require.NoError(t, err, "completed operation %q does not parse", name)
+		}
+	}
+	require.NotContains(t, operationNames(false), "any")
+	require.Contains(t, operationNames(true), "any")
+
+	for _, name := range filterPatterns {
+		_, err := kmsg.ParseACLResourcePatternType(name)
+		require.NoError(t, err, "completed pattern %q does not parse", name)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// The kinds of findings of 'acl list --find-conflicts'.
+const (
+	findingConflict  = "conflict"
+	findingRedundant = "redundant"
+)
+
+// aclFinding is an ALLOW ACL that has no effect, and the ACLs that cause it:
+// the DENY ACLs that override it, or the ALLOW ACLs that already imply it.
+type aclFinding struct {
+	Kind     string `json:"kind" yaml:"kind"`
+	ACL      acl    `json:"acl" yaml:"acl"`
+	CausedBy []acl  `json:"causedBy" yaml:"causedBy"`
+}
+
+// findConflicts returns every ALLOW ACL that conflicts with a DENY ACL, or
+// that is redundant with other ALLOW ACLs, for the exact same principal,
+// host, and resource. An ALLOW that conflicts is not also reported as
+// redundant. Findings are in the order of acls.
+func findConflicts(acls []acl) []aclFinding {
+	type target struct {
+		principal string
+		host      string
+		t         kmsg.ACLResourceType
+		name      string
+		pattern   kmsg.ACLResourcePatternType
+	}
+	byTarget := make(map[target][]acl)
+	for _, a := range acls {
+		t := target{a.Principal, a.Host, a.ResourceType, a.ResourceName, a.ResourcePatternType}
+		byTarget[t] = append(byTarget[t], a)
+	}
+
+	var findings []aclFinding
+	for _, a := range acls {
+		if a.Permission != kmsg.ACLPermissionTypeAllow {
+			continue
+		}
+		group := byTarget[target{a.Principal, a.Host, a.ResourceType, a.ResourceName, a.ResourcePatternType}]
+
+		var denies []acl
+		for _, d := range group {
+			if d.Permission == kmsg.ACLPermissionTypeDeny && (d.Operation == a.Operation || d.Operation == kmsg.ACLOperationAll) {
+				denies = append(denies, d)
+			}
+		}
+		if len(denies) > 0 {
+			findings = append(findings, aclFinding{findingConflict, a, denies})
+			continue
+		}
+
+		var implies []acl
+		for _, o := range group {
+			if o.Permission != kmsg.ACLPermissionTypeAllow || o.Operation == a.Operation {
+				continue
+			}
+			for _, op := range impliedBy(a.Operation) {
+				if o.Operation == op {
+					implies = append(implies, o)
+					break
+				}
+			}
+		}
+		if len(implies) > 0 {
+			findings = append(findings, aclFinding{findingRedundant, a, implies})
+		}
+	}
+	return findings
+}
+
+// describeReqRespConflicts is describeReqResp for --find-conflicts: we print
+// the findings for the matching ACLs rather than the ACLs. Failed filters are
+// printed to stderr.
+func describeReqRespConflicts(
+	cl *kgo.Client,
+	p *config.Params,
+	f kafka.ACLFilter,
+) {
+	ctx, cancel := kafka.RequestContext(p)
+	defer cancel()
+	results, err := kafka.ListACLs(ctx, cl, f)
+	err = kafka.RequestErr(ctx, err)
+	out.MaybeDie(err, "unable to list ACLs: %v", err)
+	printFailedDescribeFilters(results)
+
+	findings := findConflicts(describedACLs(results))
+	if p.Formatter.IsText() {
+		if len(findings) == 0 {
+			out.Infof("No conflicting or redundant ALLOW ACLs found.")
+		} else {
+			printFindings(os.Stdout, findings)
+		}
+	} else {
+		if findings == nil {
+			findings = []aclFinding{} // print [] rather than null
+		}
+		err = p.Formatter.Print(findings)
+		out.MaybeDie(err, "unable to print findings: %v", err)
+	}
+	printSecurityDisabledHint(filterErrs(results)...)
+	exitIfFiltersFailed(results)
+}
+
+func printFindings(w io.Writer, findings []aclFinding) {
+	var sep string
+	for _, section := range []struct {
+		kind   string
+		header string
+		verb   string
+	}{
+		{findingConflict, "conflicts", "is overridden by"},
+		{findingRedundant, "redundant allows", "is already implied by"},
+	} {
+		var printed bool
+		for _, f := range findings {
+			if f.Kind != section.kind {
+				continue
+			}
+			if !printed {
+				fmt.Fprintf(w, "%s%s\n%s\n", sep, strings.ToUpper(section.header), strings.Repeat("=", len(section.header)))
+				printed, sep = true, "\n"
+			}
+			fmt.Fprintf(w, "%s %s:\n", f.ACL, section.verb)
+			for _, by := range f.CausedBy {
+				fmt.Fprintf(w, "  %s\n", by)
+			}
+		}
+	}
+}
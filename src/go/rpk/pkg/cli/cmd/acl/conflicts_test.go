@@ -0,0 +1,135 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestFindConflicts(t *testing.T) {
+	binding := func(principal, host, topic string, op kmsg.ACLOperation, perm kmsg.ACLPermissionType) acl {
+		return acl{
+			Principal:           principal,
+			Host:                host,
+			ResourceType:        kmsg.ACLResourceTypeTopic,
+			ResourceName:        topic,
+			ResourcePatternType: kmsg.ACLResourcePatternTypeLiteral,
+			Operation:           op,
+			Permission:          perm,
+		}
+	}
+	var (
+		allow = kmsg.ACLPermissionTypeAllow
+		deny  = kmsg.ACLPermissionTypeDeny
+
+		read     = kmsg.ACLOperationRead
+		write    = kmsg.ACLOperationWrite
+		describe = kmsg.ACLOperationDescribe
+		all      = kmsg.ACLOperationAll
+	)
+
+	for _, test := range []struct {
+		name string
+		in   []acl
+		exp  []aclFinding
+	}{
+		{
+			name: "nothing to find",
+			in: []acl{
+				binding("User:a", "*", "foo", read, allow),
+				binding("User:a", "*", "foo", write, deny),
+			},
+		},
+		{
+			name: "deny of the same operation",
+			in: []acl{
+				binding("User:a", "*", "foo", read, allow),
+				binding("User:a", "*", "foo", read, deny),
+			},
+			exp: []aclFinding{{
+				findingConflict,
+				binding("User:a", "*", "foo", read, allow),
+				[]acl{binding("User:a", "*", "foo", read, deny)},
+			}},
+		},
+		{
+			name: "deny all overrides every allow, which are not also redundant",
+			in: []acl{
+				binding("User:a", "*", "foo", read, allow),
+				binding("User:a", "*", "foo", describe, allow),
+				binding("User:a", "*", "foo", all, deny),
+			},
+			exp: []aclFinding{
+				{findingConflict, binding("User:a", "*", "foo", read, allow), []acl{binding("User:a", "*", "foo", all, deny)}},
+				{findingConflict, binding("User:a", "*", "foo", describe, allow), []acl{binding("User:a", "*", "foo", all, deny)}},
+			},
+		},
+		{
+			name: "different principal, host, or topic does not conflict",
+			in: []acl{
+				binding("User:a", "*", "foo", read, allow),
+				binding("User:b", "*", "foo", read, deny),
+				binding("User:a", "10.0.0.1", "foo", read, deny),
+				binding("User:a", "*", "bar", read, deny),
+			},
+		},
+		{
+			name: "redundant allows",
+			in: []acl{
+				binding("User:a", "*", "foo", describe, allow),
+				binding("User:a", "*", "foo", read, allow),
+				binding("User:a", "*", "foo", all, allow),
+			},
+			exp: []aclFinding{
+				{findingRedundant, binding("User:a", "*", "foo", describe, allow), []acl{
+					binding("User:a", "*", "foo", read, allow),
+					binding("User:a", "*", "foo", all, allow),
+				}},
+				{findingRedundant, binding("User:a", "*", "foo", read, allow), []acl{
+					binding("User:a", "*", "foo", all, allow),
+				}},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.exp, findConflicts(test.in))
+		})
+	}
+}
+
+func TestPrintFindings(t *testing.T) {
+	a := acl{"User:a", "*", kmsg.ACLResourceTypeTopic, "foo", kmsg.ACLResourcePatternTypeLiteral, kmsg.ACLOperationRead, kmsg.ACLPermissionTypeAllow}
+	d := a
+	d.Permission = kmsg.ACLPermissionTypeDeny
+	all := a
+	all.Operation = kmsg.ACLOperationAll
+	describe := a
+	describe.Operation = kmsg.ACLOperationDescribe
+
+	var buf bytes.Buffer
+	printFindings(&buf, []aclFinding{
+		{findingRedundant, describe, []acl{all}},
+		{findingConflict, a, []acl{d}},
+	})
+	require.Equal(t, `CONFLICTS
+=========
+ALLOW READ User:a for LITERAL TOPIC "foo" from host * is overridden by:
+  DENY READ User:a for LITERAL TOPIC "foo" from host *
+
+REDUNDANT ALLOWS
+================
+ALLOW DESCRIBE User:a for LITERAL TOPIC "foo" from host * is already implied by:
+  ALLOW ALL User:a for LITERAL TOPIC "foo" from host *
+`, buf.String())
+}
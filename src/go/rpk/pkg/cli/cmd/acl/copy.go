@@ -0,0 +1,163 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"fmt"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/types"
+)
+
+func newCopyCommand(fs afero.Fs) *cobra.Command {
+	var (
+		from string
+		to   string
+		dry  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy the ACLs of one principal to another",
+		Long: `Copy the ACLs of one principal to another.
+
+This command is useful when onboarding a new service account that needs the
+same permissions as an existing one. Every ACL for the --from principal is
+described, and an equivalent ACL is created for the --to principal with the
+same host, resource, resource pattern type, operation, and permission.
+
+Only ACLs that name the --from principal exactly are copied; ACLs for the
+wildcard principal User:* already apply to both principals. ACLs that already
+exist for the --to principal are skipped and reported as already existing, so
+it is safe to run this command more than once.
+
+The --dry-run flag prints the ACLs that would be created and exits without
+creating anything. If the --from principal has no ACLs, nothing is created and
+the command exits with an error.
+
+Copy the ACLs of service account "orders" to "orders-v2":
+    rpk acl copy --from User:orders --to User:orders-v2
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			p := config.ParamsFromCommand(cmd)
+			out.MaybeDieErr(p.Formatter.Validate())
+			cfg, err := p.Load(fs)
+			out.MaybeDie(err, "unable to load config: %v", err)
+
+			from, err = normalizePrincipal(from)
+			out.MaybeDieErr(err)
+			to, err = normalizePrincipal(to)
+			out.MaybeDieErr(err)
+			if from == to {
+				out.Die("--from and --to must be different principals")
+			}
+
+			cl, err := kafka.NewConnectedClient(fs, p, cfg)
+			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
+			defer cl.Close()
+
+			acls, err := principalACLs(cl, p, from)
+			err = withSecurityDisabledHint(err)
+			out.MaybeDie(err, "unable to describe ACLs for %s: %v", from, err)
+			if len(acls) == 0 {
+				out.Die("Principal %s has no ACLs, nothing to copy.", from)
+			}
+			creations := copiedCreations(acls, to)
+
+			if dry {
+				exists, err := existingCreations(cl, p, creations)
+				err = withSecurityDisabledHint(err)
+				out.MaybeDie(err, "unable to check for existing ACLs: %v", err)
+				results := newCreateResults(creations, statusWouldCreate, nil)
+				for i := range creations {
+					if exists[i] {
+						results[i].Status = statusAlreadyExists
+					}
+				}
+				printCreateResults(p.Formatter, results)
+				if p.Formatter.IsText() {
+					fmt.Println()
+					out.Exit("Dry run, exiting.")
+				}
+				return
+			}
+			createEach(cl, p, creations, true, nil)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "Principal to copy the ACLs of (required)")
+	cmd.Flags().StringVar(&to, "to", "", "Principal to create the copied ACLs for (required)")
+	cmd.Flags().BoolVarP(&dry, "dry-run", "d", false, "Dry run: print the ACLs that would be created and exit without creating")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+// principalACLs returns every ACL, allowed or denied, for the exact principal,
+// sorted and without duplicates. An empty principal returns the ACLs of every
+// principal.
+func principalACLs(cl *kgo.Client, p *config.Params, principal string) ([]acl, error) {
+	req := kmsg.NewPtrDescribeACLsRequest()
+	req.ResourceType = kmsg.ACLResourceTypeAny
+	req.ResourcePatternType = kmsg.ACLResourcePatternTypeAny
+	if principal != "" {
+		req.Principal = kmsg.StringPtr(principal)
+	}
+	req.Operation = kmsg.ACLOperationAny
+	req.PermissionType = kmsg.ACLPermissionTypeAny
+
+	ctx, cancel := kafka.RequestContext(p)
+	defer cancel()
+	resp, err := req.RequestWith(ctx, cl)
+	err = kafka.RequestErr(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	acls := describeResponseACLs(resp)
+	types.DistinctInPlace(&acls)
+	return acls, nil
+}
+
+// copiedCreations returns a creation for every ACL, with the principal
+// replaced by the given principal.
+func copiedCreations(acls []acl, principal string) []kmsg.CreateACLsRequestCreation {
+	creations := make([]kmsg.CreateACLsRequestCreation, 0, len(acls))
+	for _, a := range acls {
+		c := kmsg.NewCreateACLsRequestCreation()
+		c.ResourceType = a.ResourceType
+		c.ResourceName = a.ResourceName
+		c.ResourcePatternType = a.ResourcePatternType
+		c.Operation = a.Operation
+		c.Principal = principal
+		c.Host = a.Host
+		c.PermissionType = a.Permission
+		creations = append(creations, c)
+	}
+	return creations
+}
+
+// describeResponseACLs flattens every ACL in a DescribeACLs response.
+func describeResponseACLs(resp *kmsg.DescribeACLsResponse) []acl {
+	described := kafka.DescribedACLs(resp)
+	acls := make([]acl, 0, len(described))
+	for _, a := range described {
+		acls = append(acls, acl(a))
+	}
+	return acls
+}
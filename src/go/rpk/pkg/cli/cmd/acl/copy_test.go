@@ -0,0 +1,56 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCopiedCreations(t *testing.T) {
+	in := []acl{
+		{
+			Principal:           "User:old",
+			Host:                "10.0.0.1",
+			ResourceType:        kmsg.ACLResourceTypeTopic,
+			ResourceName:        "orders.",
+			ResourcePatternType: kmsg.ACLResourcePatternTypePrefixed,
+			Operation:           kmsg.ACLOperationRead,
+			Permission:          kmsg.ACLPermissionTypeAllow,
+		},
+		{
+			Principal:           "User:old",
+			Host:                "*",
+			ResourceType:        kmsg.ACLResourceTypeCluster,
+			ResourceName:        kafkaCluster,
+			ResourcePatternType: kmsg.ACLResourcePatternTypeLiteral,
+			Operation:           kmsg.ACLOperationAlter,
+			Permission:          kmsg.ACLPermissionTypeDeny,
+		},
+	}
+	got := copiedCreations(in, "User:new")
+	require.Len(t, got, len(in))
+	for i, c := range got {
+		exp := in[i]
+		exp.Principal = "User:new"
+		require.Equal(t, exp, acl{
+			c.Principal,
+			c.Host,
+			c.ResourceType,
+			c.ResourceName,
+			c.ResourcePatternType,
+			c.Operation,
+			c.PermissionType,
+		})
+	}
+	require.Empty(t, copiedCreations(nil, "User:new"))
+}
@@ -11,18 +11,28 @@ package acl
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/twmb/types"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
 func newCreateCommand(fs afero.Fs) *cobra.Command {
-	var a acls
+	var (
+		a           acls
+		fromFile    string
+		ifNotExists bool
+		strict      bool
+	)
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create ACLs",
@@ -37,54 +47,654 @@ principal is allowed access from all hosts. The wildcard principal '*' allows
 all principals. At least one principal, one host, one resource, and one
 operation is required to create a single ACL.
 
+Resource names are matched literally by default. Use --resource-pattern-type
+prefixed to create ACLs for every resource name starting with the given names:
+--topic orders. --resource-pattern-type prefixed matches "orders.eu" and
+"orders.us".
+
+Any number of resources can be specified in one invocation: --topic, --group,
+--transactional-id, and --delegation-token can be repeated (or given comma
+separated names), and can be combined with each other and with --cluster. Every resource gets its
+own ACL for every operation, and all ACLs are created in a single request.
+The cluster resource is always literal, so --cluster cannot be used with
+--resource-pattern-type prefixed.
+
+Both --allow-principal and --deny-principal can be used in one invocation.
+Every allowed principal is allowed, and every denied principal is denied, the
+operations on the resources; --allow-host only applies to allowed principals
+and --deny-host only to denied principals. If a principal is both allowed and
+denied the same operation, the deny takes precedence and the principal has no
+access.
+
+To restrict an ACL to specific clients, use --allow-host or --deny-host, which
+can be repeated: every principal gets one ACL per host. Without a host, the ACL
+applies to all hosts ('*'). Hosts must be '*', an IP address, or a hostname.
+Brokers match ACL hosts against the IP address of the connecting client, so
+rpk warns if a host is not an IP address: such an ACL never matches.
+
+The operation 'all' expands to every operation that applies to each resource
+type, and rpk prints the operations it expanded to: for a topic, that is READ,
+WRITE, CREATE, DELETE, ALTER, DESCRIBE, DESCRIBE_CONFIGS, and ALTER_CONFIGS,
+while the cluster additionally gets CLUSTER_ACTION and IDEMPOTENT_WRITE.
+Operations can be combined with 'all'. An entry in a --from-file file with
+operation all creates a single ACL for the ALL operation.
+
+An operation that does not apply to a resource type, such as alter_configs on
+a group, is accepted by brokers but never checked, so such an ACL has no
+effect. rpk rejects these combinations, listing the operations that apply to
+the resource type; see 'rpk acl --help-operations'. Use --force to create the
+ACLs anyway. Entries in a --from-file file, such as a file from 'rpk acl
+export', are created with a warning instead, so that an export restores every
+ACL that the cluster had.
+
 Allow all permissions to user bar on topic "foo" and group "g":
     --allow-principal bar --operation all --topic foo --group g
 Allow read permissions to all users on topics biz and baz:
     --allow-principal '*' --operation read --topic biz,baz
+Allow describe permissions to user bar on topics foo and biz and the cluster:
+    --allow-principal bar --operation describe --topic foo --topic biz --cluster
+Allow user bar to read topic foo only from 10.0.0.1 and 10.0.0.2:
+    --allow-principal bar --operation read --topic foo --allow-host 10.0.0.1,10.0.0.2
 Allow write permissions to user buzz to transactional id "txn":
     --allow-principal User:buzz --operation write --transactional-id txn
+Allow user buzz to describe the delegation token with ID "tok":
+    --allow-principal User:buzz --operation describe --delegation-token tok
+Allow reading all topics prefixed with "orders.", except for user biz:
+    --allow-principal '*' --deny-principal biz --operation read --topic orders. --resource-pattern-type prefixed
+
+ACLs can also be created in bulk from a yaml or json file with --from-file.
+The file contains a list of ACLs, each with a principal, host, resourceType,
+resourceName, patternType, operation, and permission. The host defaults to '*'
+and the pattern type defaults to literal. Every entry is validated before any
+ACL is created, and all entries are created in a single request. The output
+of 'rpk acl list --format yaml' or the file written by 'rpk acl export' can be
+used as input:
+
+    - principal: User:bar
+      host: '*'
+      resourceType: topic
+      resourceName: foo
+      patternType: literal
+      operation: read
+      permission: allow
+
+The result of every ACL is printed with a status: "created", "failed" (with the
+error the broker returned for that ACL), or "already exists". With --format
+json or yaml, the results are printed as a list of objects with the ACL
+fields, a status, and an error if the ACL failed. If some ACLs fail and others
+are created, create exits with code 4; if every ACL fails, it exits with code
+3.
+
+To grant the same ACLs to many principals, such as a batch of new service
+accounts, list the principals in a file with --principal-file, one per line
+(blank lines and lines starting with # are ignored). Every principal in the
+file is allowed, exactly as if it were passed with --allow-principal, and the
+file can be combined with --allow-principal: all ACLs for every principal,
+resource, and operation are created in a single request. Every principal is
+validated before anything is created, principals listed more than once (in
+the file or the flag) are warned about and used once, and the results are
+grouped by principal in the order the principals are listed.
+
+If the same ACL is requested more than once, such as an entry that is listed
+twice in a --from-file file, rpk warns about every duplicate (naming the file
+entries) and creates the ACL once. Two ACLs are the same if their principal,
+host, resource type, resource name, pattern type, operation, and permission
+are all equal. With --strict, duplicates are an error and nothing is created.
+
+rpk checks the ACL API versions that the broker supports when connecting, and
+warns if the broker and rpk have no version of an ACL API in common. With
+--fail-incompatible-versions, this is an error instead. Prefixed ACLs need a
+broker with resource pattern types (v1 of the ACL APIs); against an older
+broker, creating them fails before anything is created.
+
+With --if-not-exists, the existing ACLs for every resource are described
+first, and any ACL that already exists with the exact same principal, host,
+resource, operation, and permission is not created again and is reported as
+"already exists". This makes it safe to repeatedly apply the same flags or
+file. With --from-file, every entry is checked independently.
+
+If no create flags are specified and rpk is run in a terminal, rpk prompts for
+the principal, permission, host, resource, and operations of the ACL, shows the
+ACLs that will be created, and asks for confirmation before creating them.
+Declining creates nothing. Connection flags such as --brokers can still be
+used. If any create flag is specified, or stdin or stdout is not a terminal,
+nothing is prompted for.
+
+If rpk is interrupted (Ctrl-C) while creating ACLs, the request is canceled and
+rpk checks and prints which ACLs exist, so you know what was created; creating
+again with --if-not-exists creates the rest. Interrupting a second time exits
+immediately. An interrupted create exits with code 130.
 `,
 
 		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, _ []string) {
 			p := config.ParamsFromCommand(cmd)
+			out.MaybeDieErr(p.Formatter.Validate())
 			cfg, err := p.Load(fs)
 			out.MaybeDie(err, "unable to load config: %v", err)
 
-			adm, err := kafka.NewAdmin(fs, p, cfg)
-			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
-			defer adm.Close()
+			if fromFile != "" {
+				norm, err := a.principalNorm()
+				out.MaybeDieErr(err)
+				createFromFile(cmd, fs, p, cfg, fromFile, norm, ifNotExists, a.force, strict)
+				return
+			}
+
+			wizard := useWizard(cmd)
+			if wizard {
+				a, err = runWizard()
+				out.MaybeDie(err, "unable to prompt for the ACL: %v", err)
+			}
+			if a.principalFile != "" {
+				dups, err := a.addPrincipalFile(fs)
+				out.MaybeDieErr(err)
+				for _, d := range dups {
+					out.Warnf("warning: %s; using it once", d)
+				}
+			}
 
-			b, err := a.createCreations()
+			// validateCreate validates and parses the flags; we
+			// create from the expanded creations so that every
+			// ACL's result can be reported.
+			err = a.validateCreate()
 			out.MaybeDieErr(err)
-			results, err := adm.CreateACLs(context.Background(), b)
-			out.MaybeDie(err, "unable to create ACLs: %v", err)
-			if len(results) == 0 {
-				fmt.Println("Specified flags created no ACLs.")
+			a.warnHostnames()
+			creations := a.creations()
+			if len(creations) == 0 {
+				out.Infof("Specified flags created no ACLs.")
 				return
 			}
-			types.Sort(results)
-
-			tw := out.NewTable(headersWithError...)
-			defer tw.Flush()
-			for _, c := range results {
-				tw.PrintStructFields(aclWithMessage{
-					c.Principal,
-					c.Host,
-					c.Type,
-					c.Name,
-					c.Pattern,
-					c.Operation,
-					c.Permission,
-					kafka.ErrMessage(c.Err),
-				})
+			creations, _ = removeDuplicates(creations, false, strict)
+			if a.principalFile != "" {
+				sortByPrincipal(creations, a.allowPrincipals)
+			}
+			if a.hasOperationAll() {
+				a.printExpandedOperations()
 			}
+			if wizard {
+				fmt.Println()
+				printCreateResults(out.Formatter{}, newCreateResults(creations, statusWouldCreate, nil))
+				fmt.Println()
+				confirmed, err := out.Confirm("Create the above ACLs?")
+				out.MaybeDie(err, "unable to confirm creation: %v", err)
+				if !confirmed {
+					out.Exit("ACL creation canceled, nothing was created.")
+				}
+			}
+			cl, err := kafka.NewConnectedClient(fs, p, cfg)
+			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
+			defer cl.Close()
+			out.MaybeDieErr(kafka.CheckBrokerACLVersions(p, cl))
+			createEach(cl, p, creations, ifNotExists, nil)
 		},
 	}
 	a.addCreateFlags(cmd)
+	cmd.Flags().StringVar(&fromFile, fromFileFlag, "", "Create the ACLs listed in this yaml or json file")
+	cmd.Flags().StringVar(&a.principalFile, principalFileFlag, "", "File listing one principal to allow per line, merged with --allow-principal")
+	cmd.Flags().BoolVar(&ifNotExists, "if-not-exists", false, "Skip creating ACLs that already exist, reporting them as already existing")
+	cmd.Flags().BoolVar(&a.force, "force", false, "Create ACLs even if an operation does not apply to the resource type (with --from-file, do not warn about such entries)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Fail if the same ACL is requested more than once, rather than warning and creating it once")
+	cmd.Flags().Bool(config.FlagFailIncompatibleVersions, false, "Fail if the broker's ACL API versions are incompatible with rpk, rather than warning")
+	registerCompletions(fs, cmd, false)
 	return cmd
 }
 
+// createFromFile creates every ACL in the file and prints the result for each
+// entry.
+func createFromFile(
+	cmd *cobra.Command, fs afero.Fs, p *config.Params, cfg *config.Config, file string, norm principalNorm, ifNotExists, force, strict bool,
+) {
+	var conflicting []string
+	for _, f := range []string{
+		resourceFlag, resourceNameFlag, namePatternFlag,
+		topicFlag, groupFlag, clusterFlag, txnIDFlag, tokenFlag, patternFlag, operationFlag,
+		allowPrincipalFlag, allowHostFlag, denyPrincipalFlag, denyHostFlag, principalFileFlag,
+	} {
+		if cmd.Flags().Changed(f) {
+			conflicting = append(conflicting, "--"+f)
+		}
+	}
+	if len(conflicting) > 0 {
+		out.Die("--%s cannot be used with %s", fromFileFlag, strings.Join(conflicting, ", "))
+	}
+
+	creations, err := parseACLFile(fs, file, norm, force)
+	out.MaybeDieErr(err)
+
+	cl, err := kafka.NewConnectedClient(fs, p, cfg)
+	out.MaybeDie(err, "unable to initialize kafka client: %v", err)
+	defer cl.Close()
+	out.MaybeDieErr(kafka.CheckBrokerACLVersions(p, cl))
+	creations, entries := removeDuplicates(creations, true, strict)
+	createEach(cl, p, creations, ifNotExists, entries)
+}
+
+// addPrincipalFile implements --principal-file: every principal in the file
+// is added to the allowed principals, after those from --allow-principal.
+// Lines are trimmed, and blank lines and lines starting with # are ignored.
+// Every principal is validated first, and all invalid lines are returned in
+// one error. Principals listed more than once are kept once, and each
+// duplicate is returned to be warned about.
+func (a *acls) addPrincipalFile(fs afero.Fs) (dups []string, err error) {
+	raw, err := afero.ReadFile(fs, a.principalFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read --%s: %v", principalFileFlag, err)
+	}
+
+	type listed struct {
+		principal string
+		where     string
+	}
+	norm, err := a.principalNorm()
+	if err != nil {
+		return nil, err
+	}
+	var all []listed
+	for _, p := range a.allowPrincipals {
+		normalized, err := norm.normalize(p)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, listed{normalized, "--" + allowPrincipalFlag})
+	}
+	var (
+		inFile  int
+		invalid []string
+	)
+	for i, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		inFile++
+		where := fmt.Sprintf("%s:%d", a.principalFile, i+1)
+		normalized, err := norm.normalize(line)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", where, err))
+			continue
+		}
+		all = append(all, listed{normalized, where})
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid principals in --%s, nothing was created:\n  %s", principalFileFlag, strings.Join(invalid, "\n  "))
+	}
+	if inFile == 0 {
+		return nil, fmt.Errorf("--%s %s contains no principals", principalFileFlag, a.principalFile)
+	}
+
+	first := make(map[string]string)
+	a.allowPrincipals = nil
+	for _, l := range all {
+		if where, seen := first[l.principal]; seen {
+			dups = append(dups, fmt.Sprintf("principal %s from %s duplicates %s", l.principal, l.where, where))
+			continue
+		}
+		first[l.principal] = l.where
+		a.allowPrincipals = append(a.allowPrincipals, l.principal)
+	}
+	return dups, nil
+}
+
+// sortByPrincipal stably sorts creations by the order of their principal in
+// principals, so that results are grouped per principal. Principals that are
+// not listed, i.e. denied principals, sort last.
+func sortByPrincipal(creations []kmsg.CreateACLsRequestCreation, principals []string) {
+	order := make(map[string]int, len(principals))
+	for i, p := range principals {
+		order[p] = i
+	}
+	idx := func(p string) int {
+		if i, ok := order[p]; ok {
+			return i
+		}
+		return len(principals)
+	}
+	sort.SliceStable(creations, func(i, j int) bool {
+		return idx(creations[i].Principal) < idx(creations[j].Principal)
+	})
+}
+
+// The status of every ACL in a create, as printed in the Status column.
+const (
+	statusCreated       = "created"
+	statusFailed        = "failed"
+	statusAlreadyExists = "already exists"
+	statusWouldCreate   = "would be created" // dry runs
+	statusExists        = "exists"           // checked after an interrupt
+	statusNotCreated    = "not created"      // checked after an interrupt
+)
+
+// createResult is the outcome of creating one ACL. With --format json or
+// yaml, the ACL fields are printed inline next to the status, the error (if
+// any), and the entry index when creating from a file.
+type createResult struct {
+	Entry  *int `json:"entry,omitempty" yaml:"entry,omitempty"`
+	acl    `yaml:",inline"`
+	Status string `json:"status" yaml:"status"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// newCreateResults returns a result for every creation with the given initial
+// status. If entries is non-nil, every result is numbered with its entry.
+func newCreateResults(creations []kmsg.CreateACLsRequestCreation, status string, entries []int) []createResult {
+	results := make([]createResult, len(creations))
+	for i, c := range creations {
+		results[i] = createResult{
+			acl:    creationACL(c),
+			Status: status,
+		}
+		if entries != nil {
+			results[i].Entry = &entries[i]
+		}
+	}
+	return results
+}
+
+// creationACL returns the ACL that a creation creates.
+func creationACL(c kmsg.CreateACLsRequestCreation) acl {
+	return acl(kafka.CreationACL(c))
+}
+
+// removeDuplicates removes ACLs that are requested more than once, keeping
+// the first of each, and warns about every duplicate. Two ACLs are the same
+// if their principal, host, resource type, resource name, pattern type,
+// operation, and permission are all equal. If numbered, creations are file
+// entries: the warnings name the entries, and the entries of the kept
+// creations are returned. If strict, any duplicate is fatal.
+func removeDuplicates(creations []kmsg.CreateACLsRequestCreation, numbered, strict bool) ([]kmsg.CreateACLsRequestCreation, []int) {
+	var (
+		first   = make(map[acl]int)
+		kept    []kmsg.CreateACLsRequestCreation
+		entries []int
+		dups    []string
+	)
+	if numbered {
+		entries = []int{} // non-nil: number the results
+	}
+	for i, c := range creations {
+		a := creationACL(c)
+		if j, seen := first[a]; seen {
+			if numbered {
+				dups = append(dups, fmt.Sprintf("entry %d duplicates entry %d: %s", i, j, a))
+			} else {
+				dups = append(dups, fmt.Sprintf("duplicate ACL: %s", a))
+			}
+			continue
+		}
+		first[a] = i
+		kept = append(kept, c)
+		if numbered {
+			entries = append(entries, i)
+		}
+	}
+	if len(dups) == 0 {
+		return creations, entries
+	}
+	if strict {
+		out.Die("ACLs are requested more than once (--strict):\n  %s", strings.Join(dups, "\n  "))
+	}
+	for _, d := range dups {
+		out.Warnf("warning: %s; creating it once", d)
+	}
+	out.Warnf("")
+	return kept, entries
+}
+
+// createEach creates every ACL in creations in one CreateACLs request and
+// prints the result the broker returned for each, with its entry if entries
+// is non-nil. If ifNotExists is true, ACLs that already exist are skipped and
+// reported as already existing. If any ACL fails, this exits after all
+// results are printed, with out.ExitPartial if some ACLs were created.
+func createEach(
+	cl *kgo.Client,
+	p *config.Params,
+	creations []kmsg.CreateACLsRequestCreation,
+	ifNotExists bool,
+	entries []int,
+) {
+	exists := make([]bool, len(creations))
+	if ifNotExists {
+		var err error
+		exists, err = existingCreations(cl, p, creations)
+		err = withSecurityDisabledHint(err)
+		out.MaybeDie(err, "unable to check for existing ACLs: %v", err)
+	}
+
+	// We only send the ACLs that do not exist, and map each result back
+	// to its creation.
+	var (
+		send []kmsg.CreateACLsRequestCreation
+		sent []int
+	)
+	results := newCreateResults(creations, statusCreated, entries)
+	for i, c := range creations {
+		if exists[i] {
+			results[i].Status = statusAlreadyExists
+			continue
+		}
+		send = append(send, c)
+		sent = append(sent, i)
+	}
+	var (
+		errs    []error
+		details []out.ErrorDetail
+	)
+	if len(send) > 0 {
+		ctx, cancel := kafka.RequestContext(p)
+		defer cancel()
+		created, err := kafka.CreateACLs(ctx, cl, send)
+		err = kafka.RequestErr(ctx, err)
+		if errors.Is(err, out.ErrInterrupted) {
+			reportInterruptedCreate(cl, p, creations, exists, entries)
+		}
+		out.MaybeDie(err, "unable to create ACLs: %v", err)
+		for i, r := range created {
+			if r.Err == nil {
+				continue
+			}
+			errs = append(errs, r.Err)
+			msg := kafka.ErrMessage(r.Err)
+			if r.Message != "" {
+				msg = fmt.Sprintf("%s: %s", msg, r.Message)
+			}
+			results[sent[i]].Status = statusFailed
+			results[sent[i]].Error = msg
+			details = append(details, out.ErrorDetail{Item: results[sent[i]], Error: msg})
+		}
+	}
+
+	printCreateResults(p.Formatter, results)
+	if len(details) > 0 {
+		printSecurityDisabledHint(errs...)
+		exitIfFailed(fmt.Sprintf("%d of %d ACLs failed to be created", len(details), len(send)), details, len(send))
+	}
+}
+
+// reportInterruptedCreate is called if we were interrupted after sending the
+// request to create ACLs, in which case the cluster may have created some or
+// all of them. We check which ACLs exist now, without the canceled context,
+// print the state of every ACL, and exit with out.ExitInterrupted. The check
+// is bounded by --request-timeout, and a second interrupt exits immediately.
+func reportInterruptedCreate(
+	cl *kgo.Client,
+	p *config.Params,
+	creations []kmsg.CreateACLsRequestCreation,
+	existed []bool,
+	entries []int,
+) {
+	fmt.Fprintln(os.Stderr, "Checking which ACLs were created before the interrupt...")
+	now, err := existingCreations(cl, p.WithContext(context.Background()), creations)
+	if err != nil {
+		out.DieCode(out.ExitInterrupted, "Interrupted while creating ACLs, and unable to check which ACLs were created: %v; use 'rpk acl list' to check.", err)
+	}
+	var (
+		results = newCreateResults(creations, statusNotCreated, entries)
+		exist   int
+	)
+	for i := range creations {
+		switch {
+		case existed[i]:
+			results[i].Status = statusAlreadyExists
+		case now[i]:
+			results[i].Status = statusExists
+		}
+		if now[i] {
+			exist++
+		}
+	}
+	printCreateResults(p.Formatter, results)
+	out.DieCode(out.ExitInterrupted, "\nInterrupted: %d of %d ACLs exist, %d were not created.", exist, len(creations), len(creations)-exist)
+}
+
+// printCreateResults prints every create result, as a table or per the
+// formatter. Results are numbered with an Entry column if they have entries.
+func printCreateResults(f out.Formatter, results []createResult) {
+	if !f.IsText() {
+		err := f.Print(results)
+		out.MaybeDie(err, "unable to print results: %v", err)
+		return
+	}
+	numbered := len(results) > 0 && results[0].Entry != nil
+	header := append(append([]string(nil), headers...), "Status", "Error")
+	if numbered {
+		header = append([]string{"Entry"}, header...)
+	}
+	tw := out.NewTable(header...)
+	defer tw.Flush()
+	for _, r := range results {
+		row := []interface{}{
+			r.Principal,
+			r.Host,
+			r.ResourceType,
+			r.ResourceName,
+			r.ResourcePatternType,
+			r.Operation,
+			r.Permission,
+			r.Status,
+			r.Error,
+		}
+		if numbered {
+			row = append([]interface{}{*r.Entry}, row...)
+		}
+		tw.Print(row...)
+	}
+}
+
+// existingCreations returns which of the creations already exist, per
+// kafka.ExistingACLs, with each describe bounded by --request-timeout.
+func existingCreations(
+	cl *kgo.Client, p *config.Params, creations []kmsg.CreateACLsRequestCreation,
+) ([]bool, error) {
+	ctx, cancel := kafka.MultiRequestContext(p)
+	defer cancel()
+	exists, err := kafka.ExistingACLs(ctx, cl, creations)
+	return exists, kafka.RequestErr(ctx, err)
+}
+
+// creations expands the flag specified ACLs into one creation per ACL, the
+// same way the ACL builder does when creating. This must be called after a
+// successful validateCreate.
+func (a *acls) creations() []kmsg.CreateACLsRequestCreation {
+	var clusters []string
+	if a.cluster {
+		clusters = []string{kafkaCluster}
+	}
+	defaultHosts := func(principals, hosts []string) []string {
+		if len(principals) > 0 && len(hosts) == 0 {
+			return []string{"*"}
+		}
+		return hosts
+	}
+	var creations []kmsg.CreateACLsRequestCreation
+	for _, typeNames := range []struct {
+		t     kmsg.ACLResourceType
+		names []string
+	}{
+		{kmsg.ACLResourceTypeTopic, a.topics},
+		{kmsg.ACLResourceTypeGroup, a.groups},
+		{kmsg.ACLResourceTypeCluster, clusters},
+		{kmsg.ACLResourceTypeTransactionalId, a.txnIDs},
+		{kmsg.ACLResourceTypeDelegationToken, a.tokens},
+	} {
+		for _, name := range typeNames.names {
+			for _, op := range a.createOperations(typeNames.t) {
+				for _, perm := range []struct {
+					principals []string
+					hosts      []string
+					permType   kmsg.ACLPermissionType
+				}{
+					{a.allowPrincipals, defaultHosts(a.allowPrincipals, a.allowHosts), kmsg.ACLPermissionTypeAllow},
+					{a.denyPrincipals, defaultHosts(a.denyPrincipals, a.denyHosts), kmsg.ACLPermissionTypeDeny},
+				} {
+					for _, principal := range perm.principals {
+						for _, host := range perm.hosts {
+							c := kmsg.NewCreateACLsRequestCreation()
+							c.ResourceType = typeNames.t
+							c.ResourceName = name
+							c.ResourcePatternType = a.parsed.pattern
+							c.Operation = op
+							c.Principal = principal
+							c.Host = host
+							c.PermissionType = perm.permType
+							creations = append(creations, c)
+						}
+					}
+				}
+			}
+		}
+	}
+	return creations
+}
+
+// hasOperationAll returns whether ALL is one of the parsed operations.
+func (a *acls) hasOperationAll() bool {
+	for _, op := range a.parsed.operations {
+		if op == kmsg.ACLOperationAll {
+			return true
+		}
+	}
+	return false
+}
+
+// createOperations returns the operations to create ACLs for on resources of
+// the given type. ALL is expanded to every operation that applies to the
+// resource type (see resourceOperations); any other operation is kept as is.
+func (a *acls) createOperations(rt kmsg.ACLResourceType) []kmsg.ACLOperation {
+	var (
+		ops  []kmsg.ACLOperation
+		seen = make(map[kmsg.ACLOperation]bool)
+	)
+	add := func(op kmsg.ACLOperation) {
+		if !seen[op] {
+			seen[op] = true
+			ops = append(ops, op)
+		}
+	}
+	for _, op := range a.parsed.operations {
+		if op != kmsg.ACLOperationAll {
+			add(op)
+			continue
+		}
+		for _, expanded := range resourceOperations[rt] {
+			add(expanded)
+		}
+	}
+	return ops
+}
+
+// printExpandedOperations prints the operations that ALL expanded to for
+// every resource type that ACLs are being created for.
+func (a *acls) printExpandedOperations() {
+	for _, rt := range a.usedResourceTypes() {
+		var names []string
+		for _, op := range a.createOperations(rt) {
+			names = append(names, op.String())
+		}
+		out.Warnf("Operation ALL for %s expanded to: %s", rt, strings.Join(names, ", "))
+	}
+	out.Warnf("")
+}
+
 func (a *acls) addCreateFlags(cmd *cobra.Command) {
 	a.addDeprecatedFlags(cmd)
 
@@ -92,13 +702,15 @@ func (a *acls) addCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&a.groups, groupFlag, nil, "Group to grant ACLs for (repeatable)")
 	cmd.Flags().BoolVar(&a.cluster, clusterFlag, false, "Whether to grant ACLs to the cluster")
 	cmd.Flags().StringSliceVar(&a.txnIDs, txnIDFlag, nil, "Transactional IDs to grant ACLs for (repeatable)")
+	cmd.Flags().StringSliceVar(&a.tokens, tokenFlag, nil, "Delegation token IDs to grant ACLs for (repeatable)")
 
 	cmd.Flags().StringVar(&a.resourcePatternType, patternFlag, "literal", "Pattern to use when matching resource names (literal or prefixed)")
 
-	cmd.Flags().StringSliceVar(&a.operations, operationFlag, nil, "Operation to grant (repeatable)")
+	cmd.Flags().StringSliceVar(&a.operations, operationFlag, nil, "Operation to grant (repeatable); all grants every operation that applies to each resource")
 
 	cmd.Flags().StringSliceVar(&a.allowPrincipals, allowPrincipalFlag, nil, "Principals for which these permissions will be granted (repeatable)")
 	cmd.Flags().StringSliceVar(&a.allowHosts, allowHostFlag, nil, "Hosts from which access will be granted (repeatable)")
 	cmd.Flags().StringSliceVar(&a.denyPrincipals, denyPrincipalFlag, nil, "Principal for which these permissions will be denied (repeatable)")
 	cmd.Flags().StringSliceVar(&a.denyHosts, denyHostFlag, nil, "Hosts from from access will be denied (repeatable)")
+	a.addPrincipalTypeFlags(cmd)
 }
@@ -0,0 +1,292 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCreations(t *testing.T) {
+	creation := func(principal, host string, rt kmsg.ACLResourceType, name string, op kmsg.ACLOperation, perm kmsg.ACLPermissionType) kmsg.CreateACLsRequestCreation {
+		c := kmsg.NewCreateACLsRequestCreation()
+		c.Principal = principal
+		c.Host = host
+		c.ResourceType = rt
+		c.ResourceName = name
+		c.ResourcePatternType = kmsg.ACLResourcePatternTypeLiteral
+		c.Operation = op
+		c.PermissionType = perm
+		return c
+	}
+
+	a := acls{
+		topics:          []string{"foo"},
+		cluster:         true,
+		operations:      []string{"read", "describe"},
+		allowPrincipals: []string{"bar"},
+		denyPrincipals:  []string{"User:baz"},
+		denyHosts:       []string{"10.0.0.1", "10.0.0.2"},
+		force:           true, // read does not apply to the cluster
+	}
+	err := a.validateCreate()
+	require.NoError(t, err)
+
+	var (
+		topic   = kmsg.ACLResourceTypeTopic
+		cluster = kmsg.ACLResourceTypeCluster
+		read    = kmsg.ACLOperationRead
+		desc    = kmsg.ACLOperationDescribe
+		allow   = kmsg.ACLPermissionTypeAllow
+		deny    = kmsg.ACLPermissionTypeDeny
+	)
+	exp := []kmsg.CreateACLsRequestCreation{
+		creation("User:bar", "*", topic, "foo", read, allow),
+		creation("User:baz", "10.0.0.1", topic, "foo", read, deny),
+		creation("User:baz", "10.0.0.2", topic, "foo", read, deny),
+		creation("User:bar", "*", topic, "foo", desc, allow),
+		creation("User:baz", "10.0.0.1", topic, "foo", desc, deny),
+		creation("User:baz", "10.0.0.2", topic, "foo", desc, deny),
+		creation("User:bar", "*", cluster, kafkaCluster, read, allow),
+		creation("User:baz", "10.0.0.1", cluster, kafkaCluster, read, deny),
+		creation("User:baz", "10.0.0.2", cluster, kafkaCluster, read, deny),
+		creation("User:bar", "*", cluster, kafkaCluster, desc, allow),
+		creation("User:baz", "10.0.0.1", cluster, kafkaCluster, desc, deny),
+		creation("User:baz", "10.0.0.2", cluster, kafkaCluster, desc, deny),
+	}
+	require.Equal(t, exp, a.creations())
+}
+
+func TestCreationsOperationAll(t *testing.T) {
+	a := acls{
+		topics:          []string{"foo"},
+		groups:          []string{"g"},
+		operations:      []string{"all", "describe"},
+		allowPrincipals: []string{"bar"},
+	}
+	err := a.validateCreate()
+	require.NoError(t, err)
+	require.True(t, a.hasOperationAll())
+
+	got := make(map[kmsg.ACLResourceType][]kmsg.ACLOperation)
+	for _, c := range a.creations() {
+		require.NotEqual(t, kmsg.ACLOperationAll, c.Operation, "ALL should be expanded")
+		got[c.ResourceType] = append(got[c.ResourceType], c.Operation)
+	}
+	require.Equal(t, map[kmsg.ACLResourceType][]kmsg.ACLOperation{
+		kmsg.ACLResourceTypeTopic: resourceOperations[kmsg.ACLResourceTypeTopic],
+		kmsg.ACLResourceTypeGroup: resourceOperations[kmsg.ACLResourceTypeGroup],
+	}, got, "ALL should expand per resource type, without duplicating describe")
+
+	explicit := acls{
+		topics:          []string{"foo"},
+		operations:      []string{"read"},
+		allowPrincipals: []string{"bar"},
+	}
+	err = explicit.validateCreate()
+	require.NoError(t, err)
+	require.False(t, explicit.hasOperationAll())
+	require.Equal(t, []kmsg.ACLOperation{kmsg.ACLOperationRead}, explicit.createOperations(kmsg.ACLResourceTypeTopic))
+}
+
+func TestCreationsPrincipalType(t *testing.T) {
+	principals := func(a acls) []string {
+		var ps []string
+		for _, c := range a.creations() {
+			ps = append(ps, c.Principal)
+		}
+		return ps
+	}
+
+	a := acls{
+		topics:          []string{"foo"},
+		operations:      []string{"read"},
+		allowPrincipals: []string{"admins", "User:alice"},
+		principalType:   "Role",
+	}
+	err := a.validateCreate()
+	require.NoError(t, err)
+	require.Equal(t, []string{"Role:admins", "User:alice"}, principals(a))
+
+	a = acls{
+		topics:          []string{"foo"},
+		operations:      []string{"read"},
+		allowPrincipals: []string{"Group:admins"},
+	}
+	err = a.validateCreate()
+	require.Error(t, err, "other types need --raw-principals")
+
+	a.rawPrincipals = true
+	err = a.validateCreate()
+	require.NoError(t, err)
+	require.Equal(t, []string{"Group:admins"}, principals(a))
+}
+
+func TestCreationsDelegationToken(t *testing.T) {
+	a := acls{
+		tokens:          []string{"tok"},
+		operations:      []string{"describe"},
+		allowPrincipals: []string{"bar"},
+	}
+	err := a.validateCreate()
+	require.NoError(t, err)
+
+	creations := a.creations()
+	require.Len(t, creations, 1)
+	require.Equal(t, kmsg.ACLResourceTypeDelegationToken, creations[0].ResourceType)
+	require.Equal(t, "tok", creations[0].ResourceName)
+
+	// CreateACLs v1 starts with the int32 creations array length, followed
+	// by the int8 resource type of the first creation.
+	req := kmsg.NewPtrCreateACLsRequest()
+	req.Version = 1
+	req.Creations = creations
+	wire := req.AppendTo(nil)
+	require.Equal(t, byte(6), wire[4], "DELEGATION_TOKEN is resource type 6 on the wire")
+
+	a = acls{
+		tokens:          []string{"tok"},
+		operations:      []string{"write"},
+		allowPrincipals: []string{"bar"},
+	}
+	err = a.validateCreate()
+	require.Error(t, err, "only describe applies to delegation tokens")
+}
+
+func TestCreateResultFormat(t *testing.T) {
+	c := kmsg.NewCreateACLsRequestCreation()
+	c.Principal = "User:bar"
+	c.Host = "*"
+	c.ResourceType = kmsg.ACLResourceTypeTopic
+	c.ResourceName = "foo"
+	c.ResourcePatternType = kmsg.ACLResourcePatternTypeLiteral
+	c.Operation = kmsg.ACLOperationRead
+	c.PermissionType = kmsg.ACLPermissionTypeAllow
+
+	results := newCreateResults([]kmsg.CreateACLsRequestCreation{c, c}, statusCreated, []int{0, 1})
+	results[1].Status = statusFailed
+	results[1].Error = "INVALID_REQUEST: bad"
+
+	got, err := out.Formatter{Kind: "json"}.Format(results)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+{"entry":0,"principal":"User:bar","host":"*","resourceType":"TOPIC","resourceName":"foo","patternType":"LITERAL","operation":"READ","permission":"ALLOW","status":"created"},
+{"entry":1,"principal":"User:bar","host":"*","resourceType":"TOPIC","resourceName":"foo","patternType":"LITERAL","operation":"READ","permission":"ALLOW","status":"failed","error":"INVALID_REQUEST: bad"}
+]`, string(got))
+
+	got, err = out.Formatter{Kind: "yaml"}.Format(newCreateResults([]kmsg.CreateACLsRequestCreation{c}, statusAlreadyExists, nil))
+	require.NoError(t, err)
+	require.Equal(t, `- principal: User:bar
+  host: '*'
+  resourceType: TOPIC
+  resourceName: foo
+  patternType: LITERAL
+  operation: READ
+  permission: ALLOW
+  status: already exists
+`, string(got))
+}
+
+func TestRemoveDuplicates(t *testing.T) {
+	c := func(principal string, op kmsg.ACLOperation) kmsg.CreateACLsRequestCreation {
+		c := kmsg.NewCreateACLsRequestCreation()
+		c.Principal = principal
+		c.Host = "*"
+		c.ResourceType = kmsg.ACLResourceTypeTopic
+		c.ResourceName = "foo"
+		c.ResourcePatternType = kmsg.ACLResourcePatternTypeLiteral
+		c.Operation = op
+		c.PermissionType = kmsg.ACLPermissionTypeAllow
+		return c
+	}
+	read, write := kmsg.ACLOperationRead, kmsg.ACLOperationWrite
+	prefixed := c("User:a", read)
+	prefixed.ResourcePatternType = kmsg.ACLResourcePatternTypePrefixed
+
+	in := []kmsg.CreateACLsRequestCreation{
+		c("User:a", read),
+		c("User:a", write),
+		c("User:a", read),  // duplicates entry 0
+		prefixed,           // differs only by pattern type
+		c("User:a", write), // duplicates entry 1
+	}
+	kept, entries := removeDuplicates(in, true, false)
+	require.Equal(t, []kmsg.CreateACLsRequestCreation{in[0], in[1], in[3]}, kept)
+	require.Equal(t, []int{0, 1, 3}, entries)
+
+	kept, entries = removeDuplicates(in[:2], true, false)
+	require.Equal(t, in[:2], kept)
+	require.Equal(t, []int{0, 1}, entries)
+
+	kept, entries = removeDuplicates(in, false, false)
+	require.Len(t, kept, 3)
+	require.Nil(t, entries, "flag ACLs are not numbered")
+}
+
+func TestAddPrincipalFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	write := func(contents string) {
+		require.NoError(t, afero.WriteFile(fs, "principals.txt", []byte(contents), 0o644))
+	}
+
+	write(`# new service accounts
+svc-a
+User:svc-b
+
+  svc-c
+User:svc-a
+`)
+	a := acls{
+		topics:          []string{"orders", "payments"},
+		operations:      []string{"read", "write"},
+		allowPrincipals: []string{"User:svc-c", "admin"},
+		principalFile:   "principals.txt",
+	}
+	dups, err := a.addPrincipalFile(fs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"User:svc-c", "User:admin", "User:svc-a", "User:svc-b"}, a.allowPrincipals)
+	require.Equal(t, []string{
+		"principal User:svc-c from principals.txt:5 duplicates --allow-principal",
+		"principal User:svc-a from principals.txt:6 duplicates principals.txt:2",
+	}, dups)
+
+	// Every principal gets every (resource, operation) pair, in one
+	// batch grouped by principal.
+	err = a.validateCreate()
+	require.NoError(t, err)
+	creations := a.creations()
+	require.Len(t, creations, 4*2*2)
+	sortByPrincipal(creations, a.allowPrincipals)
+	for i, c := range creations {
+		require.Equal(t, a.allowPrincipals[i/4], c.Principal, "creation %d", i)
+	}
+
+	// Every invalid line is reported, and nothing is merged.
+	write("svc-a\nGroup:ops\nuser:svc-b\n")
+	a = acls{principalFile: "principals.txt"}
+	_, err = a.addPrincipalFile(fs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "principals.txt:2")
+	require.Contains(t, err.Error(), "principals.txt:3")
+	require.NotContains(t, err.Error(), "principals.txt:1")
+	require.Nil(t, a.allowPrincipals)
+
+	write("# nobody yet\n\n")
+	_, err = a.addPrincipalFile(fs)
+	require.ErrorContains(t, err, "contains no principals")
+
+	a = acls{principalFile: "missing.txt"}
+	_, err = a.addPrincipalFile(fs)
+	require.Error(t, err)
+}
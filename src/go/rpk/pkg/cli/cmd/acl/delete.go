@@ -10,15 +10,18 @@
 package acl
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/cli/cmd/common"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/types"
 )
 
@@ -28,6 +31,7 @@ func newDeleteCommand(fs afero.Fs) *cobra.Command {
 		printAllFilters bool
 		dry             bool
 		noConfirm       bool
+		fromFile        string
 	)
 	cmd := &cobra.Command{
 		Use:   "delete",
@@ -41,6 +45,10 @@ everything (all operations, or all allowed principals, etc). To ensure that you
 do not accidentally delete more than you intend, this command prints everything
 that matches your input filters and prompts for a confirmation before the
 delete request is issued. Anything matching more than 10 ACLs doubly confirms.
+The --dry-run flag prints the matching ACLs and exits without deleting. If no
+ACLs match your filters, nothing is deleted and the command exits successfully.
+Tables are printed as in 'rpk acl list': aligned and colored in a terminal, and
+tab separated otherwise (see --color).
 
 As mentioned, not specifying flags matches everything. If no resources are
 specified, all resources are matched. If no operations are specified, all
@@ -51,35 +59,86 @@ The --resource-pattern-type, defaulting to "any", configures how to filter
 resource names:
   * "any" returns exact name matches of either prefixed or literal pattern type
   * "match" returns wildcard matches, prefix patterns that match your input, and literal matches
-  * "prefix" returns prefix patterns that match your input (prefix "fo" matches "foo")
-  * "literal" returns exact name matches
+  * "prefixed" returns exact name matches of prefixed pattern type
+  * "literal" returns exact name matches of literal pattern type
+
+Use "match" to find every ACL that affects a resource: --topic orders-2024
+--resource-pattern-type match returns the literal ACLs for "orders-2024", the
+prefixed ACLs for "orders-" (or any other prefix of the name), and the ACLs for
+the wildcard topic "*". Neither "any" nor "literal" return the latter two.
+
+The --name-filter flag filters the matching ACLs further by resource name with
+a shell-style glob, on the client: '*' matches any sequence of characters,
+including none, and '?' matches exactly one character; there is no escaping.
+The glob must match the whole name, regardless of whether an ACL's pattern type
+is literal or prefixed: --name-filter 'tmp-*' matches ACLs for the literal
+topic "tmp-1" and for the prefix "tmp-". The wildcard resource name "*" is
+compared like any other name, so only globs such as "*" match it, even though
+ACLs for "*" apply to every resource. Remember to quote the glob so that your
+shell does not expand it.
+
+Because a glob can match broadly, --name-filter cannot be combined with
+--no-confirm: review the matches with --dry-run or at the prompt. Only the
+listed ACLs that match the glob are deleted, each with a filter matching that
+exact ACL.
+
+The --from-file flag deletes the ACLs listed in a yaml or json file, in the
+same format as 'rpk acl create --from-file' and 'rpk acl export', so that
+deleting with the file that created ACLs removes exactly those ACLs. Every
+entry is deleted with a filter matching exactly its ACL: its principal, host,
+resource, pattern type, operation, and permission, with the same defaults as
+create (host '*' and the literal pattern type). The file is validated before
+anything is deleted, and entries are numbered from 0 in errors and output.
+The ACLs that exist for all entries are printed with their entry before the
+confirmation prompt, and --dry-run exits after printing them. Entries that
+match no ACL are reported on stderr and skipped; they do not fail the command.
+--from-file cannot be used with the resource, operation, principal, host, or
+--name-filter flags.
 `,
 		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, _ []string) {
 			p := config.ParamsFromCommand(cmd)
+			out.MaybeDieErr(out.ValidateColor(p.Color))
+			if a.nameFilter != "" && noConfirm && !dry {
+				out.Die("--%s cannot be used with --no-confirm: a glob can match more than intended, review the matches with --dry-run or at the confirmation prompt", nameFilterFlag)
+			}
 			cfg, err := p.Load(fs)
 			out.MaybeDie(err, "unable to load config: %v", err)
 
-			adm, err := kafka.NewAdmin(fs, p, cfg)
+			if fromFile != "" {
+				norm, err := a.principalNorm()
+				out.MaybeDieErr(err)
+				deleteFromFile(cmd, fs, p, cfg, fromFile, norm, printAllFilters, dry, noConfirm)
+				return
+			}
+
+			cl, err := kafka.NewConnectedClient(fs, p, cfg)
 			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
-			defer adm.Close()
+			defer cl.Close()
+			out.MaybeDieErr(kafka.CheckBrokerACLVersions(p, cl))
 
-			b, err := a.createDeletionsAndDescribes(false)
+			f, err := a.createDeletionsAndDescribes(false)
 			out.MaybeDieErr(err)
 
-			var printDeletionsHeader bool
+			var (
+				printDeletionsHeader bool
+				described            []kafka.ListACLsResult
+			)
 			if !noConfirm || dry {
-				describeReqResp(adm, printAllFilters, true, b)
+				matches, results := describeReqResp(cl, p, printAllFilters, true, f, aclSort{})
+				described = results
 				fmt.Println()
+				if matches == 0 {
+					exitDescribed(described, "No ACLs matched the given filters, nothing to delete.")
+				}
+				if dry {
+					exitDescribed(described, "Dry run, exiting.")
+				}
 
 				confirmed, err := out.Confirm("Confirm deletion of the above matching ACLs?")
 				out.MaybeDie(err, "unable to confirm deletion: %v", err)
 				if !confirmed {
-					out.Exit("Deletion canceled.")
-				}
-				if dry {
-					fmt.Println("Dry run, exiting.")
-					return
+					exitDescribed(described, "Deletion canceled.")
 				}
 				fmt.Println()
 
@@ -90,13 +149,20 @@ resource names:
 				printDeletionsHeader = true
 			}
 
-			deleteReqResp(adm, printAllFilters, printDeletionsHeader, b)
+			deleteReqResp(cl, p, printAllFilters, printDeletionsHeader, f)
+			exitIfFiltersFailed(described)
 		},
 	}
 	a.addDeleteFlags(cmd)
+	cmd.Flags().Bool(config.FlagFailIncompatibleVersions, false, "Fail if the broker's ACL API versions are incompatible with rpk, rather than warning")
 	cmd.Flags().BoolVarP(&printAllFilters, "print-filters", "f", false, "Print the filters that were requested (failed filters are always printed)")
-	cmd.Flags().BoolVarP(&dry, "dry", "d", false, "Dry run: validate what would be deleted")
+	cmd.Flags().BoolVarP(&dry, "dry-run", "d", false, "Dry run: print what would be deleted and exit without deleting")
+	cmd.Flags().BoolVar(&dry, "dry", false, "")
+	cmd.Flags().MarkDeprecated("dry", "use --dry-run")
 	cmd.Flags().BoolVar(&noConfirm, "no-confirm", false, "Disable confirmation prompt")
+	cmd.Flags().StringVar(&fromFile, fromFileFlag, "", "Delete exactly the ACLs listed in this yaml or json file")
+	common.AddColorFlag(cmd)
+	registerCompletions(fs, cmd, true)
 	return cmd
 }
 
@@ -107,8 +173,10 @@ func (a *acls) addDeleteFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&a.groups, groupFlag, nil, "Group to remove ACLs for (repeatable)")
 	cmd.Flags().BoolVar(&a.cluster, clusterFlag, false, "Whether to remove ACLs to the cluster")
 	cmd.Flags().StringSliceVar(&a.txnIDs, txnIDFlag, nil, "Transactional IDs to remove ACLs for (repeatable)")
+	cmd.Flags().StringSliceVar(&a.tokens, tokenFlag, nil, "Delegation token IDs to remove ACLs for (repeatable)")
 
 	cmd.Flags().StringVar(&a.resourcePatternType, patternFlag, "any", "Pattern to use when matching resource names (any, match, literal, or prefixed)")
+	cmd.Flags().StringVar(&a.nameFilter, nameFilterFlag, "", "Shell-style glob (* and ?) that resource names of ACLs to remove must match, applied client side")
 
 	cmd.Flags().StringSliceVar(&a.operations, operationFlag, nil, "Operation to remove (repeatable)")
 
@@ -116,18 +184,44 @@ func (a *acls) addDeleteFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&a.allowHosts, allowHostFlag, nil, "Allowed host ACLs to remove (repeatable)")
 	cmd.Flags().StringSliceVar(&a.denyPrincipals, denyPrincipalFlag, nil, "Denied principal ACLs to remove (repeatable)")
 	cmd.Flags().StringSliceVar(&a.denyHosts, denyHostFlag, nil, "Denied host ACLs to remove (repeatable)")
+	a.addPrincipalTypeFlags(cmd)
+}
+
+// exitDescribed prints msg and exits, with exitIfFiltersFailed if any of the
+// filters that we described before deleting failed.
+func exitDescribed(described []kafka.ListACLsResult, msg string) {
+	out.Infof(msg)
+	exitIfFiltersFailed(described)
+	out.ExitWith(0)
 }
 
 func deleteReqResp(
-	adm *kadm.Client,
+	cl *kgo.Client,
+	p *config.Params,
 	printAllFilters bool,
 	printDeletionsHeader bool,
-	b *kadm.ACLBuilder,
+	f kafka.ACLFilter,
 ) {
-	results, err := adm.DeleteACLs(context.Background(), b)
+	ctx, cancel := kafka.RequestContext(p)
+	defer cancel()
+	results, err := kafka.DeleteACLs(ctx, cl, f)
+	err = kafka.RequestErr(ctx, err)
+	if errors.Is(err, out.ErrInterrupted) {
+		out.DieCode(out.ExitInterrupted, "Interrupted while deleting ACLs: some or all matching ACLs may have been deleted, use 'rpk acl list' to check.")
+	}
 	out.MaybeDie(err, "unable to delete ACLs: %v", err)
 	types.Sort(results)
+	printDeletions(p, printAllFilters, printDeletionsHeader, results)
+}
 
+// printDeletions prints the results of deleting ACLs, and exits if any filter
+// or deletion failed.
+func printDeletions(
+	p *config.Params,
+	printAllFilters bool,
+	printDeletionsHeader bool,
+	results []kafka.DeleteACLsResult,
+) {
 	// If any filters failed, or if all filters are requested, we print the
 	// filter section.
 	var printFailedFilters bool
@@ -139,24 +233,56 @@ func deleteReqResp(
 	}
 	if printAllFilters || printFailedFilters {
 		out.Section("filters")
-		printDeleteFilters(printAllFilters, results)
+		printDeleteFilters(printAllFilters, results, p.Color)
 		fmt.Println()
 		printDeletionsHeader = true
 	}
+	// Every filter and every matched deletion can fail independently.
+	var (
+		deleted int
+		errs    []error
+		details []out.ErrorDetail
+	)
+	for _, f := range results {
+		deleted += len(f.Deleted)
+		if f.Err != nil {
+			errs = append(errs, f.Err)
+			details = append(details, out.ErrorDetail{
+				Item:  filterACL(f.Principal, f.Host, f.Type, f.Name, f.Pattern, f.Operation, f.Permission),
+				Error: kafka.ErrMessage(f.Err),
+			})
+		}
+		for _, d := range f.Deleted {
+			if d.Err != nil {
+				errs = append(errs, d.Err)
+				details = append(details, out.ErrorDetail{
+					Item:  acl(d.ACL),
+					Error: kafka.ErrMessage(d.Err),
+				})
+			}
+		}
+	}
+	total := len(results) + deleted
+	defer exitIfFailed(fmt.Sprintf("%d of %d ACL filters and deletions failed", len(details), total), details, total)
+	defer printSecurityDisabledHint(errs...)
+	if deleted == 0 {
+		out.Infof("No ACLs matched the given filters, nothing was deleted.")
+		return
+	}
 	if printDeletionsHeader {
 		out.Section("deletions")
 	}
-	printDeleteResults(results)
+	printDeleteResults(results, p.Color)
 }
 
-func printDeleteFilters(all bool, results kadm.DeleteACLsResults) {
-	tw := out.NewTable(headersWithError...)
+func printDeleteFilters(all bool, results []kafka.DeleteACLsResult, colorMode string) {
+	tw := out.NewStyledTable(colorMode, headersWithError...)
 	defer tw.Flush()
 	for _, f := range results {
 		if f.Err == nil && !all {
 			continue
 		}
-		tw.PrintStructFields(aclWithMessage{
+		tw.PrintStructFieldsColor(permissionColor(f.Permission), aclWithMessage{
 			unptr(f.Principal),
 			unptr(f.Host),
 			f.Type,
@@ -169,17 +295,17 @@ func printDeleteFilters(all bool, results kadm.DeleteACLsResults) {
 	}
 }
 
-func printDeleteResults(results kadm.DeleteACLsResults) {
-	tw := out.NewTable(headersWithError...)
+func printDeleteResults(results []kafka.DeleteACLsResult, colorMode string) {
+	tw := out.NewStyledTable(colorMode, headersWithError...)
 	defer tw.Flush()
 	for _, f := range results {
 		for _, d := range f.Deleted {
-			tw.PrintStructFields(aclWithMessage{
+			tw.PrintStructFieldsColor(permissionColor(d.Permission), aclWithMessage{
 				d.Principal,
 				d.Host,
-				d.Type,
-				d.Name,
-				d.Pattern,
+				d.ResourceType,
+				d.ResourceName,
+				d.ResourcePatternType,
 				d.Operation,
 				d.Permission,
 				kafka.ErrMessage(d.Err),
@@ -187,3 +313,146 @@ func printDeleteResults(results kadm.DeleteACLsResults) {
 		}
 	}
 }
+
+// fileDeletion is an entry of a 'delete --from-file' file: the ACL it lists,
+// and the filter that matches exactly that ACL.
+type fileDeletion struct {
+	entry  int
+	acl    acl
+	filter kafka.ACLFilter
+}
+
+// fileDeletions returns the deletion of every ACL in creations, which are the
+// entries of a file in order. Entries that duplicate an earlier entry are
+// skipped with a warning.
+func fileDeletions(creations []kmsg.CreateACLsRequestCreation) []fileDeletion {
+	var (
+		first     = make(map[acl]int)
+		deletions []fileDeletion
+	)
+	for i, c := range creations {
+		a := creationACL(c)
+		if j, seen := first[a]; seen {
+			out.Warnf("warning: entry %d duplicates entry %d: %s; deleting it once", i, j, a)
+			continue
+		}
+		first[a] = i
+		deletions = append(deletions, fileDeletion{i, a, kafka.ExactACLFilter(kafka.ACL(a))})
+	}
+	return deletions
+}
+
+// deleteFromFile deletes every ACL listed in file, each with a filter
+// matching exactly that ACL, and prints the result for every deleted ACL.
+func deleteFromFile(
+	cmd *cobra.Command, fs afero.Fs, p *config.Params, cfg *config.Config, file string, norm principalNorm, printAllFilters, dry, noConfirm bool,
+) {
+	var conflicting []string
+	for _, f := range []string{
+		resourceFlag, resourceNameFlag, namePatternFlag,
+		topicFlag, groupFlag, clusterFlag, txnIDFlag, tokenFlag, patternFlag, nameFilterFlag, operationFlag,
+		allowPrincipalFlag, allowHostFlag, denyPrincipalFlag, denyHostFlag,
+	} {
+		if cmd.Flags().Changed(f) {
+			conflicting = append(conflicting, "--"+f)
+		}
+	}
+	if len(conflicting) > 0 {
+		out.Die("--%s cannot be used with %s", fromFileFlag, strings.Join(conflicting, ", "))
+	}
+
+	// Entries are validated as for create, except that operations that
+	// do not apply to the resource type are allowed: create --force may
+	// have created them.
+	creations, err := parseACLFile(fs, file, norm, true)
+	out.MaybeDieErr(err)
+	deletions := fileDeletions(creations)
+
+	cl, err := kafka.NewConnectedClient(fs, p, cfg)
+	out.MaybeDie(err, "unable to initialize kafka client: %v", err)
+	defer cl.Close()
+	out.MaybeDieErr(kafka.CheckBrokerACLVersions(p, cl))
+
+	var printDeletionsHeader bool
+	if !noConfirm || dry {
+		out.Section("matches")
+		deletions = listFileDeletions(cl, p, deletions)
+		fmt.Println()
+		if len(deletions) == 0 {
+			out.Exit("None of the ACLs in %s exist, nothing to delete.", file)
+		}
+		if dry {
+			out.Exit("Dry run, exiting.")
+		}
+
+		confirmed, err := out.Confirm("Confirm deletion of the above matching ACLs?")
+		out.MaybeDie(err, "unable to confirm deletion: %v", err)
+		if !confirmed {
+			out.Exit("Deletion canceled.")
+		}
+		fmt.Println()
+		printDeletionsHeader = true
+	}
+
+	// Every entry is its own request, bounded by --request-timeout.
+	var results []kafka.DeleteACLsResult
+	for _, d := range deletions {
+		ctx, cancel := kafka.RequestContext(p)
+		deleted, err := kafka.DeleteACLs(ctx, cl, d.filter)
+		err = kafka.RequestErr(ctx, err)
+		cancel()
+		if errors.Is(err, out.ErrInterrupted) {
+			out.DieCode(out.ExitInterrupted, "Interrupted while deleting ACLs: some or all matching ACLs may have been deleted, use 'rpk acl list' to check.")
+		}
+		out.MaybeDie(err, "unable to delete the ACL of entry %d, the ACLs of the entries before it may have been deleted: %v", d.entry, err)
+		var matched bool
+		for _, r := range deleted {
+			matched = matched || r.Err != nil || len(r.Deleted) > 0
+		}
+		if !matched {
+			out.Warnf("entry %d matched no ACL: %s", d.entry, d.acl)
+		}
+		results = append(results, deleted...)
+	}
+	printDeletions(p, printAllFilters, printDeletionsHeader, results)
+}
+
+// listFileDeletions prints the ACLs that exist for every deletion, with the
+// entry that lists them, and returns the deletions that match an ACL.
+// Entries that match no ACL are reported on stderr. If listing fails for any
+// entry, this exits before anything is deleted.
+func listFileDeletions(cl *kgo.Client, p *config.Params, deletions []fileDeletion) []fileDeletion {
+	var (
+		matched   []fileDeletion
+		unmatched []fileDeletion
+	)
+	tw := out.NewStyledTable(p.Color, append([]string{"Entry"}, headers...)...)
+	for _, d := range deletions {
+		// As with deleting, every entry gets the full timeout.
+		ctx, cancel := kafka.RequestContext(p)
+		results, err := kafka.ListACLs(ctx, cl, d.filter)
+		err = kafka.RequestErr(ctx, err)
+		cancel()
+		out.MaybeDie(err, "unable to list ACLs: %v", err)
+		for _, r := range results {
+			if securityDisabled(r.Err) {
+				out.Die("unable to list ACLs: %s\n\n%s", kafka.ErrMessage(r.Err), securityDisabledHint)
+			}
+			out.MaybeDie(r.Err, "unable to list the ACL of entry %d, nothing was deleted: %s", d.entry, kafka.ErrMessage(r.Err))
+		}
+		acls := describedACLs(results)
+		if len(acls) == 0 {
+			unmatched = append(unmatched, d)
+			continue
+		}
+		matched = append(matched, d)
+		for _, a := range acls {
+			tw.PrintColor(permissionColor(a.Permission), d.entry, a.Principal, a.Host, a.ResourceType, a.ResourceName, a.ResourcePatternType, a.Operation, a.Permission)
+		}
+	}
+	tw.Flush()
+	for _, d := range unmatched {
+		out.Warnf("entry %d matches no ACL, skipping: %s", d.entry, d.acl)
+	}
+	return matched
+}
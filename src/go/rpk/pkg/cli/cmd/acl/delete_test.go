@@ -0,0 +1,86 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestFileDeletions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	const file = "/acls.yaml"
+	require.NoError(t, afero.WriteFile(fs, file, []byte(`- principal: bar
+  resourceType: topic
+  resourceName: orders-
+  patternType: prefixed
+  operation: read
+  permission: allow
+- principal: User:baz
+  host: 10.0.0.1
+  resourceType: cluster
+  operation: alter
+  permission: deny
+- principal: User:bar
+  host: "*"
+  resourceType: topic
+  resourceName: orders-
+  patternType: prefixed
+  operation: read
+  permission: allow
+- principal: bar
+  resourceType: group
+  resourceName: g
+  operation: write
+  permission: allow
+`), 0o644))
+
+	creations, err := parseACLFile(fs, file, principalNorm{typ: defaultPrincipalType}, true)
+	require.NoError(t, err, "operations that do not apply are allowed, since create --force may have created them")
+	deletions := fileDeletions(creations)
+
+	var entries []int
+	for _, d := range deletions {
+		entries = append(entries, d.entry)
+	}
+	require.Equal(t, []int{0, 1, 3}, entries, "entry 2 duplicates entry 0 once normalized")
+
+	require.Equal(t, []kafka.ACLFilter{
+		{
+			Topics:          []string{"orders-"},
+			PatternType:     kmsg.ACLResourcePatternTypePrefixed,
+			Operations:      []kmsg.ACLOperation{kmsg.ACLOperationRead},
+			AllowPrincipals: []string{"User:bar"},
+			AllowHosts:      []string{"*"},
+		},
+		{
+			Cluster:        true,
+			PatternType:    kmsg.ACLResourcePatternTypeLiteral,
+			Operations:     []kmsg.ACLOperation{kmsg.ACLOperationAlter},
+			DenyPrincipals: []string{"User:baz"},
+			DenyHosts:      []string{"10.0.0.1"},
+		},
+		{
+			Groups:          []string{"g"},
+			PatternType:     kmsg.ACLResourcePatternTypeLiteral,
+			Operations:      []kmsg.ACLOperation{kmsg.ACLOperationWrite},
+			AllowPrincipals: []string{"User:bar"},
+			AllowHosts:      []string{"*"},
+		},
+	}, []kafka.ACLFilter{deletions[0].filter, deletions[1].filter, deletions[2].filter})
+	for _, d := range deletions {
+		_, err := d.filter.Builder()
+		require.NoError(t, err)
+	}
+}
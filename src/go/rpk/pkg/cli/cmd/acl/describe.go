@@ -0,0 +1,358 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"fmt"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+const principalFlag = "principal"
+
+// The possible decisions for an operation in describe.
+const (
+	decisionAllowed     = "allowed"
+	decisionDenied      = "denied"
+	decisionUnspecified = "unspecified"
+)
+
+func newDescribeCommand(fs afero.Fs) *cobra.Command {
+	var (
+		a         acls
+		principal string
+		host      string
+	)
+	// describe used to be an alias of list, which we still run if no
+	// principal is given.
+	list := newListCommand(fs)
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Describe the effective permissions of a principal",
+		Long: `Describe the effective permissions of a principal.
+
+This command answers "what can this principal actually do?". All ALLOW and
+DENY ACLs that apply to the principal (including ACLs for the wildcard
+principal User:*) are fetched, and for every operation of every resource, the
+effective permission is computed:
+
+  * denied if any DENY ACL matches the operation (DENY overrides ALLOW)
+  * allowed if any ALLOW ACL matches the operation
+  * unspecified otherwise, which means the operation is denied
+
+An ACL for the ALL operation matches every operation. As in Kafka, allowing
+READ, WRITE, DELETE, or ALTER implies allowing DESCRIBE, and allowing
+ALTER_CONFIGS implies allowing DESCRIBE_CONFIGS. The ACL that produced each
+decision is printed alongside the decision.
+
+If a resource is specified with --topic, --group, --cluster, or
+--transactional-id, every ACL affecting that resource is considered: literal
+ACLs, prefixed ACLs matching the name, and wildcard ACLs. Otherwise, the
+effective permissions are printed for every resource the principal has an ACL
+for.
+
+ACLs for any host are considered unless --host is specified, in which case
+only ACLs for that host and the wildcard host '*' are considered.
+
+This command is read only.
+
+Before this command existed, 'rpk acl describe' was an alias of 'rpk acl list'.
+For compatibility, running describe without --principal still lists ACLs with
+the list flags, but this is deprecated: use 'rpk acl list' instead.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			if principal == "" {
+				out.Warnf("rpk acl describe without --principal is deprecated and lists ACLs, use 'rpk acl list'")
+				forwardSharedFlags(cmd, list)
+				list.Run(cmd, args)
+				return
+			}
+
+			p := config.ParamsFromCommand(cmd)
+			out.MaybeDieErr(p.Formatter.Validate())
+			cfg, err := p.Load(fs)
+			out.MaybeDie(err, "unable to load config: %v", err)
+
+			principal, err = normalizePrincipal(principal)
+			out.MaybeDieErr(err)
+			a.allowPrincipals = []string{principal, wildcardPrincipal}
+			a.denyPrincipals = []string{principal, wildcardPrincipal}
+			if host != "" {
+				a.allowHosts = []string{host, "*"}
+				a.denyHosts = []string{host, "*"}
+			}
+			nresources := len(a.topics) + len(a.groups) + len(a.txnIDs)
+			if a.cluster {
+				nresources++
+			}
+			switch {
+			case nresources > 1:
+				out.Die("only one resource can be described at a time")
+			case nresources == 1:
+				a.resourcePatternType = "match"
+			default:
+				a.resourcePatternType = "any"
+			}
+
+			f, err := a.createDeletionsAndDescribes(false)
+			out.MaybeDieErr(err)
+
+			cl, err := kafka.NewConnectedClient(fs, p, cfg)
+			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
+			defer cl.Close()
+
+			ctx, cancel := kafka.RequestContext(p)
+			defer cancel()
+			results, err := kafka.ListACLs(ctx, cl, f)
+			err = kafka.RequestErr(ctx, err)
+			out.MaybeDie(err, "unable to describe ACLs: %v", err)
+			for _, r := range results {
+				if securityDisabled(r.Err) {
+					out.Die("unable to describe ACLs: %s\n\n%s", kafka.ErrMessage(r.Err), securityDisabledHint)
+				}
+				out.MaybeDie(r.Err, "unable to describe ACLs: %s", kafka.ErrMessage(r.Err))
+			}
+
+			var rs []resourceDecisions
+			if nresources == 1 {
+				rt, name := a.singleResource()
+				rs = []resourceDecisions{{
+					ResourceType: rt,
+					ResourceName: name,
+					Decisions:    effectivePermissions(rt, describedACLs(results)),
+				}}
+			} else {
+				rs = describeAllResources(describedACLs(results))
+			}
+			printResourceDecisions(p.Formatter, principal, rs)
+		},
+	}
+
+	cmd.Flags().StringVar(&principal, principalFlag, "", "Principal to describe the effective permissions of")
+	cmd.Flags().StringVar(&host, "host", "", "Only consider ACLs for this host (and the wildcard host)")
+	cmd.Flags().StringSliceVar(&a.topics, topicFlag, nil, "Topic to describe permissions for")
+	cmd.Flags().StringSliceVar(&a.groups, groupFlag, nil, "Group to describe permissions for")
+	cmd.Flags().BoolVar(&a.cluster, clusterFlag, false, "Whether to describe permissions for the cluster")
+	cmd.Flags().StringSliceVar(&a.txnIDs, txnIDFlag, nil, "Transactional ID to describe permissions for")
+	// The list flags that describe does not have are hidden, so that old
+	// invocations of describe as list keep parsing.
+	list.Flags().VisitAll(func(f *pflag.Flag) {
+		if cmd.Flags().Lookup(f.Name) == nil {
+			hidden := *f
+			hidden.Hidden = true
+			cmd.Flags().AddFlag(&hidden)
+		}
+	})
+	cmd.RegisterFlagCompletionFunc(topicFlag, func(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return completeTopics(fs, cmd), cobra.ShellCompDirectiveNoFileComp
+	})
+	return cmd
+}
+
+// forwardSharedFlags sets the flags that describe and list both define, such
+// as --topic, on list, for the deprecated use of describe as list. The flags
+// of list that describe does not define share their values already.
+func forwardSharedFlags(describe, list *cobra.Command) {
+	describe.Flags().Visit(func(f *pflag.Flag) {
+		lf := list.Flags().Lookup(f.Name)
+		if lf == nil || lf.Value == f.Value {
+			return
+		}
+		vals := []string{f.Value.String()}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			vals = sv.GetSlice()
+		}
+		for _, v := range vals {
+			out.MaybeDieErr(lf.Value.Set(v))
+		}
+	})
+}
+
+// singleResource returns the only resource specified in a.
+func (a *acls) singleResource() (kmsg.ACLResourceType, string) {
+	switch {
+	case len(a.topics) == 1:
+		return kmsg.ACLResourceTypeTopic, a.topics[0]
+	case len(a.groups) == 1:
+		return kmsg.ACLResourceTypeGroup, a.groups[0]
+	case len(a.txnIDs) == 1:
+		return kmsg.ACLResourceTypeTransactionalId, a.txnIDs[0]
+	case len(a.tokens) == 1:
+		return kmsg.ACLResourceTypeDelegationToken, a.tokens[0]
+	default:
+		return kmsg.ACLResourceTypeCluster, kafkaCluster
+	}
+}
+
+type (
+	// decision is the effective permission for a single operation, and
+	// the ACL that produced it, if any.
+	decision struct {
+		Operation kmsg.ACLOperation `json:"operation" yaml:"operation"`
+		Decision  string            `json:"decision" yaml:"decision"`
+		ACL       *acl              `json:"acl,omitempty" yaml:"acl,omitempty"`
+	}
+
+	// resourceDecisions are the decisions for every operation that is
+	// applicable to a resource.
+	resourceDecisions struct {
+		ResourceType kmsg.ACLResourceType        `json:"resourceType" yaml:"resourceType"`
+		ResourceName string                      `json:"resourceName" yaml:"resourceName"`
+		PatternType  kmsg.ACLResourcePatternType `json:"patternType,omitempty" yaml:"patternType,omitempty"`
+		Decisions    []decision                  `json:"decisions" yaml:"decisions"`
+	}
+)
+
+// describeAllResources evaluates each resource the ACLs are for separately.
+func describeAllResources(acls []acl) []resourceDecisions {
+	type resource struct {
+		t       kmsg.ACLResourceType
+		name    string
+		pattern kmsg.ACLResourcePatternType
+	}
+	var (
+		order  []resource
+		byName = make(map[resource][]acl)
+	)
+	for _, a := range acls {
+		r := resource{a.ResourceType, a.ResourceName, a.ResourcePatternType}
+		if _, exists := byName[r]; !exists {
+			order = append(order, r)
+		}
+		byName[r] = append(byName[r], a)
+	}
+	var rs []resourceDecisions
+	for _, r := range order {
+		rs = append(rs, resourceDecisions{
+			ResourceType: r.t,
+			ResourceName: r.name,
+			PatternType:  r.pattern,
+			Decisions:    effectivePermissions(r.t, byName[r]),
+		})
+	}
+	return rs
+}
+
+// effectivePermissions returns the decision for every operation applicable
+// to the resource type, given all ACLs that match a principal and resource.
+//
+// This follows Kafka's authorizer: any matching DENY wins, and otherwise any
+// matching ALLOW allows. The ALL operation matches every operation, and
+// allowing some operations implies allowing DESCRIBE or DESCRIBE_CONFIGS.
+func effectivePermissions(rt kmsg.ACLResourceType, acls []acl) []decision {
+	var ds []decision
+	for _, op := range resourceOperations[rt] {
+		d := decision{Operation: op, Decision: decisionUnspecified}
+		for i := range acls {
+			a := &acls[i]
+			if a.Permission != kmsg.ACLPermissionTypeDeny {
+				continue
+			}
+			if a.Operation == op || a.Operation == kmsg.ACLOperationAll {
+				d.Decision = decisionDenied
+				d.ACL = a
+				break
+			}
+		}
+		if d.ACL == nil {
+			allowedBy := impliedBy(op)
+		allow:
+			for i := range acls {
+				a := &acls[i]
+				if a.Permission != kmsg.ACLPermissionTypeAllow {
+					continue
+				}
+				for _, implied := range allowedBy {
+					if a.Operation == implied {
+						d.Decision = decisionAllowed
+						d.ACL = a
+						break allow
+					}
+				}
+			}
+		}
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+// impliedBy returns the operations that, when allowed, allow op.
+func impliedBy(op kmsg.ACLOperation) []kmsg.ACLOperation {
+	switch op {
+	case kmsg.ACLOperationDescribe:
+		return []kmsg.ACLOperation{
+			kmsg.ACLOperationDescribe,
+			kmsg.ACLOperationAll,
+			kmsg.ACLOperationRead,
+			kmsg.ACLOperationWrite,
+			kmsg.ACLOperationDelete,
+			kmsg.ACLOperationAlter,
+		}
+	case kmsg.ACLOperationDescribeConfigs:
+		return []kmsg.ACLOperation{
+			kmsg.ACLOperationDescribeConfigs,
+			kmsg.ACLOperationAll,
+			kmsg.ACLOperationAlterConfigs,
+		}
+	default:
+		return []kmsg.ACLOperation{op, kmsg.ACLOperationAll}
+	}
+}
+
+func printResourceDecisions(f out.Formatter, principal string, rs []resourceDecisions) {
+	if !f.IsText() {
+		if rs == nil {
+			rs = []resourceDecisions{}
+		}
+		err := f.Print(rs)
+		out.MaybeDie(err, "unable to print permissions: %v", err)
+		return
+	}
+	if len(rs) == 0 {
+		out.Infof("No ACLs apply to %s; all operations are denied.", principal)
+		return
+	}
+	tw := out.NewTable("Resource-Type", "Resource-Name", "Resource-Pattern-Type", "Operation", "Decision", "Decided-By")
+	defer tw.Flush()
+	for _, r := range rs {
+		pattern := "-"
+		if r.PatternType != 0 {
+			pattern = r.PatternType.String()
+		}
+		for _, d := range r.Decisions {
+			by := "-"
+			if d.ACL != nil {
+				by = d.ACL.String()
+			}
+			tw.Print(r.ResourceType, r.ResourceName, pattern, d.Operation, d.Decision, by)
+		}
+	}
+}
+
+// String returns a compact description of an ACL, used when describing which
+// ACL produced a decision.
+func (a acl) String() string {
+	return fmt.Sprintf("%s %s %s for %s %s %q from host %s",
+		a.Permission,
+		a.Operation,
+		a.Principal,
+		a.ResourcePatternType,
+		a.ResourceType,
+		a.ResourceName,
+		a.Host,
+	)
+}
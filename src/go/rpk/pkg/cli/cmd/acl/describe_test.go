@@ -0,0 +1,132 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestEffectivePermissions(t *testing.T) {
+	binding := func(op kmsg.ACLOperation, perm kmsg.ACLPermissionType) acl {
+		return acl{
+			Principal:           "User:foo",
+			Host:                "*",
+			ResourceType:        kmsg.ACLResourceTypeTopic,
+			ResourceName:        "orders",
+			ResourcePatternType: kmsg.ACLResourcePatternTypeLiteral,
+			Operation:           op,
+			Permission:          perm,
+		}
+	}
+	var (
+		allowRead     = binding(kmsg.ACLOperationRead, kmsg.ACLPermissionTypeAllow)
+		allowAll      = binding(kmsg.ACLOperationAll, kmsg.ACLPermissionTypeAllow)
+		allowAlterCfg = binding(kmsg.ACLOperationAlterConfigs, kmsg.ACLPermissionTypeAllow)
+		denyRead      = binding(kmsg.ACLOperationRead, kmsg.ACLPermissionTypeDeny)
+		denyAll       = binding(kmsg.ACLOperationAll, kmsg.ACLPermissionTypeDeny)
+	)
+
+	for _, test := range []struct {
+		name string
+		rt   kmsg.ACLResourceType
+		in   []acl
+		exp  map[kmsg.ACLOperation]string
+	}{
+		{
+			name: "nothing is unspecified",
+			rt:   kmsg.ACLResourceTypeGroup,
+			exp: map[kmsg.ACLOperation]string{
+				kmsg.ACLOperationRead:     decisionUnspecified,
+				kmsg.ACLOperationDelete:   decisionUnspecified,
+				kmsg.ACLOperationDescribe: decisionUnspecified,
+			},
+		},
+		{
+			name: "allow read implies describe",
+			rt:   kmsg.ACLResourceTypeTopic,
+			in:   []acl{allowRead},
+			exp: map[kmsg.ACLOperation]string{
+				kmsg.ACLOperationRead:            decisionAllowed,
+				kmsg.ACLOperationWrite:           decisionUnspecified,
+				kmsg.ACLOperationCreate:          decisionUnspecified,
+				kmsg.ACLOperationDelete:          decisionUnspecified,
+				kmsg.ACLOperationAlter:           decisionUnspecified,
+				kmsg.ACLOperationDescribe:        decisionAllowed,
+				kmsg.ACLOperationDescribeConfigs: decisionUnspecified,
+				kmsg.ACLOperationAlterConfigs:    decisionUnspecified,
+			},
+		},
+		{
+			name: "allow alter configs implies describe configs",
+			rt:   kmsg.ACLResourceTypeTransactionalId,
+			in:   []acl{allowAlterCfg},
+			exp: map[kmsg.ACLOperation]string{
+				kmsg.ACLOperationWrite:    decisionUnspecified,
+				kmsg.ACLOperationDescribe: decisionUnspecified,
+			},
+		},
+		{
+			name: "deny overrides allow",
+			rt:   kmsg.ACLResourceTypeGroup,
+			in:   []acl{allowAll, denyRead},
+			exp: map[kmsg.ACLOperation]string{
+				kmsg.ACLOperationRead:     decisionDenied,
+				kmsg.ACLOperationDelete:   decisionAllowed,
+				kmsg.ACLOperationDescribe: decisionAllowed,
+			},
+		},
+		{
+			name: "deny all denies everything",
+			rt:   kmsg.ACLResourceTypeDelegationToken,
+			in:   []acl{allowRead, denyAll},
+			exp: map[kmsg.ACLOperation]string{
+				kmsg.ACLOperationDescribe: decisionDenied,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := effectivePermissions(test.rt, test.in)
+			require.Len(t, got, len(test.exp))
+			for _, d := range got {
+				require.Equal(t, test.exp[d.Operation], d.Decision, "decision mismatch for %s", d.Operation)
+				if d.Decision == decisionUnspecified {
+					require.Nil(t, d.ACL, "unspecified decisions should not have an ACL")
+				} else {
+					require.NotNil(t, d.ACL, "decisions should have the ACL that decided them")
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeAsList(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	describe := newDescribeCommand(fs)
+	// List only flags still parse on describe.
+	require.NoError(t, describe.ParseFlags([]string{
+		"--topic", "foo,bar",
+		"--cluster",
+		"--allow-principal", "User:a",
+		"--print-filters",
+	}))
+
+	list := newListCommand(fs)
+	forwardSharedFlags(describe, list)
+	topics, err := list.Flags().GetStringSlice(topicFlag)
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo", "bar"}, topics)
+	cluster, err := list.Flags().GetBool(clusterFlag)
+	require.NoError(t, err)
+	require.True(t, cluster)
+}
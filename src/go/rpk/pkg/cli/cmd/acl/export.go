@@ -0,0 +1,69 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	rpkos "github.com/redpanda-data/redpanda/src/go/rpk/pkg/os"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func newExportCommand(fs afero.Fs) *cobra.Command {
+	var to string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export all ACLs to a file",
+		Long: `Export all ACLs to a file.
+
+Every ACL in the cluster is written to the --to file, in the same yaml or json
+format that 'rpk acl create --from-file' reads, so ACLs can be backed up
+before a migration and restored afterwards:
+
+    rpk acl export --to acls.yaml
+    rpk acl create --from-file acls.yaml --if-not-exists
+
+The format is chosen by the file extension: .yaml, .yml, or .json. Every ACL
+is exported with its principal, host, resource, pattern type, operation, and
+permission, including DENY ACLs. ACLs are sorted, so exports of the same ACLs
+are identical and can be diffed or committed to version control. An existing
+file is replaced.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			p := config.ParamsFromCommand(cmd)
+			cfg, err := p.Load(fs)
+			out.MaybeDie(err, "unable to load config: %v", err)
+
+			// We validate the extension before issuing any request.
+			_, err = marshalACLFile(to, nil)
+			out.MaybeDieErr(err)
+
+			cl, err := kafka.NewConnectedClient(fs, p, cfg)
+			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
+			defer cl.Close()
+
+			acls, err := principalACLs(cl, p, "")
+			err = withSecurityDisabledHint(err)
+			out.MaybeDie(err, "unable to describe ACLs: %v", err)
+
+			b, err := marshalACLFile(to, acls)
+			out.MaybeDie(err, "unable to encode ACLs: %v", err)
+			err = rpkos.ReplaceFile(fs, to, b, 0o644)
+			out.MaybeDie(err, "unable to write ACLs: %v", err)
+			out.Infof("Exported %d ACLs to %s.", len(acls), to)
+		},
+	}
+	cmd.Flags().StringVar(&to, "to", "", "File to write the ACLs to (.yaml, .yml, or .json)")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
@@ -0,0 +1,169 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/types"
+	"gopkg.in/yaml.v3"
+)
+
+const fromFileFlag = "from-file"
+
+// aclSpec is a single ACL in a yaml or json ACL file. The field names match
+// our --format output of an ACL, so that the output of listing ACLs can be
+// used as input to create ACLs.
+type aclSpec struct {
+	Principal    string `json:"principal" yaml:"principal"`
+	Host         string `json:"host" yaml:"host"`
+	ResourceType string `json:"resourceType" yaml:"resourceType"`
+	ResourceName string `json:"resourceName" yaml:"resourceName"`
+	PatternType  string `json:"patternType" yaml:"patternType"`
+	Operation    string `json:"operation" yaml:"operation"`
+	Permission   string `json:"permission" yaml:"permission"`
+}
+
+// marshalACLFile marshals the ACLs for the given yaml or json file, in the
+// format parseACLFile reads. The ACLs are marshaled sorted, so that the same
+// ACLs always give the same file, and json is indented so that files diff
+// well.
+func marshalACLFile(file string, acls []acl) ([]byte, error) {
+	acls = append([]acl{}, acls...) // write [] rather than null
+	types.Sort(acls)
+	switch ext := filepath.Ext(file); ext {
+	case ".yml", ".yaml":
+		return yaml.Marshal(acls)
+	case ".json":
+		b, err := json.MarshalIndent(acls, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unable to write %q: the extension must be .yaml, .yml, or .json", file)
+	}
+}
+
+// parseACLFile reads the ACL specs in file and validates every entry, so that
+// a typo in one entry does not leave the entries before it applied. Unless
+// force is true, entries with an operation that does not apply to the
+// resource type are warned about. Principals are normalized with norm.
+func parseACLFile(fs afero.Fs, file string, norm principalNorm, force bool) ([]kmsg.CreateACLsRequestCreation, error) {
+	specs, err := out.ParseFileArray[aclSpec](fs, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no ACLs found in %q", file)
+	}
+	var (
+		creations []kmsg.CreateACLsRequestCreation
+		errs      []string
+	)
+	var warns []string
+	for i, spec := range specs {
+		c, err := spec.creation(norm)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("entry %d: %v", i, err))
+			continue
+		}
+		// Files are usually exports of ACLs that the cluster already
+		// accepted, so unlike with flags, an operation that does not
+		// apply to the resource type only warns: 'acl export' followed
+		// by 'acl create --from-file' must restore every ACL.
+		if !force {
+			if err := validateOperation(c.ResourceType, c.Operation); err != nil {
+				warns = append(warns, fmt.Sprintf("entry %d: %v", i, err))
+			}
+		}
+		creations = append(creations, c)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid ACLs in %q:\n  %s", file, strings.Join(errs, "\n  "))
+	}
+	for _, w := range warns {
+		out.Warnf("warning: %s; the ACL has no effect", w)
+	}
+	return creations, nil
+}
+
+// creation validates the spec and converts it to an ACL creation, defaulting
+// the host to '*', the pattern type to literal, and a cluster resource name
+// to kafka-cluster. As with our flags, the principal is normalized with norm,
+// which adds the "User:" prefix if missing by default.
+func (s aclSpec) creation(norm principalNorm) (kmsg.CreateACLsRequestCreation, error) {
+	c := kmsg.NewCreateACLsRequestCreation()
+
+	if s.Principal == "" {
+		return c, fmt.Errorf("missing principal")
+	}
+	var err error
+	if c.Principal, err = norm.normalize(s.Principal); err != nil {
+		return c, err
+	}
+
+	c.Host = s.Host
+	if c.Host == "" {
+		c.Host = "*"
+	}
+	if err := validateHost(c.Host); err != nil {
+		return c, err
+	}
+
+	rt, err := parseResourceType(s.ResourceType)
+	if err != nil {
+		return c, err
+	}
+	c.ResourceType = rt
+	c.ResourceName = s.ResourceName
+	if rt == kmsg.ACLResourceTypeCluster {
+		if c.ResourceName != "" && c.ResourceName != kafkaCluster {
+			return c, fmt.Errorf("invalid cluster resource name %q, must be empty or %s", c.ResourceName, kafkaCluster)
+		}
+		c.ResourceName = kafkaCluster
+	}
+	if c.ResourceName == "" {
+		return c, fmt.Errorf("missing resource name")
+	}
+	if rt == kmsg.ACLResourceTypeDelegationToken {
+		if err := validateTokenName(c.ResourceName); err != nil {
+			return c, err
+		}
+	}
+
+	pattern := s.PatternType
+	if pattern == "" {
+		pattern = "literal"
+	}
+	c.ResourcePatternType, err = kmsg.ParseACLResourcePatternType(pattern)
+	if err != nil || (c.ResourcePatternType != kmsg.ACLResourcePatternTypeLiteral &&
+		c.ResourcePatternType != kmsg.ACLResourcePatternTypePrefixed) {
+		return c, fmt.Errorf("invalid pattern type %q, must be literal or prefixed", s.PatternType)
+	}
+
+	c.Operation, err = kmsg.ParseACLOperation(s.Operation)
+	if err != nil || c.Operation == kmsg.ACLOperationAny {
+		return c, fmt.Errorf("invalid operation %q", s.Operation)
+	}
+
+	c.PermissionType, err = kmsg.ParseACLPermissionType(s.Permission)
+	if err != nil || c.PermissionType == kmsg.ACLPermissionTypeAny {
+		return c, fmt.Errorf("invalid permission %q, must be allow or deny", s.Permission)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestParseACLFile(t *testing.T) {
+	creation := func(principal, host string, rt kmsg.ACLResourceType, name string, pattern kmsg.ACLResourcePatternType, op kmsg.ACLOperation, perm kmsg.ACLPermissionType) kmsg.CreateACLsRequestCreation {
+		c := kmsg.NewCreateACLsRequestCreation()
+		c.Principal = principal
+		c.Host = host
+		c.ResourceType = rt
+		c.ResourceName = name
+		c.ResourcePatternType = pattern
+		c.Operation = op
+		c.PermissionType = perm
+		return c
+	}
+
+	for _, test := range []struct {
+		name   string
+		file   string
+		in     string
+		exp    []kmsg.CreateACLsRequestCreation
+		expErr bool
+	}{
+		{
+			name: "yaml with defaults",
+			file: "acls.yaml",
+			in: `- principal: bar
+  resourceType: topic
+  resourceName: foo
+  operation: read
+  permission: allow
+- principal: User:baz
+  host: 10.0.0.1
+  resourceType: CLUSTER
+  patternType: LITERAL
+  operation: alter
+  permission: DENY
+`,
+			exp: []kmsg.CreateACLsRequestCreation{
+				creation("User:bar", "*", kmsg.ACLResourceTypeTopic, "foo", kmsg.ACLResourcePatternTypeLiteral, kmsg.ACLOperationRead, kmsg.ACLPermissionTypeAllow),
+				creation("User:baz", "10.0.0.1", kmsg.ACLResourceTypeCluster, kafkaCluster, kmsg.ACLResourcePatternTypeLiteral, kmsg.ACLOperationAlter, kmsg.ACLPermissionTypeDeny),
+			},
+		},
+
+		{
+			name: "json prefixed",
+			file: "acls.json",
+			in:   `[{"principal":"User:bar","host":"*","resourceType":"transactional-id","resourceName":"txn-","patternType":"prefixed","operation":"write","permission":"allow"}]`,
+			exp: []kmsg.CreateACLsRequestCreation{
+				creation("User:bar", "*", kmsg.ACLResourceTypeTransactionalId, "txn-", kmsg.ACLResourcePatternTypePrefixed, kmsg.ACLOperationWrite, kmsg.ACLPermissionTypeAllow),
+			},
+		},
+
+		{
+			name: "one invalid entry fails everything",
+			file: "acls.yaml",
+			in: `- principal: bar
+  resourceType: topic
+  resourceName: foo
+  operation: read
+  permission: allow
+- principal: bar
+  resourceType: topic
+  resourceName: foo
+  operation: raed
+  permission: allow
+`,
+			expErr: true,
+		},
+
+		{name: "empty file fails", file: "acls.yaml", in: "[]", expErr: true},
+		{name: "missing principal", file: "acls.json", in: `[{"resourceType":"topic","resourceName":"foo","operation":"read","permission":"allow"}]`, expErr: true},
+		{name: "missing name", file: "acls.json", in: `[{"principal":"foo","resourceType":"topic","operation":"read","permission":"allow"}]`, expErr: true},
+		{name: "bad cluster name", file: "acls.json", in: `[{"principal":"foo","resourceType":"cluster","resourceName":"foo","operation":"alter","permission":"allow"}]`, expErr: true},
+		{name: "match pattern", file: "acls.json", in: `[{"principal":"foo","resourceType":"topic","resourceName":"foo","patternType":"match","operation":"read","permission":"allow"}]`, expErr: true},
+		{name: "any operation", file: "acls.json", in: `[{"principal":"foo","resourceType":"topic","resourceName":"foo","operation":"any","permission":"allow"}]`, expErr: true},
+		{name: "any permission", file: "acls.json", in: `[{"principal":"foo","resourceType":"topic","resourceName":"foo","operation":"read","permission":"any"}]`, expErr: true},
+		{name: "bad host", file: "acls.json", in: `[{"principal":"foo","host":"10.0.0.1:9092","resourceType":"topic","resourceName":"foo","operation":"read","permission":"allow"}]`, expErr: true},
+		{
+			// Inapplicable operations only warn, as exports may
+			// contain them.
+			name: "operation not applicable to the resource",
+			file: "acls.json",
+			in:   `[{"principal":"foo","resourceType":"group","resourceName":"g","operation":"alter_configs","permission":"allow"}]`,
+			exp: []kmsg.CreateACLsRequestCreation{
+				creation("User:foo", "*", kmsg.ACLResourceTypeGroup, "g", kmsg.ACLResourcePatternTypeLiteral, kmsg.ACLOperationAlterConfigs, kmsg.ACLPermissionTypeAllow),
+			},
+		},
+		{name: "delegation token named by principal", file: "acls.json", in: `[{"principal":"foo","resourceType":"delegation-token","resourceName":"User:foo","operation":"describe","permission":"allow"}]`, expErr: true},
+		{name: "unknown extension", file: "acls.toml", in: `[]`, expErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, test.file, []byte(test.in), 0o644))
+
+			got, err := parseACLFile(fs, test.file, principalNorm{typ: defaultPrincipalType}, false)
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+			if test.expErr {
+				return
+			}
+			require.Equal(t, test.exp, got)
+		})
+	}
+}
+
+func TestACLFileRoundTrip(t *testing.T) {
+	acls := []acl{
+		{"User:bar", "*", kmsg.ACLResourceTypeCluster, kafkaCluster, kmsg.ACLResourcePatternTypeLiteral, kmsg.ACLOperationAlter, kmsg.ACLPermissionTypeDeny},
+		{"User:bar", "10.0.0.1", kmsg.ACLResourceTypeTopic, "orders.", kmsg.ACLResourcePatternTypePrefixed, kmsg.ACLOperationDescribeConfigs, kmsg.ACLPermissionTypeAllow},
+		{"User:foo", "*", kmsg.ACLResourceTypeGroup, "g", kmsg.ACLResourcePatternTypeLiteral, kmsg.ACLOperationRead, kmsg.ACLPermissionTypeAllow},
+		{"User:foo", "*", kmsg.ACLResourceTypeTransactionalId, "txn", kmsg.ACLResourcePatternTypeLiteral, kmsg.ACLOperationIdempotentWrite, kmsg.ACLPermissionTypeDeny},
+	}
+	for _, file := range []string{"acls.yaml", "acls.yml", "acls.json"} {
+		t.Run(file, func(t *testing.T) {
+			b, err := marshalACLFile(file, acls)
+			require.NoError(t, err)
+
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, file, b, 0o644))
+			// Exported ACLs are round tripped as is, even if an
+			// operation does not apply to the resource type.
+			creations, err := parseACLFile(fs, file, principalNorm{typ: defaultPrincipalType}, false)
+			require.NoError(t, err)
+			require.Len(t, creations, len(acls))
+			for i, c := range creations {
+				require.Equal(t, acls[i], acl{
+					c.Principal,
+					c.Host,
+					c.ResourceType,
+					c.ResourceName,
+					c.ResourcePatternType,
+					c.Operation,
+					c.PermissionType,
+				})
+			}
+
+			// The order responses list ACLs in must not matter.
+			shuffled := append([]acl(nil), acls...)
+			rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+			require.NotEqual(t, acls, shuffled)
+			again, err := marshalACLFile(file, shuffled)
+			require.NoError(t, err)
+			require.Equal(t, string(b), string(again), "exports must be deterministic")
+		})
+	}
+
+	_, err := marshalACLFile("acls.txt", acls)
+	require.Error(t, err)
+
+	b, err := marshalACLFile("acls.json", nil)
+	require.NoError(t, err)
+	require.Equal(t, "[]\n", string(b))
+}
@@ -10,24 +10,33 @@
 package acl
 
 import (
-	"context"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
 
+	"github.com/fatih/color"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/cli/cmd/common"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/types"
 )
 
 func newListCommand(fs afero.Fs) *cobra.Command {
 	var a acls
-	var printAllFilters bool
+	var (
+		printAllFilters, showCounts, findConflicts, affecting bool
+		sortBy                                                aclSort
+	)
 	cmd := &cobra.Command{
 		Use:     "list",
-		Aliases: []string{"ls", "describe"},
+		Aliases: []string{"ls"},
 		Short:   "List ACLs",
 		Long: `List ACLs.
 
@@ -41,30 +50,174 @@ specified, all resources are matched. If no operations are specified, all
 operations are matched. You can also opt in to matching everything with "any":
 --operation any matches any operation.
 
+Matching ACLs are printed sorted and grouped by principal. With --format json
+or yaml, the matching ACLs are printed as a list of objects with the fields
+principal, host, resourceType, resourceName, patternType, operation, and
+permission.
+
+With --format jsonl, every matching ACL is printed as one JSON object per line
+(JSON Lines). The filters are described one resource and operation at a time,
+and each response's matches are printed and flushed as soon as it arrives.
+Unlike json, which prints one sorted array once everything is processed, jsonl
+does not sort, so tools such as jq can start processing right away on clusters
+with many ACLs. If a filter fails, the ACLs printed so far are flushed, the
+failure is printed to stderr, and the command exits non-zero.
+
+If stdout is a terminal, the table columns are aligned and, by default, DENY
+ACLs are printed in red and ALLOW ACLs in green; use --color to change that.
+If stdout is not a terminal, the columns are separated by a single tab, which
+is easy to process with cut or awk, and nothing is colored unless --color is
+always. --format json and yaml are never colored.
+
 The --resource-pattern-type, defaulting to "any", configures how to filter
 resource names:
   * "any" returns exact name matches of either prefixed or literal pattern type
   * "match" returns wildcard matches, prefix patterns that match your input, and literal matches
-  * "prefix" returns prefix patterns that match your input (prefix "fo" matches "foo")
-  * "literal" returns exact name matches
+  * "prefixed" returns exact name matches of prefixed pattern type
+  * "literal" returns exact name matches of literal pattern type
+
+Use "match" to find every ACL that affects a resource: --topic orders-2024
+--resource-pattern-type match returns the literal ACLs for "orders-2024", the
+prefixed ACLs for "orders-" (or any other prefix of the name), and the ACLs for
+the wildcard topic "*". Neither "any" nor "literal" return the latter two.
+
+The --name-filter flag filters the matching ACLs further by resource name with
+a shell-style glob, on the client: '*' matches any sequence of characters,
+including none, and '?' matches exactly one character; there is no escaping.
+The glob must match the whole name, regardless of whether an ACL's pattern type
+is literal or prefixed: --name-filter 'tmp-*' matches ACLs for the literal
+topic "tmp-1" and for the prefix "tmp-". The wildcard resource name "*" is
+compared like any other name, so only globs such as "*" match it, even though
+ACLs for "*" apply to every resource. Remember to quote the glob so that your
+shell does not expand it.
+
+The --show-counts flag prints how many ACLs match rather than the ACLs
+themselves: the total, and counts grouped by principal, by resource type, and
+by permission, such as "User:svc-a: 12 (10 ALLOW / 2 DENY)". Principals and
+resource types are sorted by count, largest first, which makes principals with
+unexpectedly broad access stand out. All filter flags apply as usual. With
+--format json or yaml, the counts are printed as one object with the fields
+total, allow, deny, principals, resourceTypes, and permissions.
+
+The --sort-by flag changes the order of the matching ACLs in the table and
+with --format json or yaml:
+  * "principal" (the default) sorts by principal, then host, then resource
+  * "resource" sorts by resource type, name, and pattern type, then principal
+  * "operation" sorts by operation name, then principal
+  * "permission" sorts by permission name (ALLOW before DENY), then principal
+Ties are always broken by every other field, so the order is deterministic.
+--reverse reverses the order of the sort key only; ties keep their ascending
+secondary order. --sort-by cannot be used with --format jsonl, which streams
+ACLs unsorted, or with --show-counts.
+
+The --find-conflicts flag analyzes the matching ACLs rather than printing
+them, and reports ALLOW ACLs that have no effect:
+  * conflicts: an ALLOW for which a DENY exists with the same principal, host,
+    and resource, for the same operation or for ALL. DENY wins, so the ALLOW
+    is dead config.
+  * redundant ALLOWs: an ALLOW that another ALLOW with the same principal,
+    host, and resource already implies, i.e. an ALLOW for ALL, or an ALLOW
+    that implies DESCRIBE or DESCRIBE_CONFIGS (see 'rpk acl describe').
+Each finding is printed with the ACLs that cause it. Only ACLs for identical
+principals, hosts, and resources are compared; wildcard principals, hosts,
+and prefixed patterns are not expanded. Filters apply as usual, so filtering
+to only allowed principals or hosts hides every conflict. With --format json
+or yaml, the findings are printed as a list of objects with the fields kind
+("conflict" or "redundant"), acl, and causedBy. This is read only and cannot
+be combined with --show-counts, --sort-by, or --format jsonl.
+
+The --affecting-resource flag prints, for a single resource, the effective
+permissions of every principal that has an ACL affecting it, rather than the
+ACLs. This is the resource-centric complement to 'rpk acl describe', which is
+principal-centric: exactly one --topic, --group, --transactional-id,
+--delegation-token, or --cluster must be specified, and every ACL affecting
+the resource is fetched with --resource-pattern-type match: the literal ACLs
+for the name, the prefixed ACLs matching it, and the wildcard ACLs. For each
+principal and operation, the decision is computed as in 'rpk acl describe':
+DENY overrides ALLOW, ALL matches every operation, and some allowed operations
+imply DESCRIBE or DESCRIBE_CONFIGS. ACLs for the wildcard principal User:*
+apply to every principal, and User:* has its own row for principals with no
+ACLs of their own. ACLs can be limited to a host, so a principal has a row
+per host of the ACLs that apply to it: the row for a host considers the ACLs
+for that host and for the wildcard host '*', and the row for '*' is what
+applies to every other host.
+
+The table has a row per principal and host and a column per operation of the
+resource type, with "allowed", "denied", or "-" for operations that no ACL allows or
+denies, which are denied. For example:
+
+    rpk acl list --affecting-resource --topic orders-2024
+
+With --format json or yaml, the resource type and name are printed with a
+list of principals and hosts and, for each, the decision and deciding ACL of
+every operation. Operation, principal, host, and name filters cannot be used, since
+every ACL must be considered, and neither can --show-counts, --find-conflicts,
+--sort-by, or --format jsonl.
 `,
 		Args: cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, _ []string) {
 			p := config.ParamsFromCommand(cmd)
+			out.MaybeDieErr(p.Formatter.Validate())
+			out.MaybeDieErr(out.ValidateColor(p.Color))
 			cfg, err := p.Load(fs)
 			out.MaybeDie(err, "unable to load config: %v", err)
 
-			adm, err := kafka.NewAdmin(fs, p, cfg)
+			cl, err := kafka.NewConnectedClient(fs, p, cfg)
 			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
-			defer adm.Close()
+			defer cl.Close()
+			out.MaybeDieErr(kafka.CheckBrokerACLVersions(p, cl))
 
-			b, err := a.createDeletionsAndDescribes(true)
+			f, err := a.createDeletionsAndDescribes(true)
 			out.MaybeDieErr(err)
-			describeReqResp(adm, printAllFilters, false, b)
+			if affecting {
+				if showCounts || findConflicts || sortBy.by != "" || p.Formatter.IsJSONL() {
+					out.Die("--%s cannot be used with --show-counts, --find-conflicts, --%s, or --format jsonl", affectingResourceFlag, sortByFlag)
+				}
+				out.MaybeDieErr(a.validateAffectingResource(cmd.Flags().Changed(patternFlag)))
+				f.PatternType = kmsg.ACLResourcePatternTypeMatch
+				describeReqRespAffecting(cl, p, &a, f)
+				return
+			}
+			if findConflicts && (showCounts || sortBy.by != "" || p.Formatter.IsJSONL()) {
+				out.Die("--find-conflicts cannot be used with --show-counts, --%s, or --format jsonl", sortByFlag)
+			}
+			if findConflicts {
+				describeReqRespConflicts(cl, p, f)
+				return
+			}
+			if sortBy.by != "" && (showCounts || p.Formatter.IsJSONL()) {
+				out.Die("--%s cannot be used with --show-counts or --format jsonl", sortByFlag)
+			}
+			out.MaybeDieErr(sortBy.validate())
+			if showCounts {
+				if p.Formatter.IsJSONL() {
+					out.Die("--show-counts does not support --format jsonl")
+				}
+				describeReqRespCounts(cl, p, f)
+				return
+			}
+			if p.Formatter.IsJSONL() {
+				describeReqRespStreamed(cl, p, f)
+				return
+			}
+			if !p.Formatter.IsText() {
+				describeReqRespFormatted(cl, p, f, sortBy)
+				return
+			}
+			_, results := describeReqResp(cl, p, printAllFilters, false, f, sortBy)
+			exitIfFiltersFailed(results)
 		},
 	}
 	a.addListFlags(cmd)
+	cmd.Flags().Bool(config.FlagFailIncompatibleVersions, false, "Fail if the broker's ACL API versions are incompatible with rpk, rather than warning")
 	cmd.Flags().BoolVarP(&printAllFilters, "print-filters", "f", false, "Print the filters that were requested (failed filters are always printed)")
+	cmd.Flags().StringVar(&sortBy.by, sortByFlag, "", "Sort matching ACLs by principal (default), resource, operation, or permission")
+	cmd.Flags().BoolVar(&sortBy.reverse, "reverse", false, "Reverse the --sort-by order")
+	cmd.Flags().BoolVar(&findConflicts, "find-conflicts", false, "Report ALLOW ACLs that a DENY overrides or that another ALLOW already implies, rather than the ACLs")
+	cmd.Flags().BoolVar(&affecting, affectingResourceFlag, false, "Print the effective permissions of every principal on the single specified resource, rather than the ACLs")
+	cmd.Flags().BoolVar(&showCounts, "show-counts", false, "Print counts of matching ACLs grouped by principal, resource type, and permission, rather than the ACLs")
+	common.AddColorFlag(cmd)
+	registerCompletions(fs, cmd, true)
 	return cmd
 }
 
@@ -83,8 +236,10 @@ func (a *acls) addListFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&a.groups, groupFlag, nil, "Group to match ACLs for (repeatable)")
 	cmd.Flags().BoolVar(&a.cluster, clusterFlag, false, "Whether to match ACLs to the cluster")
 	cmd.Flags().StringSliceVar(&a.txnIDs, txnIDFlag, nil, "Transactional IDs to match ACLs for (repeatable)")
+	cmd.Flags().StringSliceVar(&a.tokens, tokenFlag, nil, "Delegation token IDs to match ACLs for (repeatable)")
 
 	cmd.Flags().StringVar(&a.resourcePatternType, patternFlag, "any", "Pattern to use when matching resource names (any, match, literal, or prefixed)")
+	cmd.Flags().StringVar(&a.nameFilter, nameFilterFlag, "", "Shell-style glob (* and ?) that matching resource names must match, applied client side")
 
 	cmd.Flags().StringSliceVar(&a.operations, operationFlag, nil, "Operation to match (repeatable)")
 
@@ -92,15 +247,25 @@ func (a *acls) addListFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&a.allowHosts, allowHostFlag, nil, "Allowed host ACLs to match (repeatable)")
 	cmd.Flags().StringSliceVar(&a.denyPrincipals, denyPrincipalFlag, nil, "Denied principal ACLs to match (repeatable)")
 	cmd.Flags().StringSliceVar(&a.denyHosts, denyHostFlag, nil, "Denied host ACLs to match (repeatable)")
+	a.addPrincipalTypeFlags(cmd)
 }
 
+// describeReqResp prints the ACLs matching the filters in text. Failed
+// filters do not exit: the results are returned so that the caller can exit
+// with exitIfFiltersFailed once the command is done, which for delete is
+// after prompting for and running the deletion.
 func describeReqResp(
-	adm *kadm.Client,
+	cl *kgo.Client,
+	p *config.Params,
 	printAllFilters bool,
 	printMatchesHeader bool,
-	b *kadm.ACLBuilder,
-) {
-	results, err := adm.DescribeACLs(context.Background(), b)
+	f kafka.ACLFilter,
+	sortBy aclSort,
+) (matches int, results []kafka.ListACLsResult) {
+	ctx, cancel := kafka.RequestContext(p)
+	defer cancel()
+	results, err := kafka.ListACLs(ctx, cl, f)
+	err = kafka.RequestErr(ctx, err)
 	out.MaybeDie(err, "unable to list ACLs: %v", err)
 	types.Sort(results)
 
@@ -115,21 +280,266 @@ func describeReqResp(
 	}
 	if printAllFilters || printFailedFilters {
 		out.Section("filters")
-		printDescribeFilters(results)
+		printDescribeFilters(results, p.Color)
 		fmt.Println()
 		printMatchesHeader = true
 	}
 	if printMatchesHeader {
 		out.Section("matches")
 	}
-	printDescribedACLs(results)
+	matches = printDescribedACLs(results, p.Color, sortBy)
+	printSecurityDisabledHint(filterErrs(results)...)
+	return matches, results
+}
+
+// exitIfFiltersFailed is exitIfFailed for the filters of a describe.
+func exitIfFiltersFailed(results []kafka.ListACLsResult) {
+	var details []out.ErrorDetail
+	for _, r := range results {
+		if r.Err != nil {
+			details = append(details, out.ErrorDetail{
+				Item:  filterACL(r.Principal, r.Host, r.Type, r.Name, r.Pattern, r.Operation, r.Permission),
+				Error: kafka.ErrMessage(r.Err),
+			})
+		}
+	}
+	exitIfFailed(fmt.Sprintf("%d of %d ACL filters failed", len(details), len(results)), details, len(results))
+}
+
+// failedFilters returns how many describe filters failed.
+func failedFilters(results []kafka.ListACLsResult) int {
+	var failed int
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// describeReqRespFormatted is describeReqResp for --format json or yaml: we
+// print only the matching ACLs. Failed filters are printed to stderr.
+func describeReqRespFormatted(
+	cl *kgo.Client,
+	p *config.Params,
+	f kafka.ACLFilter,
+	sortBy aclSort,
+) {
+	ctx, cancel := kafka.RequestContext(p)
+	defer cancel()
+	results, err := kafka.ListACLs(ctx, cl, f)
+	err = kafka.RequestErr(ctx, err)
+	out.MaybeDie(err, "unable to list ACLs: %v", err)
+	printFailedDescribeFilters(results)
+	acls := describedACLs(results)
+	sortBy.sort(acls)
+	if acls == nil {
+		acls = []acl{} // print [] rather than null
+	}
+	err = p.Formatter.Print(acls)
+	out.MaybeDie(err, "unable to print ACLs: %v", err)
+	printSecurityDisabledHint(filterErrs(results)...)
+	exitIfFiltersFailed(results)
+}
+
+// aclCount is the number of ACLs for one value of a grouping dimension.
+type aclCount struct {
+	Name  string `json:"name" yaml:"name"`
+	Total int    `json:"total" yaml:"total"`
+	Allow int    `json:"allow" yaml:"allow"`
+	Deny  int    `json:"deny" yaml:"deny"`
+}
+
+func (c *aclCount) add(perm kmsg.ACLPermissionType) {
+	c.Total++
+	switch perm {
+	case kmsg.ACLPermissionTypeAllow:
+		c.Allow++
+	case kmsg.ACLPermissionTypeDeny:
+		c.Deny++
+	}
+}
+
+// aclCounts is what 'acl list --show-counts' prints.
+type aclCounts struct {
+	Total         int        `json:"total" yaml:"total"`
+	Allow         int        `json:"allow" yaml:"allow"`
+	Deny          int        `json:"deny" yaml:"deny"`
+	Principals    []aclCount `json:"principals" yaml:"principals"`
+	ResourceTypes []aclCount `json:"resourceTypes" yaml:"resourceTypes"`
+	Permissions   []aclCount `json:"permissions" yaml:"permissions"`
+}
+
+// countACLs groups acls by principal, resource type, and permission. Every
+// group is sorted by total, largest first, and then by name.
+func countACLs(acls []acl) aclCounts {
+	var total aclCount
+	princs := make(map[string]*aclCount)
+	rtypes := make(map[string]*aclCount)
+	perms := make(map[string]*aclCount)
+	for _, a := range acls {
+		total.add(a.Permission)
+		addCount(princs, a.Principal, a.Permission)
+		addCount(rtypes, a.ResourceType.String(), a.Permission)
+		addCount(perms, a.Permission.String(), a.Permission)
+	}
+	return aclCounts{
+		Total:         total.Total,
+		Allow:         total.Allow,
+		Deny:          total.Deny,
+		Principals:    sortedCounts(princs),
+		ResourceTypes: sortedCounts(rtypes),
+		Permissions:   sortedCounts(perms),
+	}
+}
+
+func addCount(m map[string]*aclCount, name string, perm kmsg.ACLPermissionType) {
+	c, ok := m[name]
+	if !ok {
+		c = &aclCount{Name: name}
+		m[name] = c
+	}
+	c.add(perm)
+}
+
+func sortedCounts(m map[string]*aclCount) []aclCount {
+	counts := make([]aclCount, 0, len(m))
+	for _, c := range m {
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Total != counts[j].Total {
+			return counts[i].Total > counts[j].Total
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
 }
 
-func printDescribeFilters(results kadm.DescribeACLsResults) {
-	tw := out.NewTable(headersWithError...)
+// describeReqRespCounts is describeReqResp for --show-counts: we print counts
+// of the matching ACLs rather than the ACLs. Failed filters are printed to
+// stderr.
+func describeReqRespCounts(
+	cl *kgo.Client,
+	p *config.Params,
+	f kafka.ACLFilter,
+) {
+	ctx, cancel := kafka.RequestContext(p)
+	defer cancel()
+	results, err := kafka.ListACLs(ctx, cl, f)
+	err = kafka.RequestErr(ctx, err)
+	out.MaybeDie(err, "unable to list ACLs: %v", err)
+	printFailedDescribeFilters(results)
+	c := countACLs(describedACLs(results))
+	if p.Formatter.IsText() {
+		printACLCounts(os.Stdout, c)
+	} else {
+		err = p.Formatter.Print(c)
+		out.MaybeDie(err, "unable to print ACL counts: %v", err)
+	}
+	printSecurityDisabledHint(filterErrs(results)...)
+	exitIfFiltersFailed(results)
+}
+
+func printACLCounts(w io.Writer, c aclCounts) {
+	fmt.Fprintf(w, "%d ACLs (%d ALLOW / %d DENY)\n", c.Total, c.Allow, c.Deny)
+	for _, section := range []struct {
+		header string
+		counts []aclCount
+	}{
+		{"principals", c.Principals},
+		{"resource types", c.ResourceTypes},
+	} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\n%s\n", strings.ToUpper(section.header), strings.Repeat("=", len(section.header)))
+		for _, g := range section.counts {
+			fmt.Fprintf(w, "%s: %d (%d ALLOW / %d DENY)\n", g.Name, g.Total, g.Allow, g.Deny)
+		}
+	}
+	if len(c.Permissions) > 0 {
+		fmt.Fprintf(w, "\nPERMISSIONS\n===========\n")
+		for _, g := range c.Permissions {
+			fmt.Fprintf(w, "%s: %d\n", g.Name, g.Total)
+		}
+	}
+}
+
+// describeReqRespStreamed is describeReqResp for --format jsonl: the filter
+// is described one resource and operation at a time (see kafka.ListACLsEach),
+// and every matching ACL is written as its own line, flushing after each
+// response, rather than collecting and sorting all ACLs first. Duplicates
+// across filters are still removed. Failed filters are printed to stderr.
+func describeReqRespStreamed(
+	cl *kgo.Client,
+	p *config.Params,
+	f kafka.ACLFilter,
+) {
+	ctx, cancel := kafka.MultiRequestContext(p)
+	defer cancel()
+
+	var (
+		w    = out.NewJSONLWriter(os.Stdout)
+		seen = make(map[acl]bool)
+		// filters keeps every filter without its ACLs, for
+		// exitIfFiltersFailed once everything is printed.
+		filters []kafka.ListACLsResult
+	)
+	err := kafka.ListACLsEach(ctx, cl, f, func(results []kafka.ListACLsResult) error {
+		for _, r := range results {
+			filters = append(filters, kafka.ListACLsResult{SentACLFilter: r.SentACLFilter, Err: r.Err})
+			if r.Err != nil {
+				fmt.Fprintf(os.Stderr, "filter for principal %q, host %q, resource %s %q failed: %s\n",
+					unptr(r.Principal), unptr(r.Host), r.Type, unptr(r.Name), kafka.ErrMessage(r.Err))
+				continue
+			}
+			for _, d := range r.Described {
+				a := acl(d)
+				if seen[a] {
+					continue
+				}
+				seen[a] = true
+				if err := w.Write(a); err != nil {
+					out.Die("unable to print ACLs: %v", err)
+				}
+			}
+		}
+		err := w.Flush()
+		out.MaybeDie(err, "unable to print ACLs: %v", err)
+		return nil
+	})
+	err = kafka.RequestErr(ctx, err)
+	out.MaybeDie(err, "unable to list ACLs: %v", err)
+	printSecurityDisabledHint(filterErrs(filters)...)
+	exitIfFiltersFailed(filters)
+}
+
+// printFailedDescribeFilters prints every failed describe filter to stderr,
+// for output modes that do not print a filters section.
+func printFailedDescribeFilters(results []kafka.ListACLsResult) {
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "filter for principal %q, host %q, resource %s %q failed: %s\n",
+				unptr(r.Principal), unptr(r.Host), r.Type, unptr(r.Name), kafka.ErrMessage(r.Err))
+		}
+	}
+}
+
+// filterErrs returns the error of every describe filter.
+func filterErrs(results []kafka.ListACLsResult) []error {
+	errs := make([]error, 0, len(results))
+	for _, r := range results {
+		errs = append(errs, r.Err)
+	}
+	return errs
+}
+
+func printDescribeFilters(results []kafka.ListACLsResult, colorMode string) {
+	tw := out.NewStyledTable(colorMode, headersWithError...)
 	defer tw.Flush()
 	for _, f := range results {
-		tw.PrintStructFields(aclWithMessage{
+		tw.PrintStructFieldsColor(permissionColor(f.Permission), aclWithMessage{
 			unptr(f.Principal),
 			unptr(f.Host),
 			f.Type,
@@ -142,20 +552,92 @@ func printDescribeFilters(results kadm.DescribeACLsResults) {
 	}
 }
 
-func printDescribedACLs(results kadm.DescribeACLsResults) {
-	tw := out.NewTable(headersWithError...)
-	defer tw.Flush()
+// describedACLs flattens all ACLs matched across every filter, removing
+// duplicates. The principal is the first field in our acl struct, so sorting
+// groups ACLs by principal and keeps the output stable across runs.
+func describedACLs(results []kafka.ListACLsResult) []acl {
+	var acls []acl
 	for _, f := range results {
 		for _, d := range f.Described {
-			tw.PrintStructFields(acl{
-				d.Principal,
-				d.Host,
-				d.Type,
-				d.Name,
-				d.Pattern,
-				d.Operation,
-				d.Permission,
-			})
+			acls = append(acls, acl(d))
 		}
 	}
+	types.DistinctInPlace(&acls)
+	return acls
+}
+
+func printDescribedACLs(results []kafka.ListACLsResult, colorMode string, sortBy aclSort) int {
+	tw := out.NewStyledTable(colorMode, headersWithError...)
+	defer tw.Flush()
+	acls := describedACLs(results)
+	sortBy.sort(acls)
+	for _, a := range acls {
+		tw.PrintStructFieldsColor(permissionColor(a.Permission), a)
+	}
+	return len(acls)
+}
+
+const sortByFlag = "sort-by"
+
+// aclSort is the --sort-by and --reverse order of listed ACLs. The zero value
+// sorts by principal, which is the order of describedACLs.
+type aclSort struct {
+	by      string
+	reverse bool
+}
+
+func (s aclSort) validate() error {
+	switch s.by {
+	case "", "principal", "resource", "operation", "permission":
+		return nil
+	default:
+		return fmt.Errorf("invalid --%s %q, must be principal, resource, operation, or permission", sortByFlag, s.by)
+	}
+}
+
+// key compares l and r by only the sort key, ignoring reverse.
+func (s aclSort) key(l, r acl) int {
+	switch s.by {
+	case "resource":
+		if c := int(l.ResourceType) - int(r.ResourceType); c != 0 {
+			return c
+		}
+		if c := strings.Compare(l.ResourceName, r.ResourceName); c != 0 {
+			return c
+		}
+		return int(l.ResourcePatternType) - int(r.ResourcePatternType)
+	case "operation":
+		return strings.Compare(l.Operation.String(), r.Operation.String())
+	case "permission":
+		return strings.Compare(l.Permission.String(), r.Permission.String())
+	default:
+		return strings.Compare(l.Principal, r.Principal)
+	}
+}
+
+// less returns whether l sorts before r: by the sort key, reversed if
+// requested, and then ascending by every field in acl order.
+func (s aclSort) less(l, r acl) bool {
+	if c := s.key(l, r); c != 0 {
+		return (c < 0) != s.reverse
+	}
+	return types.Less(l, r)
+}
+
+// sort sorts acls in place.
+func (s aclSort) sort(acls []acl) {
+	sort.SliceStable(acls, func(i, j int) bool { return s.less(acls[i], acls[j]) })
+}
+
+// permissionColor returns the color of an ACL row in a colored table: red for
+// DENY, green for ALLOW, and the default color otherwise.
+func permissionColor(perm kmsg.ACLPermissionType) color.Attribute {
+	switch perm {
+	case kmsg.ACLPermissionTypeDeny:
+		return color.FgRed
+	case kmsg.ACLPermissionTypeAllow:
+		return color.FgGreen
+	default:
+		return color.Reset
+	}
 }
@@ -0,0 +1,142 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCountACLs(t *testing.T) {
+	binding := func(principal string, rt kmsg.ACLResourceType, perm kmsg.ACLPermissionType) acl {
+		return acl{
+			Principal:    principal,
+			Host:         "*",
+			ResourceType: rt,
+			ResourceName: "foo",
+			Operation:    kmsg.ACLOperationRead,
+			Permission:   perm,
+		}
+	}
+	var (
+		topic = kmsg.ACLResourceTypeTopic
+		group = kmsg.ACLResourceTypeGroup
+		allow = kmsg.ACLPermissionTypeAllow
+		deny  = kmsg.ACLPermissionTypeDeny
+	)
+
+	c := countACLs([]acl{
+		binding("User:b", topic, allow),
+		binding("User:a", topic, allow),
+		binding("User:a", group, allow),
+		binding("User:a", topic, deny),
+		binding("User:c", group, allow),
+	})
+	require.Equal(t, aclCounts{
+		Total: 5,
+		Allow: 4,
+		Deny:  1,
+		Principals: []aclCount{
+			{Name: "User:a", Total: 3, Allow: 2, Deny: 1},
+			{Name: "User:b", Total: 1, Allow: 1},
+			{Name: "User:c", Total: 1, Allow: 1},
+		},
+		ResourceTypes: []aclCount{
+			{Name: "TOPIC", Total: 3, Allow: 2, Deny: 1},
+			{Name: "GROUP", Total: 2, Allow: 2},
+		},
+		Permissions: []aclCount{
+			{Name: "ALLOW", Total: 4, Allow: 4},
+			{Name: "DENY", Total: 1, Deny: 1},
+		},
+	}, c)
+
+	var buf bytes.Buffer
+	printACLCounts(&buf, c)
+	require.Equal(t, `5 ACLs (4 ALLOW / 1 DENY)
+
+PRINCIPALS
+==========
+User:a: 3 (2 ALLOW / 1 DENY)
+User:b: 1 (1 ALLOW / 0 DENY)
+User:c: 1 (1 ALLOW / 0 DENY)
+
+RESOURCE TYPES
+==============
+TOPIC: 3 (2 ALLOW / 1 DENY)
+GROUP: 2 (2 ALLOW / 0 DENY)
+
+PERMISSIONS
+===========
+ALLOW: 4
+DENY: 1
+`, buf.String())
+
+	// No matches still prints the total, and empty lists rather than null.
+	c = countACLs(nil)
+	require.Equal(t, aclCounts{
+		Principals:    []aclCount{},
+		ResourceTypes: []aclCount{},
+		Permissions:   []aclCount{},
+	}, c)
+	buf.Reset()
+	printACLCounts(&buf, c)
+	require.Equal(t, "0 ACLs (0 ALLOW / 0 DENY)\n", buf.String())
+}
+
+func TestACLSort(t *testing.T) {
+	var (
+		topic = kmsg.ACLResourceTypeTopic
+		group = kmsg.ACLResourceTypeGroup
+		read  = kmsg.ACLOperationRead
+		write = kmsg.ACLOperationWrite
+		allow = kmsg.ACLPermissionTypeAllow
+		deny  = kmsg.ACLPermissionTypeDeny
+	)
+	binding := func(principal string, rt kmsg.ACLResourceType, name string, op kmsg.ACLOperation, perm kmsg.ACLPermissionType) acl {
+		return acl{principal, "*", rt, name, kmsg.ACLResourcePatternTypeLiteral, op, perm}
+	}
+	var (
+		aTopicFooRead  = binding("User:a", topic, "foo", read, allow)
+		aGroupBarWrite = binding("User:a", group, "bar", write, deny)
+		bTopicBarRead  = binding("User:b", topic, "bar", read, deny)
+		cTopicFooWrite = binding("User:c", topic, "foo", write, allow)
+	)
+	in := []acl{cTopicFooWrite, bTopicBarRead, aGroupBarWrite, aTopicFooRead}
+
+	for _, test := range []struct {
+		by      string
+		reverse bool
+		exp     []acl
+	}{
+		// Within User:a, the topic sorts first because TOPIC (2) is
+		// before GROUP (3) as resource type numbers.
+		{"", false, []acl{aTopicFooRead, aGroupBarWrite, bTopicBarRead, cTopicFooWrite}},
+		{"principal", false, []acl{aTopicFooRead, aGroupBarWrite, bTopicBarRead, cTopicFooWrite}},
+		{"principal", true, []acl{cTopicFooWrite, bTopicBarRead, aTopicFooRead, aGroupBarWrite}},
+		{"resource", false, []acl{bTopicBarRead, aTopicFooRead, cTopicFooWrite, aGroupBarWrite}},
+		{"resource", true, []acl{aGroupBarWrite, aTopicFooRead, cTopicFooWrite, bTopicBarRead}},
+		{"operation", false, []acl{aTopicFooRead, bTopicBarRead, aGroupBarWrite, cTopicFooWrite}},
+		{"operation", true, []acl{aGroupBarWrite, cTopicFooWrite, aTopicFooRead, bTopicBarRead}},
+		{"permission", false, []acl{aTopicFooRead, cTopicFooWrite, aGroupBarWrite, bTopicBarRead}},
+		{"permission", true, []acl{aGroupBarWrite, bTopicBarRead, aTopicFooRead, cTopicFooWrite}},
+	} {
+		s := aclSort{test.by, test.reverse}
+		require.NoError(t, s.validate())
+		got := append([]acl(nil), in...)
+		s.sort(got)
+		require.Equal(t, test.exp, got, "sort by %q, reverse %v", test.by, test.reverse)
+	}
+
+	require.Error(t, aclSort{by: "host"}.validate())
+}
@@ -10,7 +10,6 @@
 package acl
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/api/admin"
@@ -138,7 +137,7 @@ acl help text for more info.
 
 			err = cl.CreateUser(cmd.Context(), user, pass, mechanism)
 			out.MaybeDie(err, "unable to create user %q: %v", user, err)
-			fmt.Printf("Created user %q.\n", user)
+			out.Infof("Created user %q.", user)
 		},
 	}
 
@@ -192,7 +191,7 @@ delete any ACLs that may exist for this user.
 
 			err = cl.DeleteUser(cmd.Context(), user)
 			out.MaybeDie(err, "unable to delete user %q: %s", user, err)
-			fmt.Printf("Deleted user %q.\n", user)
+			out.Infof("Deleted user %q.", user)
 		},
 	}
 
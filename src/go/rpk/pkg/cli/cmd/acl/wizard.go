@@ -0,0 +1,137 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"golang.org/x/term"
+)
+
+// useWizard returns whether create should prompt for the ACL to create: only
+// if both stdin and stdout are terminals and none of create's own flags are
+// specified. Connection flags such as --brokers do not disable the wizard.
+func useWizard(cmd *cobra.Command) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return false
+	}
+	var changed bool
+	cmd.LocalNonPersistentFlags().Visit(func(*pflag.Flag) { changed = true })
+	return !changed
+}
+
+// wizardACL is everything the wizard prompts for.
+type wizardACL struct {
+	principal    string
+	permission   string // allow or deny
+	host         string
+	resourceType kmsg.ACLResourceType
+	resourceName string
+	pattern      string
+	operations   []string
+}
+
+// acls returns the acls that the flags equivalent to w would have created.
+// The result must still be validated with validateCreate.
+func (w wizardACL) acls() acls {
+	var a acls
+	if w.permission == "deny" {
+		a.denyPrincipals = []string{w.principal}
+		a.denyHosts = []string{w.host}
+	} else {
+		a.allowPrincipals = []string{w.principal}
+		a.allowHosts = []string{w.host}
+	}
+	switch w.resourceType {
+	case kmsg.ACLResourceTypeTopic:
+		a.topics = []string{w.resourceName}
+	case kmsg.ACLResourceTypeGroup:
+		a.groups = []string{w.resourceName}
+	case kmsg.ACLResourceTypeCluster:
+		a.cluster = true
+	case kmsg.ACLResourceTypeTransactionalId:
+		a.txnIDs = []string{w.resourceName}
+	case kmsg.ACLResourceTypeDelegationToken:
+		a.tokens = []string{w.resourceName}
+	}
+	a.resourcePatternType = w.pattern
+	a.operations = w.operations
+	return a
+}
+
+// validateResourceName validates a resource name per the checks that the
+// flags use.
+func validateResourceName(rt kmsg.ACLResourceType, name string) error {
+	if name == "" {
+		return errors.New("the resource name cannot be empty")
+	}
+	if rt == kmsg.ACLResourceTypeDelegationToken {
+		return validateTokenName(name)
+	}
+	return nil
+}
+
+// runWizard prompts for one principal, permission, host, resource, and any
+// number of operations, and returns the equivalent acls. Every answer is
+// validated as it is entered with the same helpers that validate flags.
+func runWizard() (acls, error) {
+	var (
+		w   wizardACL
+		err error
+	)
+	out.Infof("No flags specified, creating an ACL interactively (see --help for the equivalent flags).")
+
+	if w.principal, err = out.Input("", func(s string) error {
+		_, err := normalizePrincipal(s)
+		return err
+	}, "Principal (e.g. User:alice, or * for all users):"); err != nil {
+		return acls{}, err
+	}
+	if w.permission, err = out.Pick([]string{"allow", "deny"}, "Permission:"); err != nil {
+		return acls{}, err
+	}
+	if w.host, err = out.Input("*", validateHost, "Host the principal connects from (* for any host):"); err != nil {
+		return acls{}, err
+	}
+
+	rt, err := out.Pick(resourceTypes, "Resource type:")
+	if err != nil {
+		return acls{}, err
+	}
+	if w.resourceType, err = parseResourceType(rt); err != nil {
+		return acls{}, err
+	}
+	w.pattern = "literal"
+	if w.resourceType != kmsg.ACLResourceTypeCluster {
+		if w.resourceName, err = out.Input("", func(s string) error {
+			return validateResourceName(w.resourceType, s)
+		}, "%s name:", rt); err != nil {
+			return acls{}, err
+		}
+		if w.pattern, err = out.Pick(createPatterns, "Resource pattern type:"); err != nil {
+			return acls{}, err
+		}
+	}
+
+	ops := []string{"all"}
+	for _, op := range resourceOperations[w.resourceType] {
+		ops = append(ops, strings.ToLower(op.String()))
+	}
+	if w.operations, err = out.PickMany(ops, "Operations (all selects every operation below):"); err != nil {
+		return acls{}, err
+	}
+	return w.acls(), nil
+}
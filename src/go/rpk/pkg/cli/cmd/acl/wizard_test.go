@@ -0,0 +1,87 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestWizardACLs(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		in     wizardACL
+		exp    []kmsg.CreateACLsRequestCreation
+		expErr bool
+	}{
+		{
+			name: "allow prefixed topic",
+			in: wizardACL{
+				principal:    "alice",
+				permission:   "allow",
+				host:         "*",
+				resourceType: kmsg.ACLResourceTypeTopic,
+				resourceName: "orders.",
+				pattern:      "prefixed",
+				operations:   []string{"read", "describe"},
+			},
+			exp: []kmsg.CreateACLsRequestCreation{
+				{Principal: "User:alice", Host: "*", ResourceType: kmsg.ACLResourceTypeTopic, ResourceName: "orders.", ResourcePatternType: kmsg.ACLResourcePatternTypePrefixed, Operation: kmsg.ACLOperationRead, PermissionType: kmsg.ACLPermissionTypeAllow},
+				{Principal: "User:alice", Host: "*", ResourceType: kmsg.ACLResourceTypeTopic, ResourceName: "orders.", ResourcePatternType: kmsg.ACLResourcePatternTypePrefixed, Operation: kmsg.ACLOperationDescribe, PermissionType: kmsg.ACLPermissionTypeAllow},
+			},
+		},
+		{
+			name: "deny cluster from a host",
+			in: wizardACL{
+				principal:    "User:bob",
+				permission:   "deny",
+				host:         "10.0.0.1",
+				resourceType: kmsg.ACLResourceTypeCluster,
+				pattern:      "literal",
+				operations:   []string{"alter"},
+			},
+			exp: []kmsg.CreateACLsRequestCreation{
+				{Principal: "User:bob", Host: "10.0.0.1", ResourceType: kmsg.ACLResourceTypeCluster, ResourceName: kafkaCluster, ResourcePatternType: kmsg.ACLResourcePatternTypeLiteral, Operation: kmsg.ACLOperationAlter, PermissionType: kmsg.ACLPermissionTypeDeny},
+			},
+		},
+		{
+			name: "operations are validated like flags",
+			in: wizardACL{
+				principal:    "alice",
+				permission:   "allow",
+				host:         "*",
+				resourceType: kmsg.ACLResourceTypeGroup,
+				resourceName: "g",
+				pattern:      "literal",
+				operations:   []string{"write"},
+			},
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			a := test.in.acls()
+			err := a.validateCreate()
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, a.creations())
+		})
+	}
+}
+
+func TestValidateResourceName(t *testing.T) {
+	require.Error(t, validateResourceName(kmsg.ACLResourceTypeTopic, ""))
+	require.NoError(t, validateResourceName(kmsg.ACLResourceTypeTopic, "foo"))
+	require.Error(t, validateResourceName(kmsg.ACLResourceTypeDelegationToken, "User:alice"))
+}
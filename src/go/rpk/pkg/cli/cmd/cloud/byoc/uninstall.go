@@ -43,7 +43,7 @@ disk space.
 				fmt.Println(message)
 			}
 			if anyFailed {
-				os.Exit(1)
+				out.ExitWith(out.ExitError)
 			}
 		},
 	}
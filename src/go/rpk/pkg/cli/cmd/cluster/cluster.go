@@ -58,6 +58,7 @@ func NewCommand(fs afero.Fs) *cobra.Command {
 		newHealthOverviewCommand(fs),
 		newLogdirsCommand(fs),
 		newMetadataCommand(fs),
+		newPingCommand(fs),
 
 		config.NewConfigCommand(fs),
 		license.NewLicenseCommand(fs),
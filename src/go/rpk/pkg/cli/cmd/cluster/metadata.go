@@ -11,12 +11,12 @@ package cluster
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
 
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/cli/cmd/common"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
@@ -32,6 +32,8 @@ func newMetadataCommand(fs afero.Fs) *cobra.Command {
 		topics   bool
 		internal bool
 		detailed bool
+		versions bool
+		format   string
 	)
 	cmd := &cobra.Command{
 		Use:     "metadata",
@@ -52,15 +54,31 @@ can be printed with the -d flag, and internal topics can be printed with the -i
 flag.
 
 In the broker section, the controller node is suffixed with *.
+
+With --format json or yaml, the requested sections are printed as one object
+with the cluster ID, the controller ID, the brokers (ID, host, port, and rack),
+and a summary of each topic. This is a quick way to confirm which cluster rpk
+is pointed at before changing anything:
+
+    rpk cluster info -b --format json
+
+The API versions section, which is only printed if requested with -a, lists
+every Kafka API that the broker supports with the range of versions the broker
+and rpk support, from an ApiVersions request. APIs without a version in common
+are marked incompatible. For the ACL APIs, 'rpk acl create', 'list', and
+'delete' warn about such an incompatibility when connecting.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			p := config.ParamsFromCommand(cmd)
+			out.MaybeDieErr(p.Formatter.Validate())
 			cfg, err := p.Load(fs)
 			out.MaybeDie(err, "unable to load config: %v", err)
 
-			adm, err := kafka.NewAdmin(fs, p, cfg)
-			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
-			defer adm.Close()
+			seeds := strings.Join(cfg.Rpk.KafkaAPI.Brokers, ", ")
+			cl, err := kafka.NewConnectedClient(fs, p, cfg)
+			out.MaybeDie(err, "unable to connect to any of the brokers %s: %v", seeds, err)
+			defer cl.Close()
+			adm := kadm.NewClient(cl)
 
 			// We first evaluate whether any section was requested.
 			// If none were, we default to all sections. Only after
@@ -68,7 +86,7 @@ In the broker section, the controller node is suffixed with *.
 			// implies topics (and must come after defaulting all
 			// sections).
 			requestedSections := 0
-			for _, v := range []*bool{&cluster, &brokers, &topics} {
+			for _, v := range []*bool{&cluster, &brokers, &topics, &versions} {
 				if *v {
 					requestedSections++
 				}
@@ -92,13 +110,31 @@ In the broker section, the controller node is suffixed with *.
 				fn()
 			}
 
+			ctx, cancel := kafka.RequestContext(p)
+			defer cancel()
 			var m kadm.Metadata
 			if topics || len(args) > 0 {
-				m, err = adm.Metadata(context.Background(), args...)
+				m, err = adm.Metadata(ctx, args...)
 			} else {
-				m, err = adm.BrokerMetadata(context.Background())
+				m, err = adm.BrokerMetadata(ctx)
+			}
+			err = kafka.RequestErr(ctx, err)
+			out.MaybeDie(err, "unable to request metadata from brokers %s: %v", seeds, err)
+
+			var apiVersions []kafka.APIVersion
+			if versions {
+				apiVersions, err = kafka.FetchAPIVersions(ctx, cl)
+				err = kafka.RequestErr(ctx, err)
+				out.MaybeDie(err, "unable to request API versions from brokers %s: %v", seeds, err)
+			}
+
+			if !p.Formatter.IsText() {
+				info := newMetadataInfo(m, cluster, brokers, topics, internal)
+				info.APIVersions = apiVersions
+				err := p.Formatter.Print(info)
+				out.MaybeDie(err, "unable to print metadata: %v", err)
+				return
 			}
-			out.MaybeDie(err, "unable to request metadata: %v", err)
 
 			// We only print the cluster section if the response
 			// has a cluster.
@@ -117,6 +153,11 @@ In the broker section, the controller node is suffixed with *.
 					PrintTopics(m.Topics, internal, detailed)
 				})
 			}
+			if versions {
+				header("API VERSIONS", func() {
+					printAPIVersions(apiVersions)
+				})
+			}
 		},
 	}
 
@@ -125,9 +166,71 @@ In the broker section, the controller node is suffixed with *.
 	cmd.Flags().BoolVarP(&topics, "print-topics", "t", false, "Print topics section (implied if any topics are specified)")
 	cmd.Flags().BoolVarP(&internal, "print-internal-topics", "i", false, "Print internal topics (if all topics requested, implies -t)")
 	cmd.Flags().BoolVarP(&detailed, "print-detailed-topics", "d", false, "Print per-partition information for topics (implies -t)")
+	cmd.Flags().BoolVarP(&versions, "print-api-versions", "a", false, "Print the API versions that the broker and rpk support (not included by default)")
+	common.AddFormatFlag(cmd, &format)
+	common.AddRequestTimeoutFlag(cmd)
 	return cmd
 }
 
+type (
+	// metadataInfo is what we print with --format json or yaml. Sections
+	// that were not requested are omitted.
+	metadataInfo struct {
+		Cluster      string         `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+		ControllerID *int32         `json:"controllerID,omitempty" yaml:"controllerID,omitempty"`
+		Brokers      []brokerInfo   `json:"brokers,omitempty" yaml:"brokers,omitempty"`
+		Topics       []topicSummary `json:"topics,omitempty" yaml:"topics,omitempty"`
+
+		APIVersions []kafka.APIVersion `json:"apiVersions,omitempty" yaml:"apiVersions,omitempty"`
+	}
+	brokerInfo struct {
+		NodeID int32  `json:"nodeID" yaml:"nodeID"`
+		Host   string `json:"host" yaml:"host"`
+		Port   int32  `json:"port" yaml:"port"`
+		Rack   string `json:"rack,omitempty" yaml:"rack,omitempty"`
+	}
+	topicSummary struct {
+		Name       string `json:"name" yaml:"name"`
+		Internal   bool   `json:"internal,omitempty" yaml:"internal,omitempty"`
+		Partitions int    `json:"partitions" yaml:"partitions"`
+		Replicas   int    `json:"replicas" yaml:"replicas"`
+	}
+)
+
+func newMetadataInfo(m kadm.Metadata, cluster, brokers, topics, internal bool) metadataInfo {
+	var info metadataInfo
+	if cluster {
+		info.Cluster = m.Cluster
+		if m.Controller >= 0 {
+			controller := m.Controller
+			info.ControllerID = &controller
+		}
+	}
+	if brokers {
+		for _, b := range m.Brokers {
+			var rack string
+			if b.Rack != nil {
+				rack = *b.Rack
+			}
+			info.Brokers = append(info.Brokers, brokerInfo{b.NodeID, b.Host, b.Port, rack})
+		}
+	}
+	if topics {
+		for _, t := range m.Topics.Sorted() {
+			if t.IsInternal && !internal {
+				continue
+			}
+			info.Topics = append(info.Topics, topicSummary{
+				Name:       t.Topic,
+				Internal:   t.IsInternal,
+				Partitions: len(t.Partitions),
+				Replicas:   t.Partitions.NumReplicas(),
+			})
+		}
+	}
+	return info
+}
+
 func printBrokers(controllerID int32, brokers kadm.BrokerDetails) {
 	headers := []string{"ID", "HOST", "PORT"}
 	args := func(b *kadm.BrokerDetail) []interface{} {
@@ -162,6 +265,21 @@ func printBrokers(controllerID int32, brokers kadm.BrokerDetails) {
 	}
 }
 
+func printAPIVersions(versions []kafka.APIVersion) {
+	tw := out.NewTable("key", "name", "broker-min", "broker-max", "rpk-min", "rpk-max", "status")
+	defer tw.Flush()
+	for _, v := range versions {
+		rpkMin, rpkMax, status := interface{}(v.RpkMin), interface{}(v.RpkMax), "ok"
+		switch {
+		case !v.Known():
+			rpkMin, rpkMax, status = "-", "-", "unknown to rpk"
+		case !v.Compatible():
+			status = "incompatible"
+		}
+		tw.Print(v.Key, v.Name, v.BrokerMin, v.BrokerMax, rpkMin, rpkMax, status)
+	}
+}
+
 func PrintTopics(topics kadm.TopicDetails, internal, detailed bool) {
 	if !detailed {
 		tw := out.NewTable("NAME", "PARTITIONS", "REPLICAS")
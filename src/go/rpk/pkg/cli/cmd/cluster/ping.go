@@ -0,0 +1,144 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package cluster
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/cli/cmd/common"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// pingInfo is what we print for a successful ping with --format json or
+// yaml.
+type pingInfo struct {
+	Broker        string `json:"broker" yaml:"broker"`
+	TLS           bool   `json:"tls" yaml:"tls"`
+	SASLMechanism string `json:"saslMechanism,omitempty" yaml:"saslMechanism,omitempty"`
+	SASLUser      string `json:"saslUser,omitempty" yaml:"saslUser,omitempty"`
+	ClusterID     string `json:"clusterID,omitempty" yaml:"clusterID,omitempty"`
+	Brokers       int    `json:"brokers" yaml:"brokers"`
+	LatencyMillis int64  `json:"latencyMillis" yaml:"latencyMillis"`
+}
+
+func newPingCommand(fs afero.Fs) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "ping",
+		Short: "Check that rpk can connect and authenticate to the cluster",
+		Long: `Check that rpk can connect and authenticate to the cluster.
+
+This connects to the brokers exactly as every other command does, using the
+same flags, environment variables, and config file, including TLS and SASL.
+It then issues a single metadata request for no topics, which requires the
+TLS handshake and SASL authentication to succeed. Nothing in the cluster is
+changed; this is a cheap check to run before a batch of changes in a
+pipeline.
+
+On success, this prints the broker that answered, whether TLS was used, the
+SASL mechanism (resolved if --sasl-mechanism is auto), the cluster ID, the
+number of brokers in the cluster, and how long the request took, and exits 0.
+A broker address followed by a negative ID is a seed broker that has not been
+matched to a broker ID yet.
+
+On failure, this prints why connecting failed (authentication failed, TLS
+handshake failed, unable to connect through the proxy, timed out, unable to
+connect, or request failed) followed by the error. Authentication and
+connection failures exit 2, and anything else follows rpk's usual exit codes.
+
+The check is issued once, without --retries, and is bounded by
+--request-timeout. With --quiet, nothing is printed on success, so only the
+exit code is left to check.
+`,
+		Args: cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, _ []string) {
+			p := config.ParamsFromCommand(cmd)
+			out.MaybeDieErr(p.Formatter.Validate())
+			cfg, err := p.Load(fs)
+			out.MaybeDie(err, "unable to load config: %v", err)
+
+			k := &cfg.Rpk.KafkaAPI
+			seeds := strings.Join(k.Brokers, ", ")
+			cl, err := kafka.NewFranzClient(fs, p, cfg)
+			if err != nil {
+				out.DieCode(out.ExitCode(err), "unable to initialize kafka client: %v", err)
+			}
+			defer cl.Close()
+
+			ctx, cancel := kafka.RequestContext(p)
+			defer cancel()
+			r, err := kafka.Ping(ctx, cl)
+			if err = kafka.RequestErr(ctx, err); err != nil {
+				out.DieCode(out.ExitCode(err), "%s: unable to ping any of the brokers %s: %v", kafka.ConnErrCategory(err), seeds, err)
+			}
+
+			info := pingInfo{
+				Broker:        kafka.MetaString(r.Broker),
+				TLS:           k.TLS != nil,
+				SASLMechanism: kafka.SASLMechanism(k),
+				ClusterID:     r.ClusterID,
+				Brokers:       len(r.Brokers),
+				LatencyMillis: r.Latency.Milliseconds(),
+			}
+			// Seed brokers have negative node IDs; if the seed is
+			// one of the returned brokers, we print its real ID.
+			for _, b := range r.Brokers {
+				if net.JoinHostPort(b.Host, strconv.Itoa(int(b.Port))) == net.JoinHostPort(r.Broker.Host, strconv.Itoa(int(r.Broker.Port))) {
+					r.Broker.NodeID = b.NodeID
+					info.Broker = kafka.MetaString(r.Broker)
+					break
+				}
+			}
+			if info.SASLMechanism != "" && k.SASL.User != "" && info.SASLMechanism != config.SASLMechanismOAuth {
+				info.SASLUser = k.SASL.User
+			}
+
+			if !p.Formatter.IsText() {
+				err := p.Formatter.Print(info)
+				out.MaybeDie(err, "unable to print ping result: %v", err)
+				return
+			}
+			if out.IsQuiet() {
+				return // the exit code is the result
+			}
+			tw := out.NewTabWriter()
+			defer tw.Flush()
+			tw.Print("BROKER", info.Broker)
+			tls := "disabled"
+			if info.TLS {
+				tls = "enabled"
+			}
+			tw.Print("TLS", tls)
+			sasl := "none"
+			if info.SASLMechanism != "" {
+				sasl = info.SASLMechanism
+				if info.SASLUser != "" {
+					sasl += " (user " + strconv.Quote(info.SASLUser) + ")"
+				}
+			}
+			tw.Print("SASL", sasl)
+			if info.ClusterID != "" {
+				tw.Print("CLUSTER", info.ClusterID)
+			}
+			tw.Print("BROKERS", info.Brokers)
+			tw.Print("LATENCY", r.Latency.Round(100*time.Microsecond))
+		},
+	}
+	common.AddFormatFlag(cmd, &format)
+	common.AddRequestTimeoutFlag(cmd)
+	return cmd
+}
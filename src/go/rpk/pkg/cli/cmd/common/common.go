@@ -11,8 +11,10 @@ package common
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
 	"github.com/spf13/cobra"
 )
 
@@ -31,6 +33,41 @@ func Deprecated(newCmd *cobra.Command, newUse string) *cobra.Command {
 	return newCmd
 }
 
+// AddFormatFlag adds the persistent --format flag, which commands read through
+// config.Params' Formatter.
+func AddFormatFlag(command *cobra.Command, format *string) *cobra.Command {
+	command.PersistentFlags().StringVar(
+		format,
+		config.FlagFormat,
+		"text",
+		"Output format for commands that support it ("+strings.Join(out.FormatKinds, ", ")+"); json and jsonl also write errors to stderr as JSON",
+	)
+	return command
+}
+
+// AddColorFlag adds --color, which commands read through config.Params' Color
+// and pass to out.NewStyledTable.
+func AddColorFlag(command *cobra.Command) *cobra.Command {
+	command.Flags().String(
+		config.FlagColor,
+		out.ColorAuto,
+		"When to color table output ("+strings.Join(out.ColorModes, ", ")+"); auto colors only if stdout is a terminal",
+	)
+	return command
+}
+
+// AddRequestTimeoutFlag adds --request-timeout, which bounds each request
+// that a command issues to the cluster. Commands that add this flag must use
+// kafka.RequestContext for their requests.
+func AddRequestTimeoutFlag(command *cobra.Command) *cobra.Command {
+	command.PersistentFlags().Duration(
+		config.FlagRequestTimeout,
+		config.DefaultRequestTimeout,
+		"Timeout for each request to the cluster, as a duration (e.g. 30s, 2m); 0 disables the timeout",
+	)
+	return command
+}
+
 func AddKafkaFlags(
 	command *cobra.Command,
 	configFile, user, password, saslMechanism *string,
@@ -42,10 +79,45 @@ func AddKafkaFlags(
 		brokers,
 		"brokers",
 		[]string{},
-		"Comma-separated list of broker ip:port pairs (e.g."+
-			" --brokers '192.168.78.34:9092,192.168.78.35:9092,192.179.23.54:9092')."+
+		"Comma-separated list of broker host:port pairs (e.g."+
+			" --brokers '192.168.78.34:9092,192.168.78.35:9092,[::1]:9092');"+
+			" the port defaults to 9092 if missing."+
 			" Alternatively, you may set the REDPANDA_BROKERS environment"+
-			" variable with the comma-separated list of broker addresses",
+			" variable with the comma-separated list of broker addresses."+
+			" If no brokers are specified, rpk uses the redpanda.kafka_api"+
+			" listeners of the config file, or 127.0.0.1:9092",
+	)
+	command.PersistentFlags().String(
+		config.FlagBrokersFile,
+		"",
+		"File listing one broker host:port per line (blank lines and # comments"+
+			" are ignored); takes precedence over REDPANDA_BROKERS and the config"+
+			" file, and is merged with --brokers if both are specified",
+	)
+	command.PersistentFlags().String(
+		config.FlagProxy,
+		"",
+		"Proxy to connect to brokers through, as socks5://host:port or"+
+			" http://host:port (HTTP CONNECT); user:pass@ in the URL is used for"+
+			" proxy authentication. If unset, ALL_PROXY or HTTPS_PROXY is used,"+
+			" except for brokers matching NO_PROXY",
+	)
+	command.PersistentFlags().Bool(
+		config.FlagNoShuffle,
+		false,
+		"Connect to the brokers in the order they are specified; by default,"+
+			" the first connection goes to a random broker so that many rpk"+
+			" processes do not all bootstrap from the first one",
+	)
+	command.PersistentFlags().Bool(
+		config.FlagIgnoreConfigConnection,
+		false,
+		"Ignore the rpk.kafka_api and rpk.admin_api sections of the config file,"+
+			" connecting only with flags and environment variables. By default,"+
+			" flags and environment variables override the config file field by"+
+			" field, and the file fills in anything unspecified. The config file"+
+			" is ignored for the Kafka connection (but not the Admin API)"+
+			" whenever --brokers, a SASL flag, and a TLS flag are all specified",
 	)
 	command.PersistentFlags().StringVar(
 		configFile,
@@ -64,13 +136,55 @@ func AddKafkaFlags(
 		password,
 		"password",
 		"",
-		"SASL password to be used for authentication",
+		"SASL password to be used for authentication; prefer --password-file"+
+			" or --password-stdin, which keep the password out of shell history"+
+			" and process listings",
+	)
+	command.PersistentFlags().String(
+		config.FlagSASLPassFile,
+		"",
+		"File to read the SASL password from; a trailing newline is trimmed",
+	)
+	command.PersistentFlags().Bool(
+		config.FlagSASLPassStdin,
+		false,
+		"Read the SASL password from stdin; a trailing newline is trimmed."+
+			" All of stdin is read, so this cannot be used with interactive"+
+			" prompts or with commands that read input from stdin",
 	)
 	command.PersistentFlags().StringVar(
 		saslMechanism,
 		config.FlagSASLMechanism,
 		"",
-		"The authentication mechanism to use. Supported values: SCRAM-SHA-256, SCRAM-SHA-512",
+		"The authentication mechanism to use. Supported values: SCRAM-SHA-256, SCRAM-SHA-512, OAUTHBEARER, or auto to use the best mechanism that the broker supports",
+	)
+	command.PersistentFlags().String(
+		config.FlagOAuthToken,
+		"",
+		"OAuth token to authenticate with, for --sasl-mechanism OAUTHBEARER",
+	)
+	command.PersistentFlags().String(
+		config.FlagOAuthTokenCmd,
+		"",
+		"Command that prints an OAuth token to stdout, for --sasl-mechanism OAUTHBEARER;"+
+			" the command is run every time rpk connects to a broker",
+	)
+
+	command.PersistentFlags().String(
+		config.FlagProfile,
+		"",
+		"Named profile in rpk.profiles of the config file to use for broker, SASL, and TLS settings."+
+			" Alternatively, you may set the RPK_PROFILE environment variable",
+	)
+	command.PersistentFlags().Int(
+		config.FlagRetries,
+		config.DefaultRetries,
+		"Number of times to retry connecting to the cluster on transient errors (connection refused, timeout)",
+	)
+	command.PersistentFlags().Duration(
+		config.FlagRetryBackoff,
+		config.DefaultRetryBackoff,
+		"Backoff before the first connection retry, doubling on every retry",
 	)
 
 	AddTLSFlags(command, enableTLS, certFile, keyFile, truststoreFile)
@@ -107,6 +221,16 @@ func AddTLSFlags(
 		"",
 		"The truststore to be used for TLS communication with the broker",
 	)
+	command.PersistentFlags().String(
+		config.FlagTLSServerName,
+		"",
+		"Name to verify the broker certificates against and to send as SNI, instead of the broker host; use this when brokers are addressed by IP but their certificates contain hostnames",
+	)
+	command.PersistentFlags().Bool(
+		config.FlagTLSInsecure,
+		false,
+		"DANGEROUS: do not verify broker certificates, leaving the connection open to interception; only for testing",
+	)
 
 	return command
 }
@@ -140,6 +264,16 @@ func AddAdminAPITLSFlags(
 		"",
 		"The truststore to be used for TLS communication with the Admin API",
 	)
+	command.PersistentFlags().String(
+		config.FlagAdminTLSServerName,
+		"",
+		"Name to verify the Admin API certificates against and to send as SNI, instead of the Admin API host",
+	)
+	command.PersistentFlags().Bool(
+		config.FlagAdminTLSInsecure,
+		false,
+		"DANGEROUS: do not verify Admin API certificates, leaving the connection open to interception; only for testing",
+	)
 
 	return command
 }
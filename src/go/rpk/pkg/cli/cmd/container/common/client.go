@@ -12,6 +12,9 @@ package common
 import (
 	"context"
 	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -102,13 +105,58 @@ type dockerClient struct {
 }
 
 func NewDockerClient() (Client, error) {
-	c, err := client.NewClientWithOpts(client.FromEnv)
+	c, err := client.NewClientWithOpts(client.FromEnv, directLocalDaemon)
 	if err != nil {
 		return nil, err
 	}
 	return &dockerClient{c}, nil
 }
 
+// directLocalDaemon is a client option that connects directly to a local
+// Docker daemon, i.e. a unix socket, a named pipe, or a loopback address.
+// The Docker client proxies TCP hosts with HTTP(S)_PROXY and ALL_PROXY;
+// NO_PROXY is honored, but corporate environments rarely list the daemon in
+// it, and a proxy can never reach a daemon on the local machine anyway.
+func directLocalDaemon(c *client.Client) error {
+	hostURL, err := client.ParseHostURL(c.DaemonHost())
+	if err != nil {
+		return err
+	}
+	// HTTPClient returns a copy of the client, but the transport is
+	// shared.
+	tr, ok := c.HTTPClient().Transport.(*http.Transport)
+	if !ok || !isLocalDaemon(hostURL) {
+		return nil
+	}
+	tr.Proxy = nil
+	switch hostURL.Scheme {
+	case "unix", "npipe":
+		// The transport already dials the socket or pipe directly.
+	default:
+		// The Docker client sets the deprecated Dial to an ALL_PROXY
+		// dialer; DialContext takes priority over it.
+		tr.DialContext = (&net.Dialer{Timeout: 32 * time.Second}).DialContext
+	}
+	return nil
+}
+
+// isLocalDaemon returns whether the Docker host is on the local machine.
+func isLocalDaemon(hostURL *url.URL) bool {
+	switch hostURL.Scheme {
+	case "unix", "npipe":
+		return true
+	}
+	host, _, err := net.SplitHostPort(hostURL.Host)
+	if err != nil {
+		host = hostURL.Host
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func (*dockerClient) IsErrNotFound(err error) bool {
 	return client.IsErrNotFound(err)
 }
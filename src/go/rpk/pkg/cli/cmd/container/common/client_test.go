@@ -0,0 +1,101 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package common
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/require"
+)
+
+// setUnreachableProxies points every proxy variable at an address where
+// nothing listens, so that any proxied connection fails.
+func setUnreachableProxies(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	dead := l.Addr().String()
+	l.Close()
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		t.Setenv(k, "http://"+dead)
+	}
+	t.Setenv("ALL_PROXY", "socks5://"+dead)
+	t.Setenv("all_proxy", "socks5://"+dead)
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+}
+
+func listContainers(t *testing.T, host string) {
+	t.Setenv("DOCKER_HOST", host)
+	c, err := NewDockerClient()
+	require.NoError(t, err)
+	defer c.Close()
+
+	tr := c.(*dockerClient).HTTPClient().Transport.(*http.Transport)
+	require.Nil(t, tr.Proxy, "the local daemon must not be proxied")
+
+	_, err = c.ContainerList(context.Background(), types.ContainerListOptions{})
+	require.NoError(t, err)
+}
+
+func newDaemon() *httptest.Server {
+	return httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+}
+
+func TestNewDockerClientUnixSocketBypassesProxy(t *testing.T) {
+	setUnreachableProxies(t)
+
+	sock := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	s := newDaemon()
+	s.Listener = l
+	s.Start()
+	defer s.Close()
+
+	listContainers(t, "unix://"+sock)
+}
+
+func TestNewDockerClientLoopbackBypassesProxy(t *testing.T) {
+	setUnreachableProxies(t)
+
+	s := newDaemon()
+	s.Start()
+	defer s.Close()
+
+	listContainers(t, "tcp://"+s.Listener.Addr().String())
+}
+
+func TestIsLocalDaemon(t *testing.T) {
+	for _, test := range []struct {
+		host string
+		exp  bool
+	}{
+		{"unix:///var/run/docker.sock", true},
+		{"tcp://localhost:2375", true},
+		{"tcp://127.0.0.1:2375", true},
+		{"tcp://[::1]:2375", true},
+		{"tcp://docker.example.com:2376", false},
+		{"tcp://10.0.0.1:2375", false},
+	} {
+		u, err := client.ParseHostURL(test.host)
+		require.NoError(t, err)
+		require.Equal(t, test.exp, isLocalDaemon(u), "host %s", test.host)
+	}
+}
@@ -153,3 +153,24 @@ func TestModeCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestModeNoConfig(t *testing.T) {
+	const configPath = "/etc/redpanda/redpanda.yaml"
+	fs := afero.NewMemMapFs()
+	bs, err := yaml.Marshal(fillRpkConfig(configPath, config.ModeProd))
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, configPath, bs, 0o644))
+
+	// --no-config is a root flag; the mode command only sees it as a
+	// parsed flag.
+	cmd := NewModeCommand(fs)
+	cmd.Flags().Bool(config.FlagNoConfig, false, "")
+	require.NoError(t, cmd.ParseFlags([]string{"--" + config.FlagNoConfig}))
+
+	err = executeMode(fs, cmd, config.ModeDev)
+	require.Error(t, err)
+
+	after, err := afero.ReadFile(fs, configPath)
+	require.NoError(t, err)
+	require.Equal(t, string(bs), string(after), "the existing config must not be overwritten")
+}
@@ -149,7 +149,7 @@ func NewStartCommand(fs afero.Fs, launcher rp.Launcher) *cobra.Command {
 			// blows up when there's a JSON object.
 			configKvs, filteredArgs := parseConfigKvs(os.Args)
 			p := config.ParamsFromCommand(cmd)
-			cfg, err := p.Load(fs)
+			cfg, err := p.LoadAllowMissing(fs)
 			if err != nil {
 				return fmt.Errorf("unable to load config file: %s", err)
 			}
@@ -106,7 +106,7 @@ To learn more about a tuner, run 'rpk redpanda tune help <tuner name>'.
 			exit1, err := tune(cfg, tuners, tunerFactory, &tunerParams)
 			out.MaybeDieErr(err)
 			if exit1 {
-				os.Exit(1)
+				out.ExitWith(out.ExitError)
 			}
 		},
 	}
@@ -44,13 +44,15 @@ import (
 )
 
 func Execute() {
-	verbose := false
+	var verbose, quiet bool
 	fs := afero.NewOsFs()
 
 	if !term.IsTerminal(int(os.Stdout.Fd())) {
 		color.NoColor = true
 	}
-	log.SetFormatter(cli.NewRpkLogFormatter())
+	// Info and above is logged to stdout, and verbose debug logs are
+	// written to stderr by the formatter.
+	log.SetFormatter(cli.NewLevelOutputFormatter(cli.NewRpkLogFormatter()))
 	log.SetOutput(os.Stdout)
 
 	cobra.OnInitialize(func() {
@@ -61,6 +63,7 @@ func Execute() {
 		} else {
 			log.SetLevel(log.InfoLevel)
 		}
+		out.SetQuiet(quiet)
 	})
 
 	root := &cobra.Command{
@@ -71,9 +74,23 @@ func Execute() {
 		CompletionOptions: cobra.CompletionOptions{
 			DisableDefaultCmd: true,
 		},
+
+		// Commands with --format write errors in that format, even
+		// errors from before they parse their params.
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			if f := cmd.Flags().Lookup(config.FlagFormat); f != nil {
+				out.SetErrorFormatter(out.Formatter{Kind: f.Value.String()})
+			}
+		},
 	}
 	root.PersistentFlags().BoolVarP(&verbose, config.FlagVerbose,
 		"v", false, "Enable verbose logging (default: false)")
+	root.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
+		"Suppress informational messages and warnings; errors are still written to stderr, and command output such as tables and --format output to stdout")
+	root.PersistentFlags().Bool(config.FlagNoConfig, false,
+		"Do not read any config file, using only flags, environment variables, and defaults; cannot be used with --config")
+	root.PersistentFlags().Bool(config.FlagMetrics, false,
+		"Print a summary of the Kafka requests rpk issued (counts, retries, and latency per request type) to stderr when the command exits")
 
 	root.AddCommand(
 		acl.NewCommand(fs),
@@ -119,7 +136,7 @@ func Execute() {
 		c.Flags().BoolP("help", "h", false, "Help for "+c.Name())
 	})
 
-	err := root.Execute()
+	err := root.ExecuteContext(out.InterruptContext())
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "check":
@@ -129,8 +146,9 @@ func Execute() {
 		}
 	}
 	if err != nil {
-		os.Exit(1)
+		out.ExitWith(out.ExitCode(err))
 	}
+	out.RunAtExit()
 }
 
 // See the two use cases for this.
@@ -13,7 +13,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
@@ -49,7 +48,7 @@ func newAddPartitionsCommand(fs afero.Fs) *cobra.Command {
 			var exit1 bool
 			defer func() {
 				if exit1 {
-					os.Exit(1)
+					out.ExitWith(out.ExitError)
 				}
 			}()
 
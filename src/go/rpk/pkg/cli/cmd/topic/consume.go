@@ -79,6 +79,7 @@ func newConsumeCommand(fs afero.Fs) *cobra.Command {
 			out.MaybeDie(err, "unable to initialize admin kafka client: %v", err)
 
 			err = c.parseOffset(offset, topics, adm)
+			adm.Close() // the admin client is only needed to resolve offsets
 			out.MaybeDie(err, "invalid --offset %q: %v", offset, err)
 			if allEmpty := c.filterEmptyPartitions(); allEmpty {
 				return
@@ -98,28 +99,7 @@ func newConsumeCommand(fs afero.Fs) *cobra.Command {
 			c.cl, err = kafka.NewFranzClient(fs, p, cfg, opts...)
 			out.MaybeDie(err, "unable to initialize kafka client: %v", err)
 
-			doneConsume := make(chan struct{})
-			go func() {
-				defer close(doneConsume)
-				c.consume()
-				c.cl.LeaveGroup()
-			}()
-
-			select {
-			case <-sigs:
-			case <-doneConsume:
-			}
-
-			doneClose := make(chan struct{})
-			go func() {
-				defer close(doneClose)
-				c.cl.Close()
-			}()
-
-			select {
-			case <-sigs:
-			case <-doneClose:
-			}
+			c.run(sigs)
 		},
 	}
 
@@ -147,6 +127,33 @@ func newConsumeCommand(fs afero.Fs) *cobra.Command {
 	return cmd
 }
 
+// run consumes until done or until the first signal, and then leaves the
+// group and closes the client. A second signal stops waiting for the close.
+func (c *consumer) run(sigs <-chan os.Signal) {
+	doneConsume := make(chan struct{})
+	go func() {
+		defer close(doneConsume)
+		c.consume()
+		c.cl.LeaveGroup()
+	}()
+
+	select {
+	case <-sigs:
+	case <-doneConsume:
+	}
+
+	doneClose := make(chan struct{})
+	go func() {
+		defer close(doneClose)
+		c.cl.Close()
+	}()
+
+	select {
+	case <-sigs:
+	case <-doneClose:
+	}
+}
+
 func (c *consumer) consume() {
 	var (
 		buf   []byte
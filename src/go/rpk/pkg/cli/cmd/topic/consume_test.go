@@ -1,8 +1,17 @@
 package topic
 
 import (
+	"os"
+	"os/signal"
+	"syscall"
 	"testing"
 	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka/kafkatest"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
 func TestParseFromToOffset(t *testing.T) {
@@ -266,3 +275,33 @@ func TestParseConsumeTimestamp(t *testing.T) {
 		}
 	}
 }
+
+func TestConsumeInterrupt(t *testing.T) {
+	// As in Execute, the root context is from out.InterruptContext.
+	// Consume handles signals itself, and rpk must not exit or cancel
+	// the root context underneath it.
+	rootCtx := out.InterruptContext()
+
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response { return req.ResponseKind() })
+	cl, err := kgo.NewClient(kgo.SeedBrokers(b.Addr()))
+	require.NoError(t, err)
+	c := &consumer{cl: cl}
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.run(sigs)
+	}()
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("consume did not stop on Ctrl-C")
+	}
+	require.NoError(t, rootCtx.Err(), "the root context must not be canceled for consume")
+}
@@ -13,7 +13,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka"
@@ -91,7 +90,7 @@ the cleanup.policy=compact config option set.
 			var exit1 bool
 			defer func() {
 				if exit1 {
-					os.Exit(1)
+					out.ExitWith(out.ExitError)
 				}
 			}()
 
@@ -13,8 +13,10 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os"
 
 	"github.com/fatih/color"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
 	"github.com/sirupsen/logrus"
 )
 
@@ -62,3 +64,42 @@ func (rpkLogFormatter) getPrinter(
 	}
 	return fmt.Fprintln
 }
+
+// levelOutputFormatter formats entries with the wrapped formatter for the
+// logger's output, which is stdout, except for debug and trace entries, which
+// it writes to stderr itself. Verbose logging therefore does not mix with
+// command output such as --format json. With --quiet, info and warning
+// entries are dropped and errors are written to stderr, so that nothing but
+// requested output is written to stdout.
+//
+// Entries that are written to stderr or dropped are formatted as nothing for
+// the logger's output. Code that writes to the logger's output directly, such
+// as tables, is unaffected.
+type levelOutputFormatter struct {
+	inner logrus.Formatter
+}
+
+// NewLevelOutputFormatter returns a formatter that routes entries to stdout
+// or stderr depending on their level, formatting them with inner.
+func NewLevelOutputFormatter(inner logrus.Formatter) logrus.Formatter {
+	return &levelOutputFormatter{inner}
+}
+
+func (f *levelOutputFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	var toStderr bool
+	switch {
+	case e.Level >= logrus.DebugLevel:
+		toStderr = true
+	case !out.IsQuiet():
+	case e.Level == logrus.InfoLevel, e.Level == logrus.WarnLevel:
+		return nil, nil
+	default:
+		toStderr = true
+	}
+	b, err := f.inner.Format(e)
+	if err != nil || !toStderr {
+		return b, err
+	}
+	_, err = os.Stderr.Write(b)
+	return nil, err
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelOutputFormatter(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		quiet     bool
+		expStdout string
+		expStderr string
+	}{
+		{
+			name:      "verbose",
+			expStdout: "info\nwarn\nerror\n",
+			expStderr: "debug\n",
+		},
+		{
+			// --quiet --verbose: debug logs are still written to
+			// stderr, but nothing is written to stdout.
+			name:      "quiet verbose",
+			quiet:     true,
+			expStderr: "debug\nerror\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			out.SetQuiet(test.quiet)
+			defer out.SetQuiet(false)
+
+			dir := t.TempDir()
+			stdout, err := os.Create(filepath.Join(dir, "stdout"))
+			require.NoError(t, err)
+			stderr, err := os.Create(filepath.Join(dir, "stderr"))
+			require.NoError(t, err)
+			origOut, origErr := os.Stdout, os.Stderr
+			os.Stdout, os.Stderr = stdout, stderr
+			defer func() { os.Stdout, os.Stderr = origOut, origErr }()
+
+			l := logrus.New()
+			l.SetFormatter(NewLevelOutputFormatter(NewNoopFormatter()))
+			l.SetOutput(os.Stdout)
+			l.SetLevel(logrus.DebugLevel)
+			for _, msg := range []string{"debug\n", "info\n", "warn\n", "error\n"} {
+				lvl, _ := logrus.ParseLevel(msg[:len(msg)-1])
+				l.Log(lvl, msg)
+			}
+
+			os.Stdout, os.Stderr = origOut, origErr
+			for _, c := range []struct {
+				f   *os.File
+				exp string
+			}{{stdout, test.expStdout}, {stderr, test.expStderr}} {
+				c.f.Close()
+				b, err := os.ReadFile(c.f.Name())
+				require.NoError(t, err)
+				require.Equal(t, c.exp, string(b))
+			}
+		})
+	}
+}
@@ -101,6 +101,7 @@ func setDevelopment(conf *Config) *Config {
 		BallastFilePath:      conf.Rpk.BallastFilePath,
 		BallastFileSize:      conf.Rpk.BallastFileSize,
 		Overprovisioned:      true,
+		Profiles:             conf.Rpk.Profiles,
 	}
 	return conf
 }
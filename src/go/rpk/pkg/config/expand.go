@@ -0,0 +1,189 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// envExpansion is a config field whose file value referenced environment
+// variables, so that Write can write back the reference rather than the
+// expanded value, which may be a secret.
+type envExpansion struct {
+	field    *string
+	raw      string
+	expanded string
+}
+
+// expandEnv expands the environment variable references in the rpk
+// connection fields of a config file: broker and admin API addresses, TLS
+// file paths and server names, and SASL credentials, in rpk.kafka_api,
+// rpk.admin_api, the deprecated rpk.tls and rpk.sasl, and every profile. The
+// SASL token_command is left alone, since it is run by a shell that expands
+// variables itself. See expandEnvString for the syntax.
+func (c *Config) expandEnv(lookup func(string) (string, bool)) error {
+	type field struct {
+		name string
+		v    *string
+	}
+	var fields []field
+	addTLS := func(prefix string, t *TLS) {
+		if t == nil {
+			return
+		}
+		fields = append(fields,
+			field{prefix + ".key_file", &t.KeyFile},
+			field{prefix + ".cert_file", &t.CertFile},
+			field{prefix + ".truststore_file", &t.TruststoreFile},
+			field{prefix + ".server_name", &t.ServerName},
+		)
+	}
+	addSASL := func(prefix string, s *SASL) {
+		if s == nil {
+			return
+		}
+		fields = append(fields,
+			field{prefix + ".user", &s.User},
+			field{prefix + ".password", &s.Password},
+			field{prefix + ".type", &s.Mechanism},
+			field{prefix + ".token", &s.Token},
+		)
+	}
+	addAddrs := func(prefix string, addrs []string) {
+		for i := range addrs {
+			fields = append(fields, field{fmt.Sprintf("%s[%d]", prefix, i), &addrs[i]})
+		}
+	}
+	addAPIs := func(prefix string, k *RpkKafkaAPI, a *RpkAdminAPI) {
+		addAddrs(prefix+".kafka_api.brokers", k.Brokers)
+		addTLS(prefix+".kafka_api.tls", k.TLS)
+		addSASL(prefix+".kafka_api.sasl", k.SASL)
+		addAddrs(prefix+".admin_api.addresses", a.Addresses)
+		addTLS(prefix+".admin_api.tls", a.TLS)
+	}
+
+	r := &c.Rpk
+	addTLS("rpk.tls", r.TLS)
+	addSASL("rpk.sasl", r.SASL)
+	addAPIs("rpk", &r.KafkaAPI, &r.AdminAPI)
+	names := make([]string, 0, len(r.Profiles))
+	for name := range r.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// Profiles are map values, but every field we expand is
+		// behind a pointer or in a slice, so expanding the copy
+		// expands the map's profile.
+		p := r.Profiles[name]
+		addAPIs("rpk.profiles."+name, &p.KafkaAPI, &p.AdminAPI)
+	}
+
+	for _, f := range fields {
+		expanded, err := expandEnvString(*f.v, lookup)
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.name, err)
+		}
+		if expanded != *f.v {
+			c.envExpansions = append(c.envExpansions, envExpansion{f.v, *f.v, expanded})
+			*f.v = expanded
+		}
+	}
+	return nil
+}
+
+// expandEnvString expands environment variable references in s:
+//
+//   - ${VAR} and $VAR are replaced with the value of VAR, which must be set
+//   - ${VAR:-default} is replaced with the value of VAR, or with default if
+//     VAR is unset or empty
+//   - $$ is a literal $, and a $ that does not start a reference is kept
+//
+// Variable names are letters, digits, and underscores, and do not start with
+// a digit. Errors never include s, which may be a secret.
+func expandEnvString(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+
+		case next == '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ reference")
+			}
+			name, def, hasDef := strings.Cut(s[i+2:i+2+end], ":-")
+			if !isEnvName(name) {
+				return "", fmt.Errorf("invalid environment variable name %q in ${} reference", name)
+			}
+			v, ok := lookup(name)
+			switch {
+			case hasDef && v == "":
+				v = def
+			case !ok:
+				return "", unsetEnvErr(name)
+			}
+			b.WriteString(v)
+			i += 2 + end
+
+		case isEnvNameStart(next):
+			j := i + 2
+			for j < len(s) && isEnvNameChar(s[j]) {
+				j++
+			}
+			name := s[i+1 : j]
+			v, ok := lookup(name)
+			if !ok {
+				return "", unsetEnvErr(name)
+			}
+			b.WriteString(v)
+			i = j - 1
+
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+func unsetEnvErr(name string) error {
+	return fmt.Errorf("environment variable %s is not set; use ${%s:-default} for a default value, or $$ for a literal $", name, name)
+}
+
+func isEnvNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isEnvNameChar(c byte) bool {
+	return isEnvNameStart(c) || '0' <= c && c <= '9'
+}
+
+func isEnvName(s string) bool {
+	if s == "" || !isEnvNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isEnvNameChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
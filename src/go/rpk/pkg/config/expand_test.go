@@ -0,0 +1,120 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnvString(t *testing.T) {
+	env := map[string]string{
+		"USER":  "admin",
+		"PASS":  "s3cr$t",
+		"EMPTY": "",
+	}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	for _, test := range []struct {
+		in     string
+		exp    string
+		expErr string
+	}{
+		{in: "plain", exp: "plain"},
+		{in: "${USER}", exp: "admin"},
+		{in: "$USER", exp: "admin"},
+		{in: "${USER}-$PASS", exp: "admin-s3cr$t"},
+		{in: "$USER.example.com:9092", exp: "admin.example.com:9092"},
+		{in: "${MISSING:-fallback}", exp: "fallback"},
+		{in: "${EMPTY:-fallback}", exp: "fallback"},
+		{in: "${USER:-fallback}", exp: "admin"},
+		{in: "${MISSING:-}", exp: ""},
+		{in: "${EMPTY}", exp: ""},
+		{in: "pa$$word", exp: "pa$word"},
+		{in: "$$USER", exp: "$USER"},
+		{in: "cost: 5$", exp: "cost: 5$"},
+		{in: "$1", exp: "$1"},
+		{in: "${MISSING}", expErr: "environment variable MISSING is not set"},
+		{in: "x$MISSING", expErr: "environment variable MISSING is not set"},
+		{in: "${USER", expErr: "unterminated ${ reference"},
+		{in: "${1BAD}", expErr: `invalid environment variable name "1BAD"`},
+		{in: "${}", expErr: `invalid environment variable name ""`},
+	} {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := expandEnvString(test.in, lookup)
+			if test.expErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, got)
+		})
+	}
+}
+
+func TestLoadExpandsEnv(t *testing.T) {
+	t.Setenv(EnvBrokers, "")
+	t.Setenv("RPK_KAFKA_BROKERS", "")
+	t.Setenv("TEST_RP_HOST", "kafka.example.com")
+	t.Setenv("TEST_RP_PASS", "hunter2")
+
+	const file = `rpk:
+    kafka_api:
+        brokers:
+            - ${TEST_RP_HOST}:9092
+        sasl:
+            user: ${TEST_RP_USER:-admin}
+            password: ${TEST_RP_PASS}
+            type: SCRAM-SHA-256
+`
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(file), 0o644))
+
+	p := &Params{ConfigPath: "/etc/redpanda/redpanda.yaml"}
+	cfg, err := p.Load(fs)
+	require.NoError(t, err)
+	k := cfg.Rpk.KafkaAPI
+	require.Equal(t, []string{"kafka.example.com:9092"}, k.Brokers)
+	require.Equal(t, "admin", k.SASL.User)
+	require.Equal(t, "hunter2", k.SASL.Password)
+
+	// The file config, which commands modify and write back, keeps the
+	// references.
+	require.Equal(t, "${TEST_RP_PASS}", cfg.File().Rpk.KafkaAPI.SASL.Password)
+
+	// Writing the loaded config writes the references, and unchanged
+	// fields keep their expanded values after.
+	require.NoError(t, cfg.Write(fs))
+	written, err := afero.ReadFile(fs, "/etc/redpanda/redpanda.yaml")
+	require.NoError(t, err)
+	require.Contains(t, string(written), "password: ${TEST_RP_PASS}")
+	require.Contains(t, string(written), "user: ${TEST_RP_USER:-admin}")
+	require.NotContains(t, string(written), "hunter2")
+	require.Equal(t, "hunter2", cfg.Rpk.KafkaAPI.SASL.Password)
+
+	// A changed field is written as is.
+	cfg.Rpk.KafkaAPI.SASL.Password = "changed"
+	require.NoError(t, cfg.Write(fs))
+	written, err = afero.ReadFile(fs, "/etc/redpanda/redpanda.yaml")
+	require.NoError(t, err)
+	require.Contains(t, string(written), "password: changed")
+
+	// An unset variable fails loading, naming the field but not the value.
+	require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(strings.ReplaceAll(file, "TEST_RP_PASS", "TEST_RP_UNSET")), 0o644))
+	_, err = p.Load(fs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rpk.kafka_api.sasl.password: environment variable TEST_RP_UNSET is not set")
+}
@@ -10,22 +10,31 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	vnet "github.com/redpanda-data/redpanda/src/go/rpk/pkg/net"
 	rpkos "github.com/redpanda-data/redpanda/src/go/rpk/pkg/os"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"golang.org/x/term"
 	"gopkg.in/yaml.v3"
 )
 
@@ -77,27 +86,72 @@ const (
 	// FlagConfig is rpk config flag.
 	FlagConfig = "config"
 
+	// FlagIgnoreConfigConnection ignores the config file's rpk.kafka_api
+	// and rpk.admin_api sections, so that only connection flags and
+	// environment variables are used.
+	FlagIgnoreConfigConnection = "ignore-config-connection"
+
+	// FlagNoConfig opts out of reading any config file, so that rpk only
+	// uses flags, environment variables, and defaults.
+	FlagNoConfig = "no-config"
+
 	// FlagVerbose opts in to verbose logging. This is to be replaced with
 	// a log-level flag later, with `-v` meaning DEBUG for backcompat.
 	FlagVerbose = "verbose"
 
+	// FlagMetrics opts in to printing a summary of the Kafka requests
+	// that a command issued, to stderr, when the command exits.
+	FlagMetrics = "metrics"
+
+	// FlagProfile selects a named connection profile from rpk.profiles.
+	FlagProfile = "profile"
+
+	// FlagFormat selects the output format for commands that support
+	// structured output.
+	FlagFormat = "format"
+
+	// FlagColor controls whether tables from out.NewStyledTable are
+	// colored.
+	FlagColor = "color"
+
+	// FlagRetries and FlagRetryBackoff control how many times, and how
+	// quickly, the initial connection to a cluster is retried.
+	FlagRetries      = "retries"
+	FlagRetryBackoff = "retry-backoff"
+
+	// FlagRequestTimeout bounds how long commands that support it wait
+	// for each request to the cluster.
+	FlagRequestTimeout = "request-timeout"
+
 	// This entire block is filled with our current flags and environment
 	// variables. These will all eventually be hidden.
 
-	FlagBrokers        = "brokers"
-	FlagEnableTLS      = "tls-enabled"
-	FlagTLSCA          = "tls-truststore"
-	FlagTLSCert        = "tls-cert"
-	FlagTLSKey         = "tls-key"
-	FlagSASLMechanism  = "sasl-mechanism"
-	FlagSASLUser       = "user"
-	FlagSASLPass       = "password"
-	FlagAdminHosts1    = "hosts"
-	FlagAdminHosts2    = "api-urls"
-	FlagEnableAdminTLS = "admin-api-tls-enabled"
-	FlagAdminTLSCA     = "admin-api-tls-truststore"
-	FlagAdminTLSCert   = "admin-api-tls-cert"
-	FlagAdminTLSKey    = "admin-api-tls-key"
+	FlagBrokers                  = "brokers"
+	FlagBrokersFile              = "brokers-file"
+	FlagProxy                    = "proxy"
+	FlagNoShuffle                = "no-shuffle"
+	FlagFailIncompatibleVersions = "fail-incompatible-versions"
+	FlagEnableTLS                = "tls-enabled"
+	FlagTLSCA                    = "tls-truststore"
+	FlagTLSCert                  = "tls-cert"
+	FlagTLSKey                   = "tls-key"
+	FlagTLSServerName            = "tls-servername"
+	FlagTLSInsecure              = "tls-insecure-skip-verify"
+	FlagSASLMechanism            = "sasl-mechanism"
+	FlagSASLUser                 = "user"
+	FlagSASLPass                 = "password"
+	FlagSASLPassFile             = "password-file"
+	FlagSASLPassStdin            = "password-stdin"
+	FlagOAuthToken               = "oauth-token"
+	FlagOAuthTokenCmd            = "oauth-token-command"
+	FlagAdminHosts1              = "hosts"
+	FlagAdminHosts2              = "api-urls"
+	FlagEnableAdminTLS           = "admin-api-tls-enabled"
+	FlagAdminTLSCA               = "admin-api-tls-truststore"
+	FlagAdminTLSCert             = "admin-api-tls-cert"
+	FlagAdminTLSKey              = "admin-api-tls-key"
+	FlagAdminTLSServerName       = "admin-api-tls-servername"
+	FlagAdminTLSInsecure         = "admin-api-tls-insecure-skip-verify"
 
 	EnvBrokers       = "REDPANDA_BROKERS"
 	EnvTLSCA         = "REDPANDA_TLS_TRUSTSTORE"
@@ -110,6 +164,14 @@ const (
 	EnvAdminTLSCA    = "REDPANDA_ADMIN_TLS_TRUSTSTORE"
 	EnvAdminTLSCert  = "REDPANDA_ADMIN_TLS_CERT"
 	EnvAdminTLSKey   = "REDPANDA_ADMIN_TLS_KEY"
+
+	// EnvConfig is searched for a config file first if --config is not
+	// specified.
+	EnvConfig = "RPK_CONFIG"
+
+	// EnvProfile selects a named connection profile, and is overridden by
+	// the --profile flag.
+	EnvProfile = "RPK_PROFILE"
 )
 
 // This block contains what will eventually be used as keys in the global
@@ -122,18 +184,45 @@ const (
 	xKafkaCACert     = "kafka.tls.ca_cert_path"
 	xKafkaClientCert = "kafka.tls.client_cert_path"
 	xKafkaClientKey  = "kafka.tls.client_key_path"
+	xKafkaServerName = "kafka.tls.server_name"
+	xKafkaInsecure   = "kafka.tls.insecure_skip_verify"
 
 	xKafkaSASLMechanism = "kafka.sasl.mechanism"
 	xKafkaSASLUser      = "kafka.sasl.user"
 	xKafkaSASLPass      = "kafka.sasl.pass"
+	xKafkaSASLToken     = "kafka.sasl.token"
+	xKafkaSASLTokenCmd  = "kafka.sasl.token_command"
 
 	xAdminHosts      = "admin.hosts"
 	xAdminTLSEnabled = "admin.tls.enabled"
 	xAdminCACert     = "admin.tls.ca_cert_path"
 	xAdminClientCert = "admin.tls.client_cert_path"
 	xAdminClientKey  = "admin.tls.client_key_path"
+	xAdminServerName = "admin.tls.server_name"
+	xAdminInsecure   = "admin.tls.insecure_skip_verify"
 )
 
+// DefaultRetries and DefaultRetryBackoff are the defaults for --retries and
+// --retry-backoff. These are small so that rpk does not hang when run
+// interactively against a cluster that is down.
+const (
+	DefaultRetries      = 3
+	DefaultRetryBackoff = 250 * time.Millisecond
+)
+
+// DefaultRequestTimeout is the default for --request-timeout.
+const DefaultRequestTimeout = 10 * time.Second
+
+// NoBrokersError is returned when brokers are explicitly set to none, such as
+// with --brokers "", rather than left unset, which defaults to localhost. A
+// client created from a config without brokers returns it as well. This is a
+// usage error: rpk cannot guess where the cluster is.
+type NoBrokersError struct{}
+
+func (*NoBrokersError) Error() string {
+	return "no brokers found: pass --" + FlagBrokers + " or --" + FlagBrokersFile + ", set " + EnvBrokers + ", or configure rpk.kafka_api.brokers in the config file"
+}
+
 // DefaultPath is where redpanda's configuration is located by default.
 const DefaultPath = "/etc/redpanda/redpanda.yaml"
 
@@ -144,21 +233,143 @@ type Params struct {
 	// This is unused until step (2) in the refactoring process.
 	ConfigPath string
 
+	// NoConfig is the --no-config flag: no config file is searched for or
+	// read, and combining it with --config is an error.
+	NoConfig bool
+
+	// Profile is the --profile flag, selecting a named profile from
+	// rpk.profiles.
+	Profile string
+
 	// Verbose tracks the -v flag. This will be swapped with --log-level in
 	// the future.
 	Verbose bool
 
+	// Metrics is the --metrics flag: a summary of the requests that Kafka
+	// clients issued is printed when rpk exits.
+	Metrics bool
+
+	// Formatter is the output formatter from the --format flag, for
+	// commands that support structured output.
+	Formatter out.Formatter
+
+	// Color is the --color mode for styled tables: auto, always, or
+	// never.
+	Color string
+
+	// Retries is the number of times to retry the initial connection to
+	// the cluster on transient errors, and RetryBackoff is the first
+	// backoff between attempts. The backoff doubles on every retry.
+	Retries      int
+	RetryBackoff time.Duration
+
+	// RequestTimeout bounds each request to the cluster for commands that
+	// support --request-timeout. Zero means no timeout.
+	RequestTimeout time.Duration
+
+	// Proxy is the --proxy flag, a socks5:// or http:// proxy to connect
+	// to brokers through.
+	Proxy string
+
+	// FailIncompatibleVersions is the --fail-incompatible-versions flag of
+	// commands that support it: incompatible broker ACL API versions are an
+	// error rather than a warning.
+	FailIncompatibleVersions bool
+
+	// NoShuffle is the --no-shuffle flag: seed brokers are tried in the
+	// order they are specified rather than in a random order.
+	NoShuffle bool
+
+	// BrokersFile is the --brokers-file flag, a file listing one broker
+	// per line.
+	BrokersFile string
+
+	// brokersFlag tracks whether --brokers was specified, in which case
+	// the brokers are merged with those in BrokersFile.
+	brokersFlag bool
+
+	// PasswordFile and PasswordStdin are the --password-file and
+	// --password-stdin flags, which read the SASL password rather than
+	// taking it on the command line.
+	PasswordFile  string
+	PasswordStdin bool
+
+	// passwordFlag tracks whether --password was specified, which is
+	// exclusive with PasswordFile and PasswordStdin.
+	passwordFlag bool
+
+	// PromptPassword, if non-nil, is called by kafka.NewFranzClient for
+	// the password of a SCRAM user that has none from anywhere, rather
+	// than failing the SCRAM handshake later. ParamsFromCommand sets it
+	// to prompt on stdin if stdin is a terminal. An empty password means
+	// nothing was prompted for.
+	PromptPassword func(user string) (string, error)
+
+	// IgnoreConfigConnection is the --ignore-config-connection flag: the
+	// config file's rpk.kafka_api and rpk.admin_api sections, and broker
+	// defaults derived from the redpanda section, are ignored.
+	IgnoreConfigConnection bool
+
+	// saslFlag and tlsFlag track whether any Kafka SASL or TLS flag was
+	// specified. If both are, along with --brokers, the flags are a full
+	// connection override and the config file's rpk.kafka_api section is
+	// ignored; see connectionOverridden.
+	saslFlag bool
+	tlsFlag  bool
+
 	// FlagOverrides are any flag-specified config overrides.
 	//
 	// This is unused until step (2) in the refactoring process.
 	FlagOverrides []string
+
+	// ctx is the command's context, which is canceled when rpk is
+	// interrupted.
+	ctx context.Context
+
+	// flagErr is the first flag that ParamsFromCommand could not parse,
+	// which Load returns.
+	flagErr error
+}
+
+// Context returns the context of the command the params were created from,
+// which is canceled if rpk is interrupted with SIGINT or SIGTERM. Requests to
+// the cluster should be derived from this context (see kafka.RequestContext).
+//
+// The context is only canceled for commands that are out.Interruptible;
+// other commands exit on the first interrupt.
+func (p *Params) Context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// WithContext returns a copy of the params that uses ctx as its Context.
+func (p *Params) WithContext(ctx context.Context) *Params {
+	cp := *p
+	cp.ctx = ctx
+	return &cp
+}
+
+// setFlagErr records err, if non-nil, as the flag's parse error unless an
+// earlier flag already failed.
+func (p *Params) setFlagErr(f *pflag.Flag, err error) {
+	if err != nil && p.flagErr == nil {
+		p.flagErr = fmt.Errorf("invalid --%s %q: %v", f.Name, f.Value.String(), err)
+	}
 }
 
 // ParamsFromCommand is an intermediate function to be used while refactoring
 // rpk to have a top-down passed Params function. See the docs at the top of
 // this file for the refactoring process.
 func ParamsFromCommand(cmd *cobra.Command) *Params {
-	var p Params
+	p := Params{
+		Retries:        DefaultRetries,
+		RetryBackoff:   DefaultRetryBackoff,
+		RequestTimeout: DefaultRequestTimeout,
+		PromptPassword: terminalPasswordPrompt(),
+		ctx:            cmd.Context(),
+	}
 
 	for _, set := range []*pflag.FlagSet{
 		cmd.PersistentFlags(),
@@ -176,31 +387,126 @@ func ParamsFromCommand(cmd *cobra.Command) *Params {
 				p.ConfigPath = f.Value.String()
 				return
 
+			case FlagNoConfig:
+				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
+					p.NoConfig = b
+				}
+				return
+
+			case FlagMetrics:
+				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
+					p.Metrics = b
+				}
+				return
+
+			case FlagIgnoreConfigConnection:
+				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
+					p.IgnoreConfigConnection = b
+				}
+				return
+
 			case FlagVerbose:
 				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
 					p.Verbose = b
 				}
 				return
 
+			case FlagFormat:
+				p.Formatter.Kind = f.Value.String()
+				return
+
+			case FlagColor:
+				p.Color = f.Value.String()
+				return
+
+			case FlagProfile:
+				p.Profile = f.Value.String()
+				return
+
+			case FlagRetries:
+				n, err := strconv.Atoi(f.Value.String())
+				p.setFlagErr(f, err)
+				p.Retries = n
+				return
+
+			case FlagRetryBackoff:
+				d, err := time.ParseDuration(f.Value.String())
+				p.setFlagErr(f, err)
+				p.RetryBackoff = d
+				return
+
+			case FlagRequestTimeout:
+				d, err := time.ParseDuration(f.Value.String())
+				p.setFlagErr(f, err)
+				p.RequestTimeout = d
+				return
+
 			case FlagBrokers:
 				key = xKafkaBrokers
 				stripBrackets = true
+				p.brokersFlag = true
+			case FlagBrokersFile:
+				p.BrokersFile = f.Value.String()
+				return
+			case FlagProxy:
+				p.Proxy = f.Value.String()
+				return
+			case FlagNoShuffle:
+				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
+					p.NoShuffle = b
+				}
+				return
+			case FlagFailIncompatibleVersions:
+				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
+					p.FailIncompatibleVersions = b
+				}
+				return
 
 			case FlagEnableTLS:
 				key = xKafkaTLSEnabled
+				p.tlsFlag = true
 			case FlagTLSCA:
 				key = xKafkaCACert
+				p.tlsFlag = true
 			case FlagTLSCert:
 				key = xKafkaClientCert
+				p.tlsFlag = true
 			case FlagTLSKey:
 				key = xKafkaClientKey
+				p.tlsFlag = true
+			case FlagTLSServerName:
+				key = xKafkaServerName
+				p.tlsFlag = true
+			case FlagTLSInsecure:
+				key = xKafkaInsecure
+				p.tlsFlag = true
 
 			case FlagSASLMechanism:
 				key = xKafkaSASLMechanism
+				p.saslFlag = true
 			case FlagSASLUser:
 				key = xKafkaSASLUser
+				p.saslFlag = true
 			case FlagSASLPass:
 				key = xKafkaSASLPass
+				p.passwordFlag = true
+				p.saslFlag = true
+			case FlagSASLPassFile:
+				p.PasswordFile = f.Value.String()
+				p.saslFlag = true
+				return
+			case FlagSASLPassStdin:
+				if b, err := strconv.ParseBool(f.Value.String()); err == nil {
+					p.PasswordStdin = b
+				}
+				p.saslFlag = true
+				return
+			case FlagOAuthToken:
+				key = xKafkaSASLToken
+				p.saslFlag = true
+			case FlagOAuthTokenCmd:
+				key = xKafkaSASLTokenCmd
+				p.saslFlag = true
 
 			case FlagAdminHosts1, FlagAdminHosts2:
 				key = xAdminHosts
@@ -213,6 +519,10 @@ func ParamsFromCommand(cmd *cobra.Command) *Params {
 				key = xAdminClientCert
 			case FlagAdminTLSKey:
 				key = xAdminClientKey
+			case FlagAdminTLSServerName:
+				key = xAdminServerName
+			case FlagAdminTLSInsecure:
+				key = xAdminInsecure
 			}
 
 			val := f.Value.String()
@@ -238,10 +548,45 @@ func ParamsFromCommand(cmd *cobra.Command) *Params {
 //   - Back-compats any old format into any new format.
 //   - Processes env and flag overrides.
 //   - Sets unset default values.
+//
+// A --config file that does not exist is an error; see LoadAllowMissing. With
+// --no-config, no file is searched for and the defaults are used.
 func (p *Params) Load(fs afero.Fs) (*Config, error) {
+	return p.load(fs, false)
+}
+
+// LoadAllowMissing is Load, but a --config file that does not exist is not an
+// error. This is for commands that write the config, which create the file at
+// the requested path.
+//
+// --no-config is an error: the defaults would be written over whichever file
+// the search would have found.
+func (p *Params) LoadAllowMissing(fs afero.Fs) (*Config, error) {
+	if p.NoConfig {
+		return nil, fmt.Errorf("--%s cannot be used with commands that write the config file", FlagNoConfig)
+	}
+	return p.load(fs, true)
+}
+
+func (p *Params) load(fs afero.Fs, allowMissing bool) (*Config, error) {
+	if p.NoConfig && p.ConfigPath != "" {
+		return nil, fmt.Errorf("--%s and --%s cannot be used together", FlagConfig, FlagNoConfig)
+	}
+	if p.flagErr != nil {
+		return nil, p.flagErr
+	}
+	if p.Retries < 0 {
+		return nil, fmt.Errorf("invalid --%s %d: must not be negative", FlagRetries, p.Retries)
+	}
+	if p.RetryBackoff < 0 {
+		return nil, fmt.Errorf("invalid --%s %s: must not be negative", FlagRetryBackoff, p.RetryBackoff)
+	}
+	if p.RequestTimeout < 0 {
+		return nil, fmt.Errorf("invalid --%s %s: must not be negative", FlagRequestTimeout, p.RequestTimeout)
+	}
 	// If we have a config path loaded (through --config flag) the user
 	// expect to load or create the file from this directory.
-	if p.ConfigPath != "" {
+	if p.ConfigPath != "" && allowMissing {
 		if exist, _ := afero.Exists(fs, p.ConfigPath); !exist {
 			err := fs.MkdirAll(filepath.Dir(p.ConfigPath), 0o755)
 			if err != nil {
@@ -257,6 +602,9 @@ func (p *Params) Load(fs afero.Fs) (*Config, error) {
 		if !errors.Is(err, afero.ErrFileNotFound) {
 			return nil, err
 		}
+		if p.ConfigPath != "" && !allowMissing {
+			return nil, fmt.Errorf("unable to read --config file: %w", err)
+		}
 		// If there is no file, we set the file location to the passed
 		// --config value, otherwise we use the default.
 		if p.ConfigPath != "" {
@@ -264,21 +612,45 @@ func (p *Params) Load(fs afero.Fs) (*Config, error) {
 		} else {
 			c.fileLocation = DefaultPath
 		}
+		log.Debugf("no config file found, using defaults")
 	}
 	c.backcompat()
+	if err := p.applyProfile(c); err != nil {
+		return nil, err
+	}
+	if err := p.ignoreConfigConnection(c); err != nil {
+		return nil, err
+	}
 	if err := p.processOverrides(c); err != nil {
 		return nil, err
 	}
+	if err := p.readBrokersFile(fs, c); err != nil {
+		return nil, err
+	}
+	if err := p.readPassword(fs, c); err != nil {
+		return nil, err
+	}
+	if err := c.parseBrokers(); err != nil {
+		return nil, err
+	}
 	c.addUnsetDefaults()
 	return c, nil
 }
 
-// Write writes loaded configuration parameters to redpanda.yaml.
+// Write writes loaded configuration parameters to redpanda.yaml. Fields that
+// referenced environment variables in the file are written with the
+// references, not the expanded values, unless they were changed since.
 func (c *Config) Write(fs afero.Fs) (rerr error) {
 	location := c.fileLocation
 	if location == "" {
 		location = DefaultPath
 	}
+	for _, e := range c.envExpansions {
+		if *e.field == e.expanded {
+			*e.field = e.raw
+			defer func(e envExpansion) { *e.field = e.expanded }(e)
+		}
+	}
 	b, err := yaml.Marshal(c)
 	if err != nil {
 		return fmt.Errorf("marshal error in loaded config, err: %s", err)
@@ -287,34 +659,84 @@ func (c *Config) Write(fs afero.Fs) (rerr error) {
 	return rpkos.ReplaceFile(fs, location, b, 0o644)
 }
 
+// LocateConfig returns the path of the config file to use. If --config was
+// specified, that is the only path. Otherwise, the first readable file of the
+// following is used:
+//
+//   - $RPK_CONFIG
+//   - $XDG_CONFIG_HOME/rpk/rpk.yaml
+//   - $HOME/.config/rpk/rpk.yaml
+//   - the OS user config dir's rpk/rpk.yaml, if different (e.g. on MacOS)
+//   - /etc/redpanda/redpanda.yaml
+//   - redpanda.yaml in the current directory
+//   - redpanda.yaml in the home directory
+//
+// With --no-config, nothing is searched and this returns afero.ErrFileNotFound.
 func (p *Params) LocateConfig(fs afero.Fs) (string, error) {
+	if p.NoConfig {
+		return "", fmt.Errorf("%w: not searching for a config file with --%s", afero.ErrFileNotFound, FlagNoConfig)
+	}
 	paths := []string{p.ConfigPath}
 	if p.ConfigPath == "" {
-		paths = nil
-		if configDir, _ := os.UserConfigDir(); configDir != "" {
-			paths = append(paths, filepath.Join(configDir, "rpk", "rpk.yaml"))
-		}
-		paths = append(paths, filepath.FromSlash(DefaultPath))
-		if cd, _ := os.Getwd(); cd != "" {
-			paths = append(paths, filepath.Join(cd, "redpanda.yaml"))
-		}
-		if home, _ := os.UserHomeDir(); home != "" {
-			paths = append(paths, filepath.Join(home, "redpanda.yaml"))
-		}
+		paths = configSearchPaths()
 	}
 
 	for _, path := range paths {
-		// Ignore error: we only care whether it exists, other
-		// stat() errors are not interesting.
-		exists, _ := afero.Exists(fs, path)
-		if exists {
-			return path, nil
+		// We only care whether the file exists and is readable; any
+		// other error is not interesting and we move on.
+		f, err := fs.Open(path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Debugf("skipping config file %s: %v", path, err)
+			}
+			continue
+		}
+		fi, err := f.Stat()
+		f.Close()
+		if err != nil || fi.IsDir() {
+			continue
 		}
+		return path, nil
 	}
 
 	return "", fmt.Errorf("%w: unable to find config in searched paths %v", afero.ErrFileNotFound, paths)
 }
 
+// configSearchPaths returns the paths LocateConfig searches, in order, if
+// --config is not specified.
+func configSearchPaths() []string {
+	var paths []string
+	add := func(path string) {
+		for _, p := range paths {
+			if p == path {
+				return
+			}
+		}
+		paths = append(paths, path)
+	}
+	if path := os.Getenv(EnvConfig); path != "" {
+		add(path)
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		add(filepath.Join(xdg, "rpk", "rpk.yaml"))
+	}
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		add(filepath.Join(home, ".config", "rpk", "rpk.yaml"))
+	}
+	if configDir, _ := os.UserConfigDir(); configDir != "" {
+		add(filepath.Join(configDir, "rpk", "rpk.yaml"))
+	}
+	add(filepath.FromSlash(DefaultPath))
+	if cd, _ := os.Getwd(); cd != "" {
+		add(filepath.Join(cd, "redpanda.yaml"))
+	}
+	if home != "" {
+		add(filepath.Join(home, "redpanda.yaml"))
+	}
+	return paths
+}
+
 func (p *Params) readConfig(fs afero.Fs, c *Config) error {
 	path, err := p.LocateConfig(fs)
 	if err != nil {
@@ -330,12 +752,16 @@ func (p *Params) readConfig(fs afero.Fs, c *Config) error {
 		return fmt.Errorf("unable to yaml decode %s: %v", path, err)
 	}
 	yaml.Unmarshal(file, &c.file) // cannot error since previous did not
+	if err := c.expandEnv(os.LookupEnv); err != nil {
+		return fmt.Errorf("unable to expand environment variables in %s: %v", path, err)
+	}
 	abs, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
 	c.fileLocation = abs
 	c.file.fileLocation = abs
+	log.Debugf("using config file %s", abs)
 	return nil
 }
 
@@ -354,6 +780,106 @@ func (c *Config) backcompat() {
 	}
 }
 
+// parseEnabled parses a bool and calls mk if the bool is true. This is used for
+// tls.enabled keys: --tls-enabled=false should not opt in to TLS.
+//
+// Any value used to opt in, so an empty value still does, and so does a
+// value that is not a bool, with a warning.
+func parseEnabled(key, in string, mk func()) error {
+	enabled, err := strconv.ParseBool(in)
+	switch {
+	case in == "":
+		enabled = true
+	case err != nil:
+		out.Warnf("warning: unable to parse %s=%q as a bool, enabling TLS; use true or false", key, in)
+		enabled = true
+	}
+	if enabled {
+		mk()
+	}
+	return nil
+}
+
+// parseInsecure parses an insecure_skip_verify key. Like parseEnabled, true
+// opts in to TLS; false only disables skipping verification if TLS is in use.
+func parseInsecure(in string, mk func(), tls func() *TLS) error {
+	insecure, err := strconv.ParseBool(in)
+	if err != nil {
+		return fmt.Errorf("unable to parse %q as a bool: %v", in, err)
+	}
+	if insecure {
+		mk()
+	}
+	if t := tls(); t != nil {
+		t.InsecureSkipVerify = insecure
+	}
+	return nil
+}
+
+// SASLMechanisms are the SASL mechanisms that rpk supports.
+var SASLMechanisms = []string{
+	"SCRAM-SHA-256",
+	"SCRAM-SHA-512",
+	SASLMechanismOAuth,
+}
+
+// SASLMechanismOAuth is the OAUTHBEARER mechanism, which authenticates with a
+// token rather than a user and password.
+const SASLMechanismOAuth = "OAUTHBEARER"
+
+// SASLMechanismAuto asks the broker which mechanisms it supports and uses the
+// first one that rpk supports for the given credentials: SCRAM-SHA-512, then
+// SCRAM-SHA-256 for a user and password, or OAUTHBEARER for a token. Without
+// credentials, rpk does not use SASL.
+const SASLMechanismAuto = "auto"
+
+// Validate checks that the SASL settings are usable by the mechanism:
+// OAUTHBEARER requires exactly one token source and does not accept a user or
+// password, while the SCRAM mechanisms do not accept a token.
+func (s *SASL) Validate() error {
+	if err := ValidateSASLMechanism(s.Mechanism); err != nil {
+		return err
+	}
+	if strings.EqualFold(s.Mechanism, SASLMechanismAuto) {
+		if (s.User != "" || s.Password != "") && (s.Token != "" || s.TokenCommand != "") {
+			return fmt.Errorf("--%s %s cannot be used with both --%s and --%s or --%s",
+				FlagSASLMechanism, SASLMechanismAuto, FlagSASLUser, FlagOAuthToken, FlagOAuthTokenCmd)
+		}
+		return nil
+	}
+	if strings.EqualFold(s.Mechanism, SASLMechanismOAuth) {
+		switch {
+		case s.User != "" || s.Password != "":
+			return fmt.Errorf("--%s and --%s cannot be used with %s, which authenticates with --%s or --%s",
+				FlagSASLUser, FlagSASLPass, SASLMechanismOAuth, FlagOAuthToken, FlagOAuthTokenCmd)
+		case s.Token == "" && s.TokenCommand == "":
+			return fmt.Errorf("%s requires a token: use --%s or --%s", SASLMechanismOAuth, FlagOAuthToken, FlagOAuthTokenCmd)
+		case s.Token != "" && s.TokenCommand != "":
+			return fmt.Errorf("only one of --%s or --%s can be used", FlagOAuthToken, FlagOAuthTokenCmd)
+		}
+		return nil
+	}
+	if s.Token != "" || s.TokenCommand != "" {
+		return fmt.Errorf("--%s and --%s require --%s %s", FlagOAuthToken, FlagOAuthTokenCmd, FlagSASLMechanism, SASLMechanismOAuth)
+	}
+	return nil
+}
+
+// ValidateSASLMechanism returns an error if the mechanism is not one of
+// SASLMechanisms or SASLMechanismAuto. Mechanisms are case insensitive, and an
+// empty mechanism is valid (rpk defaults to SCRAM-SHA-256).
+func ValidateSASLMechanism(mechanism string) error {
+	if mechanism == "" || strings.EqualFold(mechanism, SASLMechanismAuto) {
+		return nil
+	}
+	for _, m := range SASLMechanisms {
+		if strings.EqualFold(m, mechanism) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown SASL mechanism %q, supported: %s, %s", mechanism, strings.Join(SASLMechanisms, ", "), SASLMechanismAuto)
+}
+
 func splitCommaIntoStrings(in string, dst *[]string) error {
 	*dst = nil
 	split := strings.Split(in, ",")
@@ -367,6 +893,239 @@ func splitCommaIntoStrings(in string, dst *[]string) error {
 	return nil
 }
 
+// readBrokersFile reads the Kafka brokers from --brokers-file, if specified.
+// The file lists one broker per line; blank lines and lines starting with #
+// are ignored. The brokers in the file replace those from the env or config
+// file. If --brokers is also specified, the flag's brokers come first and the
+// two lists are merged without duplicates.
+func (p *Params) readBrokersFile(fs afero.Fs, c *Config) error {
+	if p.BrokersFile == "" {
+		return nil
+	}
+	raw, err := afero.ReadFile(fs, p.BrokersFile)
+	if err != nil {
+		return fmt.Errorf("unable to read --%s: %v", FlagBrokersFile, err)
+	}
+	var fileBrokers []string
+	for i, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := vnet.ParseBroker(line, DefaultKafkaPort); err != nil {
+			return fmt.Errorf("%s:%d: invalid kafka broker: %v", p.BrokersFile, i+1, err)
+		}
+		fileBrokers = append(fileBrokers, line)
+	}
+	if len(fileBrokers) == 0 {
+		return fmt.Errorf("--%s %s contains no brokers", FlagBrokersFile, p.BrokersFile)
+	}
+
+	k := &c.Rpk.KafkaAPI
+	var all []string
+	if p.brokersFlag {
+		all = k.Brokers
+	}
+	all = append(all, fileBrokers...)
+
+	// We dedupe on the normalized address so that "foo" and "foo:9092"
+	// are the same broker. Bad --brokers addresses are left for
+	// parseBrokers to report.
+	seen := make(map[string]bool)
+	k.Brokers = nil
+	for _, b := range all {
+		key := b
+		if hostport, err := vnet.ParseBroker(b, DefaultKafkaPort); err == nil {
+			key = hostport
+		}
+		if !seen[key] {
+			seen[key] = true
+			k.Brokers = append(k.Brokers, b)
+		}
+	}
+	log.Debugf("using brokers %v from --%s", k.Brokers, FlagBrokersFile)
+	return nil
+}
+
+// passwordStdin is where --password-stdin reads from, and stdinIsTerminal
+// and promptPassword are how terminalPasswordPrompt prompts; these are
+// swapped in tests.
+var (
+	passwordStdin   io.Reader = os.Stdin
+	stdinIsTerminal           = func() bool { return term.IsTerminal(int(os.Stdin.Fd())) }
+	promptPassword            = out.Password
+)
+
+// readPassword reads the SASL password from --password-file or
+// --password-stdin, if either is specified. At most one of these and
+// --password can be used. The password replaces any password from the env or
+// config file, and a single trailing newline is trimmed.
+func (p *Params) readPassword(fs afero.Fs, c *Config) error {
+	var n int
+	for _, set := range []bool{p.passwordFlag, p.PasswordFile != "", p.PasswordStdin} {
+		if set {
+			n++
+		}
+	}
+	switch {
+	case n == 0:
+		return nil
+	case n > 1:
+		return fmt.Errorf("only one of --%s, --%s, or --%s can be used", FlagSASLPass, FlagSASLPassFile, FlagSASLPassStdin)
+	case p.passwordFlag:
+		return nil
+	}
+
+	var (
+		raw  []byte
+		from string
+		err  error
+	)
+	if p.PasswordFile != "" {
+		from = "--" + FlagSASLPassFile + " " + p.PasswordFile
+		raw, err = afero.ReadFile(fs, p.PasswordFile)
+	} else {
+		from = "--" + FlagSASLPassStdin
+		raw, err = io.ReadAll(passwordStdin)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", from, err)
+	}
+	pass := string(raw)
+	if strings.HasSuffix(pass, "\n") {
+		pass = strings.TrimSuffix(strings.TrimSuffix(pass, "\n"), "\r")
+	}
+	if pass == "" {
+		return fmt.Errorf("%s: password is empty", from)
+	}
+	k := &c.Rpk.KafkaAPI
+	if k.SASL == nil {
+		k.SASL = new(SASL)
+	}
+	k.SASL.Password = pass
+	return nil
+}
+
+// terminalPasswordPrompt returns a Params.PromptPassword that prompts for
+// the password if stdin is a terminal. Each user is prompted for at most
+// once, so that a command with several clients does not prompt again.
+func terminalPasswordPrompt() func(string) (string, error) {
+	var (
+		mu       sync.Mutex
+		prompted = make(map[string]string)
+	)
+	return func(user string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if pass, ok := prompted[user]; ok {
+			return pass, nil
+		}
+		if !stdinIsTerminal() {
+			return "", nil
+		}
+		pass, err := promptPassword("SASL password for user %q:", user)
+		if err != nil {
+			return "", fmt.Errorf("unable to read SASL password: %v", err)
+		}
+		prompted[user] = pass
+		return pass, nil
+	}
+}
+
+// parseBrokers validates and normalizes the Kafka brokers, wherever they came
+// from, into host:port form. A bad address fails here, naming the address,
+// rather than failing later when the client tries to dial it.
+func (c *Config) parseBrokers() error {
+	k := &c.Rpk.KafkaAPI
+	if len(k.Brokers) == 0 {
+		return nil
+	}
+	parsed := make([]string, 0, len(k.Brokers))
+	for _, b := range k.Brokers {
+		hostport, err := vnet.ParseBroker(b, DefaultKafkaPort)
+		if err != nil {
+			return fmt.Errorf("invalid kafka broker: %v", err)
+		}
+		parsed = append(parsed, hostport)
+	}
+	k.Brokers = parsed
+	return nil
+}
+
+// connectionOverridden returns whether --brokers and at least one Kafka SASL
+// flag and one Kafka TLS flag were all specified. Such flags fully describe
+// the cluster to connect to, so the config file's rpk.kafka_api section must
+// not fill in the gaps: merging, say, a config file's TLS client certificate
+// into flags meant for another cluster is surprising.
+func (p *Params) connectionOverridden() bool {
+	return p.brokersFlag && p.saslFlag && p.tlsFlag
+}
+
+// ignoreConfigConnection clears the connection settings read from the config
+// file if --ignore-config-connection was specified or if flags fully override
+// the Kafka connection. This runs before env and flag overrides are applied.
+func (p *Params) ignoreConfigConnection(c *Config) error {
+	switch {
+	case p.IgnoreConfigConnection:
+		if p.Profile != "" {
+			return fmt.Errorf("--%s and --%s cannot be used together", FlagIgnoreConfigConnection, FlagProfile)
+		}
+		log.Debugf("--%s: ignoring the rpk.kafka_api and rpk.admin_api sections of the config file", FlagIgnoreConfigConnection)
+		c.Rpk.KafkaAPI = RpkKafkaAPI{}
+		c.Rpk.AdminAPI = RpkAdminAPI{}
+		c.ignoreRedpandaListeners = true
+	case p.connectionOverridden():
+		log.Debugf("--%s, SASL, and TLS flags were specified: ignoring the rpk.kafka_api section of the config file", FlagBrokers)
+		c.Rpk.KafkaAPI = RpkKafkaAPI{}
+	}
+	return nil
+}
+
+// applyProfile applies the profile selected by --profile or RPK_PROFILE, if
+// any. Settings the profile defines replace those in the rpk section; env and
+// flag overrides are applied afterwards and still take precedence.
+func (p *Params) applyProfile(c *Config) error {
+	name, from := p.Profile, "--"+FlagProfile
+	if name == "" {
+		name, from = os.Getenv(EnvProfile), EnvProfile
+	}
+	if name == "" {
+		return nil
+	}
+	profile, exists := c.Rpk.Profiles[name]
+	if !exists {
+		names := make([]string, 0, len(c.Rpk.Profiles))
+		for n := range c.Rpk.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("%s: profile %q does not exist, no profiles are defined in rpk.profiles of %s", from, name, c.fileLocation)
+		}
+		return fmt.Errorf("%s: profile %q does not exist in rpk.profiles of %s, available profiles: %s", from, name, c.fileLocation, strings.Join(names, ", "))
+	}
+	log.Debugf("using profile %q from %s", name, from)
+
+	k, pk := &c.Rpk.KafkaAPI, &profile.KafkaAPI
+	if len(pk.Brokers) > 0 {
+		k.Brokers = pk.Brokers
+	}
+	if pk.TLS != nil {
+		k.TLS = pk.TLS
+	}
+	if pk.SASL != nil {
+		k.SASL = pk.SASL
+	}
+	a, pa := &c.Rpk.AdminAPI, &profile.AdminAPI
+	if len(pa.Addresses) > 0 {
+		a.Addresses = pa.Addresses
+	}
+	if pa.TLS != nil {
+		a.TLS = pa.TLS
+	}
+	return nil
+}
+
 // Process overrides processes env and flag overrides into a config file (so
 // that we result in our priority order: flag, env, file).
 func (p *Params) processOverrides(c *Config) error {
@@ -400,22 +1159,36 @@ func (p *Params) processOverrides(c *Config) error {
 	fns := map[string]func(string) error{
 		xKafkaBrokers: func(v string) error { return splitCommaIntoStrings(v, &k.Brokers) },
 
-		xKafkaTLSEnabled: func(string) error { mkKafkaTLS(); return nil },
+		xKafkaTLSEnabled: func(v string) error { return parseEnabled(xKafkaTLSEnabled, v, mkKafkaTLS) },
 		xKafkaCACert:     func(v string) error { mkKafkaTLS(); k.TLS.TruststoreFile = v; return nil },
 		xKafkaClientCert: func(v string) error { mkKafkaTLS(); k.TLS.CertFile = v; return nil },
 		xKafkaClientKey:  func(v string) error { mkKafkaTLS(); k.TLS.KeyFile = v; return nil },
+		xKafkaServerName: func(v string) error { mkKafkaTLS(); k.TLS.ServerName = v; return nil },
+		xKafkaInsecure:   func(v string) error { return parseInsecure(v, mkKafkaTLS, func() *TLS { return k.TLS }) },
 
-		xKafkaSASLMechanism: func(v string) error { mkSASL(); k.SASL.Mechanism = v; return nil },
+		xKafkaSASLMechanism: func(v string) error { mkSASL(); k.SASL.Mechanism = v; return ValidateSASLMechanism(v) },
 		xKafkaSASLUser:      func(v string) error { mkSASL(); k.SASL.User = v; return nil },
 		xKafkaSASLPass:      func(v string) error { mkSASL(); k.SASL.Password = v; return nil },
+		xKafkaSASLToken:     func(v string) error { mkSASL(); k.SASL.Token = v; return nil },
+		xKafkaSASLTokenCmd:  func(v string) error { mkSASL(); k.SASL.TokenCommand = v; return nil },
 
 		xAdminHosts:      func(v string) error { return splitCommaIntoStrings(v, &a.Addresses) },
-		xAdminTLSEnabled: func(string) error { mkAdminTLS(); return nil },
+		xAdminTLSEnabled: func(v string) error { return parseEnabled(xAdminTLSEnabled, v, mkAdminTLS) },
 		xAdminCACert:     func(v string) error { mkAdminTLS(); a.TLS.TruststoreFile = v; return nil },
 		xAdminClientCert: func(v string) error { mkAdminTLS(); a.TLS.CertFile = v; return nil },
 		xAdminClientKey:  func(v string) error { mkAdminTLS(); a.TLS.KeyFile = v; return nil },
+		xAdminServerName: func(v string) error { mkAdminTLS(); a.TLS.ServerName = v; return nil },
+		xAdminInsecure:   func(v string) error { return parseInsecure(v, mkAdminTLS, func() *TLS { return a.TLS }) },
 	}
 
+	// We track where brokers come from so that -v makes it obvious which
+	// of the flag, env, or config file was used.
+	brokersFrom := "config file"
+	if len(k.Brokers) == 0 {
+		brokersFrom = ""
+	}
+	envNames := make(map[string]string)
+
 	// The parse function accepts the given overrides (key=value pairs) and
 	// processes each. This is run first for env vars then for flags.
 	parse := func(isEnv bool, kvs []string) error {
@@ -434,15 +1207,39 @@ func (p *Params) processOverrides(c *Config) error {
 			if !exists {
 				return fmt.Errorf("%s config: unknown key %q", from, k)
 			}
+			// Brokers explicitly set to nothing, as with
+			// --brokers "", must not silently fall back to the
+			// localhost default.
+			if !isEnv && strings.ToLower(k) == xKafkaBrokers && strings.TrimSpace(v) == "" {
+				return &NoBrokersError{}
+			}
 			if err := fn(v); err != nil {
 				return fmt.Errorf("%s config key %q: %s", from, k, err)
 			}
+			if strings.ToLower(k) == xKafkaBrokers {
+				brokersFrom = from
+				if isEnv {
+					brokersFrom = "env var " + envNames[xKafkaBrokers]
+				}
+			}
 		}
 		return nil
 	}
 
 	var envOverrides []string
 
+	// addEnv adds an env override, tracking the name of the env var that
+	// last set each key. Empty list values (brokers or admin hosts) are
+	// treated as unset, which allows CI to always export the variable.
+	addEnv := func(name, targetKey, v string) {
+		if (targetKey == xKafkaBrokers || targetKey == xAdminHosts) && strings.TrimSpace(v) == "" {
+			log.Debugf("ignoring empty env var %s", name)
+			return
+		}
+		envNames[targetKey] = name
+		envOverrides = append(envOverrides, targetKey+"="+v)
+	}
+
 	// Similar to our flag mapping in ParamsFromCommand, we want to
 	// continue supporting older environment variables. This section maps
 	// old env vars to what key we should use in this new format.
@@ -463,7 +1260,7 @@ func (p *Params) processOverrides(c *Config) error {
 		{EnvAdminTLSKey, xAdminClientKey},
 	} {
 		if v, exists := os.LookupEnv(envMapping.old); exists {
-			envOverrides = append(envOverrides, envMapping.targetKey+"="+v)
+			addEnv(envMapping.old, envMapping.targetKey, v)
 		}
 	}
 
@@ -477,7 +1274,7 @@ func (p *Params) processOverrides(c *Config) error {
 		k = strings.ReplaceAll(k, ".", "_")
 		k = strings.ToUpper(k)
 		if v, exists := os.LookupEnv("RPK_" + k); exists {
-			envOverrides = append(envOverrides, targetKey+"="+v)
+			addEnv("RPK_"+k, targetKey, v)
 		}
 	}
 
@@ -486,21 +1283,38 @@ func (p *Params) processOverrides(c *Config) error {
 	if err := parse(true, envOverrides); err != nil {
 		return err
 	}
-	return parse(false, p.FlagOverrides)
+	if err := parse(false, p.FlagOverrides); err != nil {
+		return err
+	}
+	if brokersFrom != "" {
+		log.Debugf("using brokers %v from %s", k.Brokers, brokersFrom)
+	}
+	return nil
 }
 
 // As a final step in initializing a config, we add a few defaults to some
 // specific unset values.
 func (c *Config) addUnsetDefaults() {
+	if len(c.Rpk.KafkaAPI.Brokers) == 0 {
+		c.brokersDefaulted = true
+		defer func() {
+			log.Debugf("no brokers specified, defaulting to %v", c.Rpk.KafkaAPI.Brokers)
+		}()
+	}
+	kafkaAPI, kafkaAPITLS := namedAuthnToNamed(c.Redpanda.KafkaAPI), c.Redpanda.KafkaAPITLS
+	adminAPI, adminAPITLS := c.Redpanda.AdminAPI, c.Redpanda.AdminAPITLS
+	if c.ignoreRedpandaListeners {
+		kafkaAPI, kafkaAPITLS, adminAPI, adminAPITLS = nil, nil, nil, nil
+	}
 	defaultFromRedpanda(
-		namedAuthnToNamed(c.Redpanda.KafkaAPI),
-		c.Redpanda.KafkaAPITLS,
+		kafkaAPI,
+		kafkaAPITLS,
 		&c.Rpk.KafkaAPI.Brokers,
 		"127.0.0.1:9092",
 	)
 	defaultFromRedpanda(
-		c.Redpanda.AdminAPI,
-		c.Redpanda.AdminAPITLS,
+		adminAPI,
+		adminAPITLS,
 		&c.Rpk.AdminAPI.Addresses,
 		"127.0.0.1:9644",
 	)
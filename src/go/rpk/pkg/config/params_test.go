@@ -1,11 +1,19 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 )
 
@@ -244,6 +252,7 @@ func TestAddUnsetDefaults(t *testing.T) {
 			name:  "default kafka broker and default admin api",
 			inCfg: &Config{},
 			expCfg: &Config{
+				brokersDefaulted: true,
 				Rpk: RpkConfig{
 					KafkaAPI: RpkKafkaAPI{
 						Brokers: []string{"127.0.0.1:9092"},
@@ -308,6 +317,7 @@ func TestAddUnsetDefaults(t *testing.T) {
 				},
 			},
 			expCfg: &Config{
+				brokersDefaulted: true,
 				Redpanda: RedpandaNodeConfig{
 					KafkaAPI: []NamedAuthNSocketAddress{
 						{Address: "250.12.12.12", Port: 9095},
@@ -357,6 +367,7 @@ func TestAddUnsetDefaults(t *testing.T) {
 				},
 			},
 			expCfg: &Config{
+				brokersDefaulted: true,
 				Redpanda: RedpandaNodeConfig{
 					KafkaAPI: []NamedAuthNSocketAddress{
 						{Address: "10.1.0.1", Port: 5555, Name: "tls"},
@@ -439,6 +450,7 @@ func TestAddUnsetDefaults(t *testing.T) {
 				},
 			},
 			expCfg: &Config{
+				brokersDefaulted: true,
 				Redpanda: RedpandaNodeConfig{
 					KafkaAPI: []NamedAuthNSocketAddress{
 						{Address: "10.1.0.1", Port: 1111, Name: "mtls"},
@@ -511,6 +523,7 @@ func TestAddUnsetDefaults(t *testing.T) {
 				},
 			},
 			expCfg: &Config{
+				brokersDefaulted: true,
 				Redpanda: RedpandaNodeConfig{
 					KafkaAPI: []NamedAuthNSocketAddress{
 						{Address: "10.1.0.1", Port: 5555, Name: "tls"},
@@ -554,3 +567,844 @@ func TestAddUnsetDefaults(t *testing.T) {
 		})
 	}
 }
+
+func TestSASLMechanismOverride(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		mechanism string
+		expErr    bool
+	}{
+		{"scram sha256", "SCRAM-SHA-256", false},
+		{"scram sha512 lowercase", "scram-sha-512", false},
+		{"typo", "SCRAM-SHA-265", true},
+		{"unsupported", "PLAIN", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := &Params{FlagOverrides: []string{xKafkaSASLMechanism + "=" + test.mechanism}}
+			cfg, err := p.Load(afero.NewMemMapFs())
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+			if test.expErr {
+				require.Contains(t, err.Error(), "SCRAM-SHA-256, SCRAM-SHA-512")
+				return
+			}
+			require.Equal(t, test.mechanism, cfg.Rpk.KafkaAPI.SASL.Mechanism)
+		})
+	}
+}
+
+func TestBrokersEnv(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		env       string
+		rpkEnv    string
+		overrides []string
+		exp       []string
+	}{
+		{
+			name: "env is used",
+			env:  "10.0.0.1:9092, 10.0.0.2:9092",
+			exp:  []string{"10.0.0.1:9092", "10.0.0.2:9092"},
+		},
+		{
+			name:      "flag overrides env",
+			env:       "10.0.0.1:9092",
+			overrides: []string{xKafkaBrokers + "=10.0.0.3:9092"},
+			exp:       []string{"10.0.0.3:9092"},
+		},
+		{
+			name:   "new format env overrides old",
+			env:    "10.0.0.1:9092",
+			rpkEnv: "10.0.0.4:9092",
+			exp:    []string{"10.0.0.4:9092"},
+		},
+		{
+			name: "empty env is unset",
+			exp:  []string{"127.0.0.1:9092"},
+		},
+		{
+			name: "whitespace env is unset",
+			env:  "  ",
+			exp:  []string{"127.0.0.1:9092"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(EnvBrokers, test.env)
+			t.Setenv("RPK_KAFKA_BROKERS", test.rpkEnv)
+
+			p := &Params{FlagOverrides: test.overrides}
+			cfg, err := p.Load(afero.NewMemMapFs())
+			require.NoError(t, err)
+			require.Equal(t, test.exp, cfg.Rpk.KafkaAPI.Brokers)
+		})
+	}
+}
+
+func TestParseBrokers(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		brokers string
+		exp     []string
+		expErr  string
+	}{
+		{
+			name:    "ports default to 9092",
+			brokers: "redpanda, 10.0.0.1, [::1]",
+			exp:     []string{"redpanda:9092", "10.0.0.1:9092", "[::1]:9092"},
+		},
+		{
+			name:    "schemes are dropped",
+			brokers: "redpanda://foo.com:9093,PLAINTEXT://[fe80::1]:9094",
+			exp:     []string{"foo.com:9093", "[fe80::1]:9094"},
+		},
+		{
+			name:    "invalid broker is named",
+			brokers: "10.0.0.1:9092,fe80::1:9092",
+			expErr:  `"fe80::1:9092"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := &Params{FlagOverrides: []string{xKafkaBrokers + "=" + test.brokers}}
+			cfg, err := p.Load(afero.NewMemMapFs())
+			if test.expErr != "" {
+				require.ErrorContains(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, cfg.Rpk.KafkaAPI.Brokers)
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	const cfg = `rpk:
+  kafka_api:
+    brokers: [127.0.0.1:9092]
+  admin_api:
+    addresses: [127.0.0.1:9644]
+  profiles:
+    prod:
+      kafka_api:
+        brokers: [prod-0:9092, prod-1:9092]
+        sasl:
+          user: admin
+          password: secret
+          type: SCRAM-SHA-512
+      admin_api:
+        addresses: [prod-0:9644]
+    staging:
+      kafka_api:
+        brokers: [staging-0:9092]
+`
+	for _, test := range []struct {
+		name       string
+		profile    string
+		envProfile string
+		overrides  []string
+		expBrokers []string
+		expAdmin   []string
+		expSASL    *SASL
+		expErr     bool
+	}{
+		{
+			name:       "no profile",
+			expBrokers: []string{"127.0.0.1:9092"},
+			expAdmin:   []string{"127.0.0.1:9644"},
+		},
+		{
+			name:       "flag profile",
+			profile:    "prod",
+			expBrokers: []string{"prod-0:9092", "prod-1:9092"},
+			expAdmin:   []string{"prod-0:9644"},
+			expSASL:    &SASL{User: "admin", Password: "secret", Mechanism: "SCRAM-SHA-512"},
+		},
+		{
+			name:       "env profile, unset profile fields are kept",
+			envProfile: "staging",
+			expBrokers: []string{"staging-0:9092"},
+			expAdmin:   []string{"127.0.0.1:9644"},
+		},
+		{
+			name:       "flag profile overrides env profile",
+			profile:    "staging",
+			envProfile: "prod",
+			expBrokers: []string{"staging-0:9092"},
+			expAdmin:   []string{"127.0.0.1:9644"},
+		},
+		{
+			name:       "flags override profile",
+			profile:    "prod",
+			overrides:  []string{xKafkaBrokers + "=other:9092", xKafkaSASLUser + "=bob"},
+			expBrokers: []string{"other:9092"},
+			expAdmin:   []string{"prod-0:9644"},
+			expSASL:    &SASL{User: "bob", Password: "secret", Mechanism: "SCRAM-SHA-512"},
+		},
+		{
+			name:    "unknown profile",
+			profile: "dev",
+			expErr:  true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(EnvProfile, test.envProfile)
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/etc/redpanda/redpanda.yaml", []byte(cfg), 0o644))
+
+			p := &Params{Profile: test.profile, FlagOverrides: test.overrides}
+			c, err := p.Load(fs)
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+			if test.expErr {
+				require.Contains(t, err.Error(), "available profiles: prod, staging")
+				return
+			}
+			require.Equal(t, test.expBrokers, c.Rpk.KafkaAPI.Brokers)
+			require.Equal(t, test.expAdmin, c.Rpk.AdminAPI.Addresses)
+			require.Equal(t, test.expSASL, c.Rpk.KafkaAPI.SASL)
+		})
+	}
+}
+
+func TestIgnoreConfigConnection(t *testing.T) {
+	const cfg = `redpanda:
+  kafka_api:
+    - address: 10.0.0.1
+      port: 9092
+rpk:
+  kafka_api:
+    brokers: [primary:9092]
+    sasl:
+      user: primary-user
+      password: primary-secret
+      type: SCRAM-SHA-256
+    tls:
+      cert_file: /primary.crt
+      key_file: /primary.key
+  admin_api:
+    addresses: [primary:9644]
+`
+	for _, test := range []struct {
+		name       string
+		params     Params
+		expBrokers []string
+		expAdmin   []string
+		expSASL    *SASL
+		expTLS     *TLS
+		expErr     bool
+	}{
+		{
+			name: "flags are merged with the file by default",
+			params: Params{brokersFlag: true, saslFlag: true, FlagOverrides: []string{
+				xKafkaBrokers + "=secondary:9092",
+				xKafkaSASLUser + "=secondary-user",
+			}},
+			expBrokers: []string{"secondary:9092"},
+			expAdmin:   []string{"primary:9644"},
+			expSASL:    &SASL{User: "secondary-user", Password: "primary-secret", Mechanism: "SCRAM-SHA-256"},
+			expTLS:     &TLS{CertFile: "/primary.crt", KeyFile: "/primary.key"},
+		},
+		{
+			name: "brokers, SASL, and TLS flags are a full override",
+			params: Params{brokersFlag: true, saslFlag: true, tlsFlag: true, FlagOverrides: []string{
+				xKafkaBrokers + "=secondary:9092",
+				xKafkaSASLUser + "=secondary-user",
+				xKafkaCACert + "=/secondary-ca.crt",
+			}},
+			expBrokers: []string{"secondary:9092"},
+			expAdmin:   []string{"primary:9644"},
+			expSASL:    &SASL{User: "secondary-user"},
+			expTLS:     &TLS{TruststoreFile: "/secondary-ca.crt"},
+		},
+		{
+			name:       "ignore everything, including redpanda listeners",
+			params:     Params{IgnoreConfigConnection: true},
+			expBrokers: []string{"127.0.0.1:9092"},
+			expAdmin:   []string{"127.0.0.1:9644"},
+		},
+		{
+			name: "ignore with flags",
+			params: Params{IgnoreConfigConnection: true, brokersFlag: true, FlagOverrides: []string{
+				xKafkaBrokers + "=secondary:9092",
+				xAdminHosts + "=secondary:9644",
+			}},
+			expBrokers: []string{"secondary:9092"},
+			expAdmin:   []string{"secondary:9644"},
+		},
+		{
+			name:   "ignore with a profile",
+			params: Params{IgnoreConfigConnection: true, Profile: "prod"},
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(EnvBrokers, "")
+			t.Setenv("RPK_KAFKA_BROKERS", "")
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, DefaultPath, []byte(cfg), 0o644))
+
+			c, err := test.params.Load(fs)
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expBrokers, c.Rpk.KafkaAPI.Brokers)
+			require.Equal(t, test.expAdmin, c.Rpk.AdminAPI.Addresses)
+			require.Equal(t, test.expSASL, c.Rpk.KafkaAPI.SASL)
+			require.Equal(t, test.expTLS, c.Rpk.KafkaAPI.TLS)
+		})
+	}
+}
+
+func TestTLSOverrides(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		overrides []string
+		expTLS    *TLS
+		expErr    bool
+	}{
+		{
+			name:      "enabled opts in to tls",
+			overrides: []string{xKafkaTLSEnabled + "=true"},
+			expTLS:    &TLS{},
+		},
+		{
+			name:      "disabled does not opt in to tls",
+			overrides: []string{xKafkaTLSEnabled + "=false"},
+		},
+		{
+			name:      "empty enabled opts in to tls",
+			overrides: []string{xKafkaTLSEnabled + "="},
+			expTLS:    &TLS{},
+		},
+		{
+			name:      "non bool enabled opts in to tls",
+			overrides: []string{xKafkaTLSEnabled + "=yes please"},
+			expTLS:    &TLS{},
+		},
+		{
+			name:      "cert and key",
+			overrides: []string{xKafkaClientCert + "=cert.pem", xKafkaClientKey + "=key.pem"},
+			expTLS:    &TLS{CertFile: "cert.pem", KeyFile: "key.pem"},
+		},
+		{
+			name:      "server name opts in to tls",
+			overrides: []string{xKafkaServerName + "=broker.example.com"},
+			expTLS:    &TLS{ServerName: "broker.example.com"},
+		},
+		{
+			name:      "insecure opts in to tls",
+			overrides: []string{xKafkaInsecure + "=true"},
+			expTLS:    &TLS{InsecureSkipVerify: true},
+		},
+		{
+			name:      "secure does not opt in to tls",
+			overrides: []string{xKafkaInsecure + "=false"},
+		},
+		{
+			name:      "invalid insecure fails",
+			overrides: []string{xKafkaInsecure + "=maybe"},
+			expErr:    true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := &Params{FlagOverrides: test.overrides}
+			cfg, err := p.Load(afero.NewMemMapFs())
+			gotErr := err != nil
+			require.Equal(t, test.expErr, gotErr, "error mismatch, got: %v, exp? %v", err, test.expErr)
+			if test.expErr {
+				return
+			}
+			require.Equal(t, test.expTLS, cfg.Rpk.KafkaAPI.TLS)
+		})
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	tc, err := (*TLS)(nil).Config(fs)
+	require.NoError(t, err)
+	require.Nil(t, tc, "nil TLS should not create a tls config")
+
+	tc, err = new(TLS).Config(fs)
+	require.NoError(t, err)
+	require.NotNil(t, tc)
+	require.Nil(t, tc.RootCAs, "no truststore should use the system cert pool")
+
+	_, err = (&TLS{CertFile: "cert.pem"}).Config(fs)
+	require.Error(t, err, "cert without key should fail")
+	_, err = (&TLS{KeyFile: "key.pem"}).Config(fs)
+	require.Error(t, err, "key without cert should fail")
+}
+
+func TestTLSConfigServerName(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	tc, err := (&TLS{ServerName: "broker.example.com"}).Config(fs)
+	require.NoError(t, err)
+	require.Equal(t, "broker.example.com", tc.ServerName)
+	require.False(t, tc.InsecureSkipVerify)
+
+	tc, err = (&TLS{InsecureSkipVerify: true}).Config(fs)
+	require.NoError(t, err)
+	require.True(t, tc.InsecureSkipVerify)
+}
+
+func TestExplainTLSError(t *testing.T) {
+	cert := &x509.Certificate{
+		DNSNames:    []string{"broker-0.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+	}
+	err := fmt.Errorf("dial: %w", &tls.CertificateVerificationError{
+		Err: x509.HostnameError{Certificate: cert, Host: "10.0.0.2"},
+	})
+	got := ExplainTLSError(err, FlagTLSServerName)
+	require.ErrorIs(t, got, err)
+	require.Contains(t, got.Error(), `expected server name "10.0.0.2", but the certificate presents broker-0.example.com, 10.0.0.1; use --tls-servername`)
+
+	other := errors.New("connection refused")
+	require.Equal(t, other, ExplainTLSError(other, FlagTLSServerName))
+}
+
+func TestSASLValidate(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		sasl   SASL
+		expErr bool
+	}{
+		{"scram with user", SASL{User: "u", Password: "p", Mechanism: "SCRAM-SHA-256"}, false},
+		{"empty mechanism", SASL{User: "u", Password: "p"}, false},
+		{"unknown mechanism", SASL{Mechanism: "PLAIN"}, true},
+		{"oauth with token", SASL{Mechanism: "oauthbearer", Token: "t"}, false},
+		{"oauth with command", SASL{Mechanism: "OAUTHBEARER", TokenCommand: "get-token"}, false},
+		{"oauth without token", SASL{Mechanism: "OAUTHBEARER"}, true},
+		{"oauth with both token sources", SASL{Mechanism: "OAUTHBEARER", Token: "t", TokenCommand: "get-token"}, true},
+		{"oauth with user", SASL{Mechanism: "OAUTHBEARER", Token: "t", User: "u"}, true},
+		{"oauth with password", SASL{Mechanism: "OAUTHBEARER", Token: "t", Password: "p"}, true},
+		{"token without oauth", SASL{Mechanism: "SCRAM-SHA-512", Token: "t"}, true},
+		{"auto with user", SASL{Mechanism: "auto", User: "u", Password: "p"}, false},
+		{"auto with token", SASL{Mechanism: "AUTO", Token: "t"}, false},
+		{"auto without credentials", SASL{Mechanism: "auto"}, false},
+		{"auto with user and token", SASL{Mechanism: "auto", User: "u", Token: "t"}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.sasl.Validate()
+			require.Equal(t, test.expErr, err != nil, "got err %v", err)
+		})
+	}
+}
+
+func TestLocateConfig(t *testing.T) {
+	const (
+		env  = "/env/rpk.yaml"
+		xdg  = "/xdg/rpk/rpk.yaml"
+		home = "/home/user/.config/rpk/rpk.yaml"
+	)
+	for _, test := range []struct {
+		name     string
+		explicit string
+		files    []string
+		dirs     []string
+		exp      string
+		expErr   bool
+	}{
+		{
+			name:  "RPK_CONFIG is first",
+			files: []string{env, xdg, home, DefaultPath},
+			exp:   env,
+		},
+		{
+			name:  "XDG_CONFIG_HOME is before HOME",
+			files: []string{xdg, home, DefaultPath},
+			exp:   xdg,
+		},
+		{
+			name:  "HOME is before the default",
+			files: []string{home, DefaultPath},
+			exp:   home,
+		},
+		{
+			name:  "default path",
+			files: []string{DefaultPath},
+			exp:   DefaultPath,
+		},
+		{
+			name:  "directories are skipped",
+			dirs:  []string{env, xdg},
+			files: []string{DefaultPath},
+			exp:   DefaultPath,
+		},
+		{
+			name:     "explicit path wins",
+			explicit: "/explicit.yaml",
+			files:    []string{"/explicit.yaml", env, DefaultPath},
+			exp:      "/explicit.yaml",
+		},
+		{
+			name:     "explicit path is not searched past",
+			explicit: "/explicit.yaml",
+			files:    []string{env, DefaultPath},
+			expErr:   true,
+		},
+		{
+			name:   "nothing found",
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(EnvConfig, env)
+			t.Setenv("XDG_CONFIG_HOME", "/xdg")
+			t.Setenv("HOME", "/home/user")
+
+			fs := afero.NewMemMapFs()
+			for _, d := range test.dirs {
+				require.NoError(t, fs.MkdirAll(d, 0o755))
+			}
+			for _, f := range test.files {
+				require.NoError(t, afero.WriteFile(fs, f, []byte("rpk: {}\n"), 0o644))
+			}
+
+			p := &Params{ConfigPath: test.explicit}
+			path, err := p.LocateConfig(fs)
+			if test.expErr {
+				require.ErrorIs(t, err, afero.ErrFileNotFound)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, path)
+		})
+	}
+}
+
+func TestLoadMissingExplicitConfig(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	p := &Params{ConfigPath: "/missing/redpanda.yaml"}
+
+	_, err := p.Load(fs)
+	require.Error(t, err, "a missing --config file should be an error")
+
+	cfg, err := p.LoadAllowMissing(fs)
+	require.NoError(t, err)
+	require.Equal(t, "/missing/redpanda.yaml", cfg.FileLocation())
+}
+
+func TestBrokersDefaulted(t *testing.T) {
+	t.Setenv(EnvBrokers, "")
+	t.Setenv("RPK_KAFKA_BROKERS", "")
+
+	for _, test := range []struct {
+		name      string
+		overrides []string
+		exp       bool
+	}{
+		{"no brokers defaults", nil, true},
+		{"flag brokers are not defaulted", []string{xKafkaBrokers + "=10.0.0.1:9092"}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := &Params{FlagOverrides: test.overrides}
+			cfg, err := p.Load(afero.NewMemMapFs())
+			require.NoError(t, err)
+			require.Equal(t, test.exp, cfg.BrokersDefaulted())
+			require.NotEmpty(t, cfg.Rpk.KafkaAPI.Brokers, "defaulting must always leave brokers to connect to")
+		})
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		params Params
+		file   string
+		stdin  string
+		exp    string
+		expErr bool
+	}{
+		{
+			name:   "file",
+			params: Params{PasswordFile: "/pass"},
+			file:   "secret\n",
+			exp:    "secret",
+		},
+		{
+			name:   "file only trims one newline",
+			params: Params{PasswordFile: "/pass"},
+			file:   "secret\n\n",
+			exp:    "secret\n",
+		},
+		{
+			name:   "file with crlf",
+			params: Params{PasswordFile: "/pass"},
+			file:   "secret\r\n",
+			exp:    "secret",
+		},
+		{
+			name:   "stdin",
+			params: Params{PasswordStdin: true},
+			stdin:  "secret",
+			exp:    "secret",
+		},
+		{
+			name:   "flag password is left alone",
+			params: Params{passwordFlag: true, FlagOverrides: []string{xKafkaSASLPass + "=flag"}},
+			exp:    "flag",
+		},
+		{
+			name:   "missing file",
+			params: Params{PasswordFile: "/missing"},
+			expErr: true,
+		},
+		{
+			name:   "empty stdin",
+			params: Params{PasswordStdin: true},
+			stdin:  "\n",
+			expErr: true,
+		},
+		{
+			name:   "file and stdin",
+			params: Params{PasswordFile: "/pass", PasswordStdin: true},
+			file:   "secret",
+			expErr: true,
+		},
+		{
+			name:   "flag and file",
+			params: Params{passwordFlag: true, PasswordFile: "/pass", FlagOverrides: []string{xKafkaSASLPass + "=flag"}},
+			file:   "secret",
+			expErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if test.file != "" {
+				require.NoError(t, afero.WriteFile(fs, "/pass", []byte(test.file), 0o600))
+			}
+			defer func(r io.Reader) { passwordStdin = r }(passwordStdin)
+			passwordStdin = strings.NewReader(test.stdin)
+
+			cfg, err := test.params.Load(fs)
+			if test.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, cfg.Rpk.KafkaAPI.SASL.Password)
+		})
+	}
+}
+
+func TestPromptPassword(t *testing.T) {
+	defer func(isTerm func() bool, prompt func(string, ...interface{}) (string, error)) {
+		stdinIsTerminal, promptPassword = isTerm, prompt
+	}(stdinIsTerminal, promptPassword)
+
+	var prompts int
+	stdinIsTerminal = func() bool { return true }
+	promptPassword = func(string, ...interface{}) (string, error) {
+		prompts++
+		return "prompted", nil
+	}
+
+	// Loading never prompts, even on a terminal: only Kafka clients do.
+	p := Params{FlagOverrides: []string{xKafkaSASLUser + "=bob"}, PromptPassword: terminalPasswordPrompt()}
+	cfg, err := p.Load(afero.NewMemMapFs())
+	require.NoError(t, err)
+	require.Empty(t, cfg.Rpk.KafkaAPI.SASL.Password)
+	require.Zero(t, prompts)
+
+	// Each user is prompted for once.
+	for i := 0; i < 2; i++ {
+		pass, err := p.PromptPassword("bob")
+		require.NoError(t, err)
+		require.Equal(t, "prompted", pass)
+	}
+	require.Equal(t, 1, prompts)
+
+	stdinIsTerminal = func() bool { return false }
+	pass, err := terminalPasswordPrompt()("bob")
+	require.NoError(t, err)
+	require.Empty(t, pass)
+	require.Equal(t, 1, prompts)
+}
+
+func TestReadBrokersFile(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		params Params
+		env    string
+		file   string
+		exp    []string
+		expErr string
+	}{
+		{
+			name:   "file with comments and blanks",
+			params: Params{BrokersFile: "/brokers"},
+			file:   "# generated\n\n  foo:9093\nbar\n",
+			exp:    []string{"foo:9093", "bar:9092"},
+		},
+		{
+			name:   "file replaces env",
+			params: Params{BrokersFile: "/brokers"},
+			env:    "env:9092",
+			file:   "foo",
+			exp:    []string{"foo:9092"},
+		},
+		{
+			name:   "flag and file are merged without duplicates",
+			params: Params{BrokersFile: "/brokers", brokersFlag: true, FlagOverrides: []string{xKafkaBrokers + "=flag,foo"}},
+			file:   "foo:9092\nbar\nflag",
+			exp:    []string{"flag:9092", "foo:9092", "bar:9092"},
+		},
+		{
+			name:   "bad line reports the file and line",
+			params: Params{BrokersFile: "/brokers"},
+			file:   "foo\n# ok\nfoo:bar:baz\n",
+			expErr: "/brokers:3:",
+		},
+		{
+			name:   "no brokers",
+			params: Params{BrokersFile: "/brokers"},
+			file:   "# nothing\n",
+			expErr: "contains no brokers",
+		},
+		{
+			name:   "missing file",
+			params: Params{BrokersFile: "/missing"},
+			expErr: "unable to read --brokers-file",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if test.file != "" {
+				require.NoError(t, afero.WriteFile(fs, "/brokers", []byte(test.file), 0o644))
+			}
+			if test.env != "" {
+				t.Setenv(EnvBrokers, test.env)
+			}
+
+			cfg, err := test.params.Load(fs)
+			if test.expErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, cfg.Rpk.KafkaAPI.Brokers)
+		})
+	}
+}
+
+// openRecordingFs records every path that is opened or stat'd.
+type openRecordingFs struct {
+	afero.Fs
+	opened []string
+}
+
+func (fs *openRecordingFs) Open(name string) (afero.File, error) {
+	fs.opened = append(fs.opened, name)
+	return fs.Fs.Open(name)
+}
+
+func (fs *openRecordingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	fs.opened = append(fs.opened, name)
+	return fs.Fs.OpenFile(name, flag, perm)
+}
+
+func (fs *openRecordingFs) Stat(name string) (os.FileInfo, error) {
+	fs.opened = append(fs.opened, name)
+	return fs.Fs.Stat(name)
+}
+
+func TestNoBrokers(t *testing.T) {
+	t.Setenv(EnvBrokers, "")
+	t.Setenv("RPK_KAFKA_BROKERS", "")
+
+	p := &Params{FlagOverrides: []string{xKafkaBrokers + "="}}
+	_, err := p.Load(afero.NewMemMapFs())
+	var nb *NoBrokersError
+	require.ErrorAs(t, err, &nb, "explicitly empty brokers must not default to localhost")
+
+	cfg, err := (&Params{}).Load(afero.NewMemMapFs())
+	require.NoError(t, err, "unset brokers default to localhost")
+	require.True(t, cfg.BrokersDefaulted())
+}
+
+func TestInvalidRetries(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, err := (&Params{Retries: -1}).Load(fs)
+	require.EqualError(t, err, "invalid --retries -1: must not be negative")
+	_, err = (&Params{RetryBackoff: -time.Second}).Load(fs)
+	require.EqualError(t, err, "invalid --retry-backoff -1s: must not be negative")
+	_, err = (&Params{RequestTimeout: -time.Second}).Load(fs)
+	require.EqualError(t, err, "invalid --request-timeout -1s: must not be negative")
+}
+
+func TestInvalidTimeoutFlags(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		flag   string
+		value  string
+		expErr string
+	}{
+		{"negative request timeout", FlagRequestTimeout, "-5s", "invalid --request-timeout -5s: must not be negative"},
+		{"unparseable request timeout", FlagRequestTimeout, "soon", `invalid --request-timeout "soon": time: invalid duration "soon"`},
+		{"negative retry backoff", FlagRetryBackoff, "-1s", "invalid --retry-backoff -1s: must not be negative"},
+		{"unparseable retry backoff", FlagRetryBackoff, "later", `invalid --retry-backoff "later": time: invalid duration "later"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// A string flag lets us pass values that a duration flag
+			// would reject before ParamsFromCommand sees them.
+			cmd := &cobra.Command{}
+			cmd.Flags().String(test.flag, "", "")
+			require.NoError(t, cmd.Flags().Set(test.flag, test.value))
+
+			_, err := ParamsFromCommand(cmd).Load(afero.NewMemMapFs())
+			require.EqualError(t, err, test.expErr)
+		})
+	}
+}
+
+func TestNoConfig(t *testing.T) {
+	t.Setenv(EnvBrokers, "")
+	t.Setenv("RPK_KAFKA_BROKERS", "")
+	t.Setenv(EnvConfig, "/env/rpk.yaml")
+
+	fs := &openRecordingFs{Fs: afero.NewMemMapFs()}
+	for _, path := range append(configSearchPaths(), "/env/rpk.yaml") {
+		require.NoError(t, afero.WriteFile(fs.Fs, path, []byte("rpk:\n  kafka_api:\n    brokers: [file:9092]\n"), 0o644))
+	}
+
+	// Without --no-config, the stray file is used.
+	cfg, err := (&Params{}).Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"file:9092"}, cfg.Rpk.KafkaAPI.Brokers)
+
+	fs.opened = nil
+	p := &Params{NoConfig: true}
+	cfg, err = p.Load(fs)
+	require.NoError(t, err)
+	require.Empty(t, fs.opened, "no file should be read with --no-config")
+	require.Equal(t, []string{"127.0.0.1:9092"}, cfg.Rpk.KafkaAPI.Brokers)
+
+	_, err = p.LocateConfig(fs)
+	require.ErrorIs(t, err, afero.ErrFileNotFound)
+	require.Empty(t, fs.opened)
+
+	// Commands that write the config cannot use --no-config.
+	_, err = p.LoadAllowMissing(fs)
+	require.EqualError(t, err, "--no-config cannot be used with commands that write the config file")
+	require.Empty(t, fs.opened)
+
+	// Flags and env vars still apply.
+	t.Setenv(EnvBrokers, "env:9092")
+	cfg, err = p.Load(fs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"env:9092"}, cfg.Rpk.KafkaAPI.Brokers)
+	require.Empty(t, fs.opened)
+
+	p.ConfigPath = "/env/rpk.yaml"
+	_, err = p.Load(fs)
+	require.EqualError(t, err, "--config and --no-config cannot be used together")
+	_, err = p.LoadAllowMissing(fs)
+	require.Error(t, err)
+	require.Empty(t, fs.opened)
+}
@@ -11,7 +11,12 @@ package config
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
 	"path"
+	"strings"
 
 	"github.com/spf13/afero"
 	"github.com/twmb/tlscfg"
@@ -21,6 +26,19 @@ type Config struct {
 	file         *Config
 	fileLocation string
 
+	// brokersDefaulted is whether no brokers were specified and
+	// addUnsetDefaults chose them.
+	brokersDefaulted bool
+
+	// ignoreRedpandaListeners is set with --ignore-config-connection, in
+	// which case addUnsetDefaults does not default brokers and admin
+	// addresses from the redpanda section.
+	ignoreRedpandaListeners bool
+
+	// envExpansions are the fields that referenced environment variables
+	// in the file; see expandEnv.
+	envExpansions []envExpansion
+
 	NodeUUID             string             `yaml:"node_uuid,omitempty" json:"node_uuid"`
 	Organization         string             `yaml:"organization,omitempty" json:"organization"`
 	LicenseKey           string             `yaml:"license_key,omitempty" json:"license_key"`
@@ -48,6 +66,13 @@ func (c *Config) FileLocation() string {
 	return c.fileLocation
 }
 
+// BrokersDefaulted returns whether no brokers were specified by flag, env, or
+// config file, in which case the brokers are the defaults: the
+// redpanda.kafka_api listeners of the config file, or 127.0.0.1:9092.
+func (c *Config) BrokersDefaulted() bool {
+	return c.brokersDefaulted
+}
+
 // RedpandaNodeConfig is the source of truth for Redpanda node configuration.
 //
 // Cluster properties must NOT be enlisted in this struct. Adding a cluster
@@ -135,12 +160,43 @@ type TLS struct {
 	KeyFile        string `yaml:"key_file,omitempty" json:"key_file"`
 	CertFile       string `yaml:"cert_file,omitempty" json:"cert_file"`
 	TruststoreFile string `yaml:"truststore_file,omitempty" json:"truststore_file"`
+
+	// ServerName overrides the name that the server's certificate is
+	// verified against (and that is sent as SNI), which otherwise is the
+	// host being connected to. This allows brokers to be addressed by IP
+	// while their certificates contain only hostnames.
+	ServerName string `yaml:"server_name,omitempty" json:"server_name,omitempty"`
+
+	// InsecureSkipVerify disables verifying the server's certificate.
+	// This is dangerous and only meant for testing.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty"`
 }
 
+// Config returns a client *tls.Config, or nil if t is nil. If no truststore is
+// given, the system cert pool is used to verify the server. A client cert and
+// key must either both be specified or both be empty.
 func (t *TLS) Config(fs afero.Fs) (*tls.Config, error) {
 	if t == nil {
 		return nil, nil
 	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, fmt.Errorf("TLS client cert %q and key %q must be specified together", t.CertFile, t.KeyFile)
+	}
+	tc, err := t.baseConfig(fs)
+	if err != nil {
+		return nil, err
+	}
+	tc.ServerName = t.ServerName
+	if t.InsecureSkipVerify {
+		// This is a security warning, not chatter, so it is printed
+		// even with --quiet.
+		fmt.Fprintln(os.Stderr, "WARNING: TLS certificate verification is disabled; the connection is encrypted but the server is not authenticated. Only use this for testing.")
+		tc.InsecureSkipVerify = true
+	}
+	return tc, nil
+}
+
+func (t *TLS) baseConfig(fs afero.Fs) (*tls.Config, error) {
 	return tlscfg.New(
 		tlscfg.WithFS(
 			tlscfg.FuncFS(func(path string) ([]byte, error) {
@@ -158,6 +214,32 @@ func (t *TLS) Config(fs afero.Fs) (*tls.Config, error) {
 	)
 }
 
+// ExplainTLSError returns err with a clearer message if it is a certificate
+// verification failure because the certificate is not valid for the name it
+// was verified against: the message lists the expected name and the names
+// that the certificate presents. serverNameFlag is the flag that overrides the
+// expected name. Any other error is returned unchanged.
+func ExplainTLSError(err error, serverNameFlag string) error {
+	var he x509.HostnameError
+	if !errors.As(err, &he) || he.Certificate == nil {
+		return err
+	}
+	var presented []string
+	presented = append(presented, he.Certificate.DNSNames...)
+	for _, ip := range he.Certificate.IPAddresses {
+		presented = append(presented, ip.String())
+	}
+	names := "no subject alternative names"
+	if len(presented) > 0 {
+		names = strings.Join(presented, ", ")
+	}
+	if cn := he.Certificate.Subject.CommonName; cn != "" {
+		names += fmt.Sprintf(" (subject common name %q, which is not used for verification)", cn)
+	}
+	return fmt.Errorf("TLS certificate verification failed: expected server name %q, but the certificate presents %s; use --%s to verify against a name in the certificate: %w",
+		he.Host, names, serverNameFlag, err)
+}
+
 type ServerTLS struct {
 	Name              string                 `yaml:"name,omitempty" json:"name"`
 	KeyFile           string                 `yaml:"key_file,omitempty" json:"key_file"`
@@ -198,6 +280,16 @@ type RpkConfig struct {
 	WellKnownIo              string      `yaml:"well_known_io,omitempty" json:"well_known_io"`
 	Overprovisioned          bool        `yaml:"overprovisioned,omitempty" json:"overprovisioned"`
 	SMP                      *int        `yaml:"smp,omitempty" json:"smp,omitempty"`
+
+	Profiles map[string]RpkProfile `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+}
+
+// RpkProfile is a named set of connection settings for a cluster. When a
+// profile is selected with --profile or RPK_PROFILE, any settings it defines
+// replace those in rpk.kafka_api and rpk.admin_api.
+type RpkProfile struct {
+	KafkaAPI RpkKafkaAPI `yaml:"kafka_api,omitempty" json:"kafka_api"`
+	AdminAPI RpkAdminAPI `yaml:"admin_api,omitempty" json:"admin_api"`
 }
 
 type RpkKafkaAPI struct {
@@ -215,6 +307,11 @@ type SASL struct {
 	User      string `yaml:"user,omitempty" json:"user,omitempty"`
 	Password  string `yaml:"password,omitempty" json:"password,omitempty"`
 	Mechanism string `yaml:"type,omitempty" json:"type,omitempty"`
+
+	// Token and TokenCommand are the token sources for OAUTHBEARER:
+	// either a static token, or a command that prints a token to stdout.
+	Token        string `yaml:"token,omitempty" json:"token,omitempty"`
+	TokenCommand string `yaml:"token_command,omitempty" json:"token_command,omitempty"`
 }
 
 func (c *Config) PIDFile() string {
@@ -404,6 +404,8 @@ func (rpkc *RpkConfig) UnmarshalYAML(n *yaml.Node) error {
 		WellKnownIo              weakString      `yaml:"well_known_io"`
 		Overprovisioned          weakBool        `yaml:"overprovisioned"`
 		SMP                      *weakInt        `yaml:"smp"`
+
+		Profiles map[string]RpkProfile `yaml:"profiles"`
 	}
 	if err := n.Decode(&internal); err != nil {
 		return err
@@ -434,6 +436,7 @@ func (rpkc *RpkConfig) UnmarshalYAML(n *yaml.Node) error {
 	rpkc.BallastFileSize = string(internal.BallastFileSize)
 	rpkc.WellKnownIo = string(internal.WellKnownIo)
 	rpkc.Overprovisioned = bool(internal.Overprovisioned)
+	rpkc.Profiles = internal.Profiles
 	rpkc.SMP = (*int)(internal.SMP)
 	return nil
 }
@@ -652,6 +655,9 @@ func (s *SASL) UnmarshalYAML(n *yaml.Node) error {
 		User      weakString `yaml:"user"`
 		Password  weakString `yaml:"password"`
 		Mechanism weakString `yaml:"type"`
+
+		Token        weakString `yaml:"token"`
+		TokenCommand weakString `yaml:"token_command"`
 	}
 	if err := n.Decode(&internal); err != nil {
 		return err
@@ -659,6 +665,8 @@ func (s *SASL) UnmarshalYAML(n *yaml.Node) error {
 	s.User = string(internal.User)
 	s.Password = string(internal.Password)
 	s.Mechanism = string(internal.Mechanism)
+	s.Token = string(internal.Token)
+	s.TokenCommand = string(internal.TokenCommand)
 
 	return nil
 }
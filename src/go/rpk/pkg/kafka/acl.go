@@ -0,0 +1,597 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// This file contains the ACL operations behind 'rpk acl', independent of the
+// command line. Validating user input (principal formats, which operations
+// apply to which resources, and so on) is left to the caller.
+
+// CreateACLResult is the result of creating one ACL.
+type CreateACLResult struct {
+	// Creation is the ACL that was requested.
+	Creation kmsg.CreateACLsRequestCreation
+
+	// Err is the error the broker returned for this ACL, if any. This is
+	// a *kerr.Error, and Message contains the broker's error message, if
+	// it sent one.
+	Err     error
+	Message string
+}
+
+// CreateACLs creates every ACL in creations in a single CreateACLs request
+// and returns the result of each creation, in order. An error is returned
+// only if the request itself fails; ACLs that the broker rejects are reported
+// in their result. Creations that fail with NOT_CONTROLLER, during a
+// controller election, are retried a bounded number of times. An interrupted
+// or timed out request may have created some or all ACLs. If the broker does
+// not support the pattern type of any creation, nothing is created.
+func CreateACLs(ctx context.Context, cl *kgo.Client, creations []kmsg.CreateACLsRequestCreation) ([]CreateACLResult, error) {
+	if len(creations) == 0 {
+		return nil, nil
+	}
+	if err := checkACLPatterns(fetchVersions(ctx, cl), kmsg.CreateACLs, creationPatterns(creations)...); err != nil {
+		return nil, err
+	}
+	results := make([]CreateACLResult, len(creations))
+	pending := make([]int, len(creations)) // indices into creations
+	for i := range pending {
+		pending[i] = i
+	}
+	err := retryNotController(ctx, kadm.NewClient(cl), "ACL creations", func() (int, error) {
+		req := kmsg.NewPtrCreateACLsRequest()
+		for _, i := range pending {
+			req.Creations = append(req.Creations, creations[i])
+		}
+		resp, err := req.RequestWith(ctx, cl)
+		if err != nil {
+			return 0, err
+		}
+		created, err := createACLResults(req.Creations, resp)
+		if err != nil {
+			return 0, err
+		}
+		var retry []int
+		for j, r := range created {
+			results[pending[j]] = r
+			if isNotController(r.Err) {
+				retry = append(retry, pending[j])
+			}
+		}
+		pending = retry
+		return len(pending), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func createACLResults(creations []kmsg.CreateACLsRequestCreation, resp *kmsg.CreateACLsResponse) ([]CreateACLResult, error) {
+	if len(resp.Results) != len(creations) {
+		return nil, fmt.Errorf("received %d results to %d creations", len(resp.Results), len(creations))
+	}
+	results := make([]CreateACLResult, len(creations))
+	for i, r := range resp.Results {
+		results[i] = CreateACLResult{
+			Creation: creations[i],
+			Err:      kerr.ErrorForCode(r.ErrorCode),
+		}
+		if r.ErrorMessage != nil {
+			results[i].Message = *r.ErrorMessage
+		}
+	}
+	return results, nil
+}
+
+// ExistingACLs returns which of the creations already exist. Every distinct
+// resource is described once, and an ACL exists only if an existing ACL for
+// the resource has the exact same principal, host, operation, and
+// permission. If ctx is from MultiRequestContext, each describe is bounded by
+// the request timeout on its own.
+func ExistingACLs(ctx context.Context, cl *kgo.Client, creations []kmsg.CreateACLsRequestCreation) ([]bool, error) {
+	if err := checkACLPatterns(fetchVersions(ctx, cl), kmsg.DescribeACLs, creationPatterns(creations)...); err != nil {
+		return nil, err
+	}
+	type resource struct {
+		t       kmsg.ACLResourceType
+		name    string
+		pattern kmsg.ACLResourcePatternType
+	}
+	var (
+		described = make(map[resource]bool)
+		existing  = make(map[ACL]bool)
+	)
+	for _, c := range creations {
+		r := resource{c.ResourceType, c.ResourceName, c.ResourcePatternType}
+		if described[r] {
+			continue
+		}
+		described[r] = true
+
+		req := kmsg.NewPtrDescribeACLsRequest()
+		req.ResourceType = r.t
+		req.ResourceName = kmsg.StringPtr(r.name)
+		req.ResourcePatternType = r.pattern
+		req.Operation = kmsg.ACLOperationAny
+		req.PermissionType = kmsg.ACLPermissionTypeAny
+
+		reqCtx, cancel := perRequestContext(ctx)
+		resp, err := req.RequestWith(reqCtx, cl)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+			return nil, err
+		}
+		for _, a := range DescribedACLs(resp) {
+			existing[a] = true
+		}
+	}
+
+	exists := make([]bool, len(creations))
+	for i, c := range creations {
+		exists[i] = existing[CreationACL(c)]
+	}
+	return exists, nil
+}
+
+// ACL is one ACL binding. ACLs are comparable, so an ACL can key a map, such
+// as to find which ACLs already exist.
+type ACL struct {
+	Principal           string                      `json:"principal" yaml:"principal"`
+	Host                string                      `json:"host" yaml:"host"`
+	ResourceType        kmsg.ACLResourceType        `json:"resourceType" yaml:"resourceType"`
+	ResourceName        string                      `json:"resourceName" yaml:"resourceName"`
+	ResourcePatternType kmsg.ACLResourcePatternType `json:"patternType" yaml:"patternType"`
+	Operation           kmsg.ACLOperation           `json:"operation" yaml:"operation"`
+	Permission          kmsg.ACLPermissionType      `json:"permission" yaml:"permission"`
+}
+
+// CreationACL returns the ACL that a creation creates.
+func CreationACL(c kmsg.CreateACLsRequestCreation) ACL {
+	return ACL{
+		Principal:           c.Principal,
+		Host:                c.Host,
+		ResourceType:        c.ResourceType,
+		ResourceName:        c.ResourceName,
+		ResourcePatternType: c.ResourcePatternType,
+		Operation:           c.Operation,
+		Permission:          c.PermissionType,
+	}
+}
+
+// DescribedACLs flattens every ACL in a DescribeACLs response.
+func DescribedACLs(resp *kmsg.DescribeACLsResponse) []ACL {
+	var acls []ACL
+	for _, res := range resp.Resources {
+		for _, a := range res.ACLs {
+			acls = append(acls, ACL{
+				Principal:           a.Principal,
+				Host:                a.Host,
+				ResourceType:        res.ResourceType,
+				ResourceName:        res.ResourceName,
+				ResourcePatternType: res.ResourcePatternType,
+				Operation:           a.Operation,
+				Permission:          a.PermissionType,
+			})
+		}
+	}
+	return acls
+}
+
+// creationPatterns returns the pattern type of every creation.
+func creationPatterns(creations []kmsg.CreateACLsRequestCreation) []kmsg.ACLResourcePatternType {
+	patterns := make([]kmsg.ACLResourcePatternType, 0, len(creations))
+	for _, c := range creations {
+		patterns = append(patterns, c.ResourcePatternType)
+	}
+	return patterns
+}
+
+// fetchVersions returns a function that fetches the broker's API versions
+// for checkACLPatterns, which only calls it if a pattern type is gated. The
+// versions are cached per client; see clientAPIVersions.
+func fetchVersions(ctx context.Context, cl *kgo.Client) func() ([]APIVersion, error) {
+	return func() ([]APIVersion, error) {
+		ctx, cancel := perRequestContext(ctx)
+		defer cancel()
+		return clientAPIVersions(ctx, cl)
+	}
+}
+
+// ACLFilter selects ACLs to list or delete. Filters work like 'rpk acl list'
+// flags: every field that is empty matches everything, and filters multiply,
+// e.g. two topics and two operations match four (topic, operation) pairs.
+// Principals without a type are prefixed with "User:", and principals with
+// any type are used as is.
+type ACLFilter struct {
+	Topics           []string
+	Groups           []string
+	Cluster          bool
+	TransactionalIDs []string
+	DelegationTokens []string
+
+	// PatternType is how resource names are matched. The zero value
+	// matches any pattern type.
+	PatternType kmsg.ACLResourcePatternType
+
+	Operations []kmsg.ACLOperation
+
+	AllowPrincipals []string
+	AllowHosts      []string
+	DenyPrincipals  []string
+	DenyHosts       []string
+
+	// NameGlob, if non-empty, further filters ACLs by resource name on
+	// the client, after the broker returns the ACLs matching the other
+	// fields; see MatchGlob. This allows matching names by pattern
+	// regardless of the ACLs' pattern types, which broker filters cannot
+	// do.
+	NameGlob string
+}
+
+// Builder returns the kadm ACL builder for the filter.
+func (f ACLFilter) Builder() (*kadm.ACLBuilder, error) {
+	pattern := f.PatternType
+	if pattern == kmsg.ACLResourcePatternTypeUnknown {
+		pattern = kmsg.ACLResourcePatternTypeAny
+	}
+
+	// The builder opts in to all when using functions if the input slice
+	// is empty, but we can use the Maybe functions to avoid opting in to
+	// all by default.
+	b := kadm.NewACLs().
+		ResourcePatternType(pattern).
+		Operations(f.Operations...).
+		MaybeTopics(f.Topics...).
+		MaybeGroups(f.Groups...).
+		MaybeClusters(f.Cluster).
+		MaybeTransactionalIDs(f.TransactionalIDs...).
+		MaybeDelegationTokens(f.DelegationTokens...).
+		MaybeAllow(f.AllowPrincipals...).
+		MaybeAllowHosts(f.AllowHosts...).
+		MaybeDeny(f.DenyPrincipals...).
+		MaybeDenyHosts(f.DenyHosts...)
+
+	// Resources: if no resources are specified, we use all resources.
+	if !b.HasResource() {
+		b.AnyResource()
+	}
+	// User & host: when unspecified, we default to everything. This means
+	// that to specifically filter for allowed or denied, the filter must
+	// have only allow or only deny fields.
+	if !b.HasPrincipals() {
+		b.Allow()
+		b.Deny()
+	}
+	if !b.HasHosts() {
+		b.AllowHosts()
+		b.DenyHosts()
+	}
+
+	// Add the "User:" prefix to every principal without a type.
+	b.PrefixUserExcept(principalTypePrefixes(f.AllowPrincipals, f.DenyPrincipals)...)
+
+	return b, b.ValidateFilter()
+}
+
+// principalTypePrefixes returns the "Type:" prefix of every principal that
+// has a type, for kadm's PrefixUserExcept, so that only principals without a
+// type are prefixed with "User:".
+func principalTypePrefixes(principals ...[]string) []string {
+	var prefixes []string
+	for _, ps := range principals {
+		for _, p := range ps {
+			if typ, _, hasType := strings.Cut(p, ":"); hasType {
+				prefixes = append(prefixes, typ+":")
+			}
+		}
+	}
+	return prefixes
+}
+
+// SentACLFilter is one filter that ListACLs or DeleteACLs sent to the broker,
+// one of the filters that an ACLFilter expands to. Nil fields match anything.
+type SentACLFilter struct {
+	Principal  *string
+	Host       *string
+	Type       kmsg.ACLResourceType
+	Name       *string
+	Pattern    kmsg.ACLResourcePatternType
+	Operation  kmsg.ACLOperation
+	Permission kmsg.ACLPermissionType
+}
+
+// ListACLsResult is the result of one filter of ListACLs: either the ACLs
+// that the filter matched (possibly none) or the filter's error.
+type ListACLsResult struct {
+	SentACLFilter
+	Described []ACL
+	Err       error
+}
+
+// DeletedACL is one ACL that a delete filter matched. Err is non-nil if the
+// matched ACL failed to be deleted.
+type DeletedACL struct {
+	ACL
+	Err error
+}
+
+// DeleteACLsResult is the result of one filter of DeleteACLs: either the
+// ACLs that the filter matched (possibly none) or the filter's error.
+type DeleteACLsResult struct {
+	SentACLFilter
+	Deleted []DeletedACL
+	Err     error
+}
+
+// ListACLs returns the ACLs matching the filter. Every filter in the
+// resulting builder is described independently and can fail independently.
+// If the broker does not support the filter's pattern type, the filter is not
+// described.
+func ListACLs(ctx context.Context, cl *kgo.Client, f ACLFilter) ([]ListACLsResult, error) {
+	b, err := f.Builder()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkACLPatterns(fetchVersions(ctx, cl), kmsg.DescribeACLs, f.PatternType); err != nil {
+		return nil, err
+	}
+	return listACLs(ctx, kadm.NewClient(cl), b, f.NameGlob)
+}
+
+// ListACLsEach is ListACLs for output that is printed as it arrives: the
+// filter is split into one filter per resource and operation, which are
+// described one after another, and fn is called with the results of each as
+// soon as its response arrives. Unlike in ListACLs, a broad filter does not
+// hold every response in memory before anything is printed.
+//
+// Split filters can match the same ACL, such as a prefixed ACL that matches
+// two topics, so fn may see an ACL more than once. Each request is bounded by
+// the request timeout of a MultiRequestContext. This stops at the first
+// error from a request or from fn.
+func ListACLsEach(ctx context.Context, cl *kgo.Client, f ACLFilter, fn func([]ListACLsResult) error) error {
+	if _, err := f.Builder(); err != nil {
+		return err
+	}
+	if err := checkACLPatterns(fetchVersions(ctx, cl), kmsg.DescribeACLs, f.PatternType); err != nil {
+		return err
+	}
+	adm := kadm.NewClient(cl)
+	for _, part := range f.split() {
+		b, err := part.Builder()
+		if err != nil {
+			return err
+		}
+		rctx, cancel := perRequestContext(ctx)
+		results, err := listACLs(rctx, adm, b, f.NameGlob)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if err := fn(results); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listACLs(ctx context.Context, adm *kadm.Client, b *kadm.ACLBuilder, glob string) ([]ListACLsResult, error) {
+	described, err := adm.DescribeACLs(ctx, b)
+	results := make([]ListACLsResult, 0, len(described))
+	for _, r := range described {
+		res := ListACLsResult{
+			SentACLFilter: SentACLFilter{r.Principal, r.Host, r.Type, r.Name, r.Pattern, r.Operation, r.Permission},
+			Err:           r.Err,
+		}
+		for _, d := range r.Described {
+			if glob == "" || MatchGlob(glob, d.Name) {
+				res.Described = append(res.Described, ACL{d.Principal, d.Host, d.Type, d.Name, d.Pattern, d.Operation, d.Permission})
+			}
+		}
+		results = append(results, res)
+	}
+	return results, err
+}
+
+// split returns the filter as one filter per resource and operation, which
+// together match what the filter matches. A filter without resources or
+// operations matches any, and is not split by them.
+func (f ACLFilter) split() []ACLFilter {
+	base := f
+	base.Topics, base.Groups, base.Cluster, base.TransactionalIDs, base.DelegationTokens = nil, nil, false, nil, nil
+	base.Operations = nil
+
+	var resources []ACLFilter
+	for _, r := range []struct {
+		names []string
+		set   func(*ACLFilter, string)
+	}{
+		{f.Topics, func(f *ACLFilter, n string) { f.Topics = []string{n} }},
+		{f.Groups, func(f *ACLFilter, n string) { f.Groups = []string{n} }},
+		{f.TransactionalIDs, func(f *ACLFilter, n string) { f.TransactionalIDs = []string{n} }},
+		{f.DelegationTokens, func(f *ACLFilter, n string) { f.DelegationTokens = []string{n} }},
+	} {
+		for _, n := range r.names {
+			part := base
+			r.set(&part, n)
+			resources = append(resources, part)
+		}
+	}
+	if f.Cluster {
+		part := base
+		part.Cluster = true
+		resources = append(resources, part)
+	}
+	if len(resources) == 0 {
+		resources = []ACLFilter{base}
+	}
+	if len(f.Operations) == 0 {
+		return resources
+	}
+
+	parts := make([]ACLFilter, 0, len(resources)*len(f.Operations))
+	for _, r := range resources {
+		for _, op := range f.Operations {
+			part := r
+			part.Operations = []kmsg.ACLOperation{op}
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// DeleteACLs deletes the ACLs matching the filter. Every filter in the
+// resulting builder, and every matched ACL, can fail independently. Filters
+// that fail with NOT_CONTROLLER are retried as in CreateACLs. An
+// interrupted or timed out request may have deleted some or all ACLs.
+//
+// If the filter has a NameGlob, the matching ACLs are first listed and then
+// each is deleted with a filter matching exactly that ACL, so that nothing
+// outside of the glob is deleted. The results then have one filter per
+// listed ACL, and failing to list is reported as this function's error.
+//
+// As in ListACLs, nothing is deleted if the broker does not support the
+// filter's pattern type.
+func DeleteACLs(ctx context.Context, cl *kgo.Client, f ACLFilter) ([]DeleteACLsResult, error) {
+	if err := checkACLPatterns(fetchVersions(ctx, cl), kmsg.DeleteACLs, f.PatternType); err != nil {
+		return nil, err
+	}
+	if f.NameGlob != "" {
+		return deleteGlobACLs(ctx, cl, f)
+	}
+	b, err := f.Builder()
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := deleteACLs(ctx, kadm.NewClient(cl), b)
+	return deleteResults(deleted), err
+}
+
+func deleteGlobACLs(ctx context.Context, cl *kgo.Client, f ACLFilter) ([]DeleteACLsResult, error) {
+	listed, err := ListACLs(ctx, cl, f)
+	if err != nil {
+		return nil, err
+	}
+	var matched []ACL
+	for _, r := range listed {
+		if r.Err != nil {
+			return nil, fmt.Errorf("unable to list the ACLs to delete: %w", r.Err)
+		}
+		matched = append(matched, r.Described...)
+	}
+	var (
+		adm     = kadm.NewClient(cl)
+		results []DeleteACLsResult
+	)
+	for _, a := range matched {
+		b, err := ExactACLFilter(a).Builder()
+		if err != nil {
+			return results, err
+		}
+		deleted, err := deleteACLs(ctx, adm, b)
+		results = append(results, deleteResults(deleted)...)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// deleteResults converts kadm's delete results to ours.
+func deleteResults(deleted kadm.DeleteACLsResults) []DeleteACLsResult {
+	results := make([]DeleteACLsResult, 0, len(deleted))
+	for _, r := range deleted {
+		res := DeleteACLsResult{
+			SentACLFilter: SentACLFilter{r.Principal, r.Host, r.Type, r.Name, r.Pattern, r.Operation, r.Permission},
+			Err:           r.Err,
+		}
+		for _, d := range r.Deleted {
+			res.Deleted = append(res.Deleted, DeletedACL{
+				ACL{d.Principal, d.Host, d.Type, d.Name, d.Pattern, d.Operation, d.Permission},
+				d.Err,
+			})
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// ExactACLFilter returns a filter that matches exactly the ACL, and nothing
+// else.
+func ExactACLFilter(a ACL) ACLFilter {
+	f := ACLFilter{
+		PatternType: a.ResourcePatternType,
+		Operations:  []kmsg.ACLOperation{a.Operation},
+	}
+	switch a.ResourceType {
+	case kmsg.ACLResourceTypeTopic:
+		f.Topics = []string{a.ResourceName}
+	case kmsg.ACLResourceTypeGroup:
+		f.Groups = []string{a.ResourceName}
+	case kmsg.ACLResourceTypeCluster:
+		f.Cluster = true
+	case kmsg.ACLResourceTypeTransactionalId:
+		f.TransactionalIDs = []string{a.ResourceName}
+	case kmsg.ACLResourceTypeDelegationToken:
+		f.DelegationTokens = []string{a.ResourceName}
+	}
+	if a.Permission == kmsg.ACLPermissionTypeDeny {
+		f.DenyPrincipals, f.DenyHosts = []string{a.Principal}, []string{a.Host}
+	} else {
+		f.AllowPrincipals, f.AllowHosts = []string{a.Principal}, []string{a.Host}
+	}
+	return f
+}
+
+// MatchGlob returns whether name matches the shell-style glob: '*' matches
+// any sequence of characters, including none, '?' matches exactly one
+// character, and every other character matches itself. There is no escaping
+// and no character classes. The glob must match the entire name, and names
+// are compared as is: the wildcard resource name "*", which applies to every
+// resource, only matches globs that match the one-character string "*", such
+// as "*" or "?".
+func MatchGlob(glob, name string) bool {
+	g, n := []rune(glob), []rune(name)
+	var gi, ni int
+	star, starNi := -1, 0 // the last '*' seen, and where in name it began matching
+	for ni < len(n) {
+		switch {
+		case gi < len(g) && (g[gi] == '?' || g[gi] == n[ni]) && g[gi] != '*':
+			gi++
+			ni++
+		case gi < len(g) && g[gi] == '*':
+			star, starNi = gi, ni
+			gi++
+		case star >= 0:
+			// Backtrack: let the last '*' match one more character.
+			starNi++
+			gi, ni = star+1, starNi
+		default:
+			return false
+		}
+	}
+	for gi < len(g) && g[gi] == '*' {
+		gi++
+	}
+	return gi == len(g)
+}
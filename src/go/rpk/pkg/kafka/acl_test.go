@@ -0,0 +1,292 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka/kafkatest"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCreateACLResults(t *testing.T) {
+	creations := make([]kmsg.CreateACLsRequestCreation, 2)
+	creations[0].ResourceName = "ok"
+	creations[1].ResourceName = "bad"
+
+	resp := kmsg.NewPtrCreateACLsResponse()
+	resp.Results = make([]kmsg.CreateACLsResponseResult, 2)
+	resp.Results[1].ErrorCode = kerr.InvalidRequest.Code
+	resp.Results[1].ErrorMessage = kmsg.StringPtr("no can do")
+
+	results, err := createACLResults(creations, resp)
+	require.NoError(t, err)
+	require.Equal(t, []CreateACLResult{
+		{Creation: creations[0]},
+		{Creation: creations[1], Err: kerr.InvalidRequest, Message: "no can do"},
+	}, results)
+
+	resp.Results = resp.Results[:1]
+	_, err = createACLResults(creations, resp)
+	require.Error(t, err, "a result count mismatch must be an error")
+}
+
+func TestACLFilterBuilder(t *testing.T) {
+	_, err := ACLFilter{}.Builder()
+	require.NoError(t, err, "the empty filter matches everything")
+
+	_, err = ACLFilter{Topics: []string{"foo"}, PatternType: kmsg.ACLResourcePatternTypeLiteral}.Builder()
+	require.NoError(t, err)
+}
+
+func TestACLFilterPrincipalTypes(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		r := req.(*kmsg.DescribeACLsRequest)
+		sent = append(sent, *r.Principal)
+		return r.ResponseKind()
+	})
+	_, err := ListACLs(context.Background(), b.Client(), ACLFilter{
+		AllowPrincipals: []string{"Role:admins", "bob", "User:carol"},
+	})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(sent)
+	require.Equal(t, []string{"Role:admins", "User:bob", "User:carol"}, sent,
+		"only principals without a type should be prefixed with User:")
+}
+
+func TestListACLsMatch(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []*kmsg.DescribeACLsRequest
+	)
+	// The broker answers with every pattern that affects the topic, as
+	// Kafka does for MATCH: the literal, prefixed, and wildcard ACLs.
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		r := req.(*kmsg.DescribeACLsRequest)
+		sent = append(sent, r)
+		resp := r.ResponseKind().(*kmsg.DescribeACLsResponse)
+		for _, s := range []struct {
+			name    string
+			pattern kmsg.ACLResourcePatternType
+		}{
+			{"orders-2024", kmsg.ACLResourcePatternTypeLiteral},
+			{"orders-", kmsg.ACLResourcePatternTypePrefixed},
+			{"*", kmsg.ACLResourcePatternTypeLiteral},
+		} {
+			resp.Resources = append(resp.Resources, kmsg.DescribeACLsResponseResource{
+				ResourceType:        kmsg.ACLResourceTypeTopic,
+				ResourceName:        s.name,
+				ResourcePatternType: s.pattern,
+				ACLs: []kmsg.DescribeACLsResponseResourceACL{{
+					Principal:      "User:a",
+					Host:           "*",
+					Operation:      kmsg.ACLOperationRead,
+					PermissionType: kmsg.ACLPermissionTypeAllow,
+				}},
+			})
+		}
+		return resp
+	})
+
+	results, err := ListACLs(context.Background(), b.Client(), ACLFilter{
+		Topics:      []string{"orders-2024"},
+		PatternType: kmsg.ACLResourcePatternTypeMatch,
+	})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, sent, 1)
+	r := sent[0]
+	require.Equal(t, kmsg.ACLResourceTypeTopic, r.ResourceType)
+	require.Equal(t, kmsg.ACLResourcePatternTypeMatch, r.ResourcePatternType, "match must be sent to the broker as is")
+	require.NotNil(t, r.ResourceName)
+	require.Equal(t, "orders-2024", *r.ResourceName)
+
+	// The ACLs for other names that the broker matched are kept.
+	var names []string
+	for _, r := range results {
+		require.NoError(t, r.Err)
+		for _, d := range r.Described {
+			names = append(names, d.ResourceName)
+		}
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"*", "orders-", "orders-2024"}, names)
+}
+
+func TestListACLsEach(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent int
+	)
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		sent++
+		return req.(*kmsg.DescribeACLsRequest).ResponseKind()
+	})
+
+	type part struct {
+		name string
+		op   kmsg.ACLOperation
+	}
+	var (
+		parts    []part
+		received int
+	)
+	err := ListACLsEach(context.Background(), b.Client(), ACLFilter{
+		Topics:     []string{"a", "b"},
+		Operations: []kmsg.ACLOperation{kmsg.ACLOperationRead, kmsg.ACLOperationWrite},
+	}, func(results []ListACLsResult) error {
+		require.NotEmpty(t, results)
+		for _, r := range results {
+			require.NoError(t, r.Err)
+			require.Equal(t, *results[0].Name, *r.Name, "each call is for one resource")
+			require.Equal(t, results[0].Operation, r.Operation, "each call is for one operation")
+		}
+		received += len(results)
+		mu.Lock()
+		require.Equal(t, received, sent, "later filters must not be described before earlier results are handled")
+		mu.Unlock()
+		parts = append(parts, part{*results[0].Name, results[0].Operation})
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []part{
+		{"a", kmsg.ACLOperationRead},
+		{"a", kmsg.ACLOperationWrite},
+		{"b", kmsg.ACLOperationRead},
+		{"b", kmsg.ACLOperationWrite},
+	}, parts)
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, test := range []struct {
+		glob string
+		name string
+		exp  bool
+	}{
+		{"tmp-*", "tmp-1", true},
+		{"tmp-*", "tmp-", true},
+		{"tmp-*", "tmp", false},
+		{"tmp-*", "my-tmp-1", false},
+		{"*-tmp", "my-tmp", true},
+		{"*tmp*", "a-tmp-b", true},
+		{"t?p", "tmp", true},
+		{"t?p", "tp", false},
+		{"t?p", "tmmp", false},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "axxbyybzc", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "acb", false},
+		{"**", "foo", true},
+
+		// Empty names are only matched by globs that can match nothing.
+		{"*", "", true},
+		{"?", "", false},
+		{"tmp-*", "", false},
+
+		// The wildcard resource is compared as the literal name "*".
+		{"*", "*", true},
+		{"?", "*", true},
+		{"tmp-*", "*", false},
+
+		// Characters are runes, not bytes.
+		{"??", "éa", true},
+		{"[ab]", "[ab]", true},
+		{"[ab]", "a", false},
+	} {
+		require.Equal(t, test.exp, MatchGlob(test.glob, test.name), "MatchGlob(%q, %q)", test.glob, test.name)
+	}
+}
+
+func TestExactACLFilter(t *testing.T) {
+	for _, test := range []struct {
+		a   ACL
+		exp ACLFilter
+	}{
+		{
+			a: ACL{
+				Principal:           "User:alice",
+				Host:                "*",
+				ResourceType:        kmsg.ACLResourceTypeTopic,
+				ResourceName:        "tmp-1",
+				ResourcePatternType: kmsg.ACLResourcePatternTypeLiteral,
+				Operation:           kmsg.ACLOperationRead,
+				Permission:          kmsg.ACLPermissionTypeAllow,
+			},
+			exp: ACLFilter{
+				Topics:          []string{"tmp-1"},
+				PatternType:     kmsg.ACLResourcePatternTypeLiteral,
+				Operations:      []kmsg.ACLOperation{kmsg.ACLOperationRead},
+				AllowPrincipals: []string{"User:alice"},
+				AllowHosts:      []string{"*"},
+			},
+		},
+		{
+			a: ACL{
+				Principal:           "User:bob",
+				Host:                "10.0.0.1",
+				ResourceType:        kmsg.ACLResourceTypeGroup,
+				ResourceName:        "tmp-",
+				ResourcePatternType: kmsg.ACLResourcePatternTypePrefixed,
+				Operation:           kmsg.ACLOperationDescribe,
+				Permission:          kmsg.ACLPermissionTypeDeny,
+			},
+			exp: ACLFilter{
+				Groups:         []string{"tmp-"},
+				PatternType:    kmsg.ACLResourcePatternTypePrefixed,
+				Operations:     []kmsg.ACLOperation{kmsg.ACLOperationDescribe},
+				DenyPrincipals: []string{"User:bob"},
+				DenyHosts:      []string{"10.0.0.1"},
+			},
+		},
+		{
+			a: ACL{
+				Principal:           "User:carol",
+				Host:                "*",
+				ResourceType:        kmsg.ACLResourceTypeCluster,
+				ResourceName:        "kafka-cluster",
+				ResourcePatternType: kmsg.ACLResourcePatternTypeLiteral,
+				Operation:           kmsg.ACLOperationAlter,
+				Permission:          kmsg.ACLPermissionTypeAllow,
+			},
+			exp: ACLFilter{
+				Cluster:         true,
+				PatternType:     kmsg.ACLResourcePatternTypeLiteral,
+				Operations:      []kmsg.ACLOperation{kmsg.ACLOperationAlter},
+				AllowPrincipals: []string{"User:carol"},
+				AllowHosts:      []string{"*"},
+			},
+		},
+	} {
+		f := ExactACLFilter(test.a)
+		require.Equal(t, test.exp, f)
+		_, err := f.Builder()
+		require.NoError(t, err)
+	}
+}
@@ -10,15 +10,22 @@
 package kafka
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kerr"
@@ -32,9 +39,17 @@ func NewFranzClient(
 	fs afero.Fs, p *config.Params, cfg *config.Config, extraOpts ...kgo.Opt,
 ) (*kgo.Client, error) {
 	k := &cfg.Rpk.KafkaAPI
+	if len(k.Brokers) == 0 {
+		return nil, &config.NoBrokersError{}
+	}
+
+	seeds := k.Brokers
+	if !p.NoShuffle {
+		seeds = shuffleSeeds(seeds)
+	}
 
 	opts := []kgo.Opt{
-		kgo.SeedBrokers(k.Brokers...),
+		kgo.SeedBrokers(seeds...),
 		kgo.ClientID("rpk"),
 
 		// We want our timeouts to be _short_ but still allow for
@@ -56,7 +71,7 @@ func NewFranzClient(
 		// once we support -X and then add these as configurable
 		// options. We cannot be "aggressively" low without override
 		// options because we may affect end users.
-		kgo.DialTimeout(3 * time.Second),
+		kgo.DialTimeout(dialTimeout),
 		kgo.RequestTimeoutOverhead(5 * time.Second),
 		kgo.RetryTimeout(11 * time.Second), // if updating this, update below's SetTimeoutMillis
 
@@ -70,27 +85,79 @@ func NewFranzClient(
 		kgo.MetadataMinAge(250 * time.Millisecond),
 	}
 
+	tc, err := k.TLS.Config(fs)
+	if err != nil {
+		return nil, err
+	}
+	if tc != nil {
+		log.Debugf("using TLS for the kafka API")
+	}
+	// Our dialer handles TLS itself, so that the handshake is with the
+	// broker through any proxy tunnel and certificate errors explain the
+	// expected and presented names.
+	dial, err := newDialer(p.Proxy, tc, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if dial != nil {
+		opts = append(opts, kgo.Dialer(dial))
+	}
+
+	// With --sasl-mechanism auto, we replace the mechanism with whatever
+	// we negotiate, or disable SASL if there are no credentials. We work
+	// on a copy so that the caller's config is left as is.
+	var (
+		sasl      *config.SASL
+		mechanism string
+	)
 	if k.SASL != nil {
+		if err := k.SASL.Validate(); err != nil {
+			return nil, err
+		}
+		copied := *k.SASL
+		sasl = &copied
+		mechanism = sasl.Mechanism
+		if strings.EqualFold(mechanism, config.SASLMechanismAuto) {
+			if m, ok := negotiatedSASL.Load(k.SASL); ok {
+				mechanism = m.(string)
+			} else {
+				if mechanism, err = negotiateSASLMechanism(opts, sasl); err != nil {
+					return nil, err
+				}
+				negotiatedSASL.Store(k.SASL, mechanism)
+			}
+			if mechanism == "" {
+				sasl = nil
+			} else {
+				sasl.Mechanism = mechanism
+			}
+		}
+	}
+	if sasl != nil && strings.EqualFold(mechanism, config.SASLMechanismOAuth) {
+		opts = append(opts, kgo.SASL(oauthMechanism(*sasl)))
+	} else if sasl != nil {
+		if sasl.User != "" && sasl.Password == "" && p.PromptPassword != nil {
+			if sasl.Password, err = p.PromptPassword(sasl.User); err != nil {
+				return nil, err
+			}
+		}
 		mech := scram.Auth{
-			User: k.SASL.User,
-			Pass: k.SASL.Password,
+			User: sasl.User,
+			Pass: sasl.Password,
 		}
-		switch name := strings.ToUpper(k.SASL.Mechanism); name {
+		log.Debugf("using SASL mechanism %q with user %q and password %s", mechanism, sasl.User, redact(sasl.Password))
+		switch name := strings.ToUpper(mechanism); name {
 		case "SCRAM-SHA-256", "": // we default to SCRAM-SHA-256 -- people commonly specify user & pass without --sasl-mechanism
 			opts = append(opts, kgo.SASL(mech.AsSha256Mechanism()))
 		case "SCRAM-SHA-512":
 			opts = append(opts, kgo.SASL(mech.AsSha512Mechanism()))
 		default:
-			return nil, fmt.Errorf("unknown SASL mechanism %q, supported: [SCRAM-SHA-256, SCRAM-SHA-512]", name)
+			return nil, fmt.Errorf("unknown SASL mechanism %q, supported: %s", name, strings.Join(config.SASLMechanisms, ", "))
 		}
 	}
 
-	tc, err := k.TLS.Config(fs)
-	if err != nil {
-		return nil, err
-	}
-	if tc != nil {
-		opts = append(opts, kgo.DialTLSConfig(tc))
+	if hook, ok := metricsHook(p); ok {
+		opts = append(opts, hook)
 	}
 
 	if p.Verbose {
@@ -104,11 +171,139 @@ func NewFranzClient(
 	return kgo.NewClient(opts...)
 }
 
+// shuffleSeeds returns a shuffled copy of seeds. Requests that can go to any
+// broker, including the first metadata request, start with the first seed
+// and move on to the next seed on every retry, so shuffling spreads the
+// bootstrap load of many rpk processes across the brokers while still
+// trying every seed.
+func shuffleSeeds(seeds []string) []string {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	shuffled := make([]string, len(seeds))
+	copy(shuffled, seeds)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled
+}
+
+// negotiatedSASL caches what --sasl-mechanism auto resolved to, by the
+// *config.SASL it was resolved for, so that further clients do not negotiate
+// again and SASLMechanism reports what is used. An empty mechanism means SASL
+// is disabled.
+var negotiatedSASL sync.Map
+
+// SASLMechanism returns the SASL mechanism that clients from NewFranzClient
+// use for k, or an empty string if they do not use SASL. This must be called
+// after NewFranzClient for --sasl-mechanism auto to be resolved.
+func SASLMechanism(k *config.RpkKafkaAPI) string {
+	if k.SASL == nil {
+		return ""
+	}
+	mechanism := k.SASL.Mechanism
+	if strings.EqualFold(mechanism, config.SASLMechanismAuto) {
+		m, ok := negotiatedSASL.Load(k.SASL)
+		if !ok {
+			return strings.ToUpper(mechanism)
+		}
+		if mechanism = m.(string); mechanism == "" {
+			return ""
+		}
+	}
+	if mechanism == "" {
+		return "SCRAM-SHA-256"
+	}
+	return strings.ToUpper(mechanism)
+}
+
+// redact returns a placeholder for a secret, so that secrets are never logged.
+func redact(secret string) string {
+	if secret == "" {
+		return "(empty)"
+	}
+	return "[REDACTED]"
+}
+
+// negotiateSASLMechanism implements --sasl-mechanism auto: it asks a broker
+// which SASL mechanisms it supports and returns the first one that rpk can
+// use with the configured credentials, per config.SASLMechanismAuto. If there
+// are no credentials, this returns an empty mechanism without asking.
+func negotiateSASLMechanism(opts []kgo.Opt, sasl *config.SASL) (string, error) {
+	var want []string
+	switch {
+	case sasl.User != "":
+		want = []string{"SCRAM-SHA-512", "SCRAM-SHA-256"}
+	case sasl.Token != "" || sasl.TokenCommand != "":
+		want = []string{config.SASLMechanismOAuth}
+	default:
+		log.Debugf("--%s %s without credentials, not using SASL", config.FlagSASLMechanism, config.SASLMechanismAuto)
+		return "", nil
+	}
+
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return "", err
+	}
+	defer cl.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// A handshake for no mechanism fails, but the broker replies with
+	// every mechanism it supports.
+	req := kmsg.NewPtrSASLHandshakeRequest()
+	resp, err := req.RequestWith(ctx, cl)
+	if err != nil {
+		return "", fmt.Errorf("unable to ask the broker for its SASL mechanisms for --%s %s: %w", config.FlagSASLMechanism, config.SASLMechanismAuto, err)
+	}
+	mechanism, err := pickSASLMechanism(resp.SupportedMechanisms, want)
+	if err != nil {
+		return "", err
+	}
+	log.Debugf("broker supports SASL mechanisms %v, using %s", resp.SupportedMechanisms, mechanism)
+	return mechanism, nil
+}
+
+// pickSASLMechanism returns the first mechanism in want that the broker
+// offers.
+func pickSASLMechanism(offered, want []string) (string, error) {
+	for _, w := range want {
+		for _, o := range offered {
+			if strings.EqualFold(w, o) {
+				return w, nil
+			}
+		}
+	}
+	brokerHas := "none"
+	if len(offered) > 0 {
+		brokerHas = strings.Join(offered, ", ")
+	}
+	return "", fmt.Errorf("the broker does not support any SASL mechanism that rpk can use with the given credentials: the broker supports %s, rpk supports %s",
+		brokerHas, strings.Join(want, ", "))
+}
+
+// NewConnectedClient returns a franz-go client, as NewFranzClient does, that
+// has connected to the cluster, retrying per --retries. If the connection fails
+// and no brokers were configured, the error says that rpk used the default.
+func NewConnectedClient(
+	fs afero.Fs, p *config.Params, cfg *config.Config, extraOpts ...kgo.Opt,
+) (*kgo.Client, error) {
+	cl, err := NewFranzClient(fs, p, cfg, extraOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := connectWithRetries(p.Context(), cl, p.Retries, p.RetryBackoff); err != nil {
+		cl.Close()
+		if cfg.BrokersDefaulted() {
+			return nil, fmt.Errorf("%w; no brokers were specified, so rpk used the default %s: pass --%s, set %s, or configure rpk.kafka_api.brokers in the config file",
+				err, strings.Join(cfg.Rpk.KafkaAPI.Brokers, ","), config.FlagBrokers, config.EnvBrokers)
+		}
+		return nil, err
+	}
+	return cl, nil
+}
+
 // NewAdmin returns a franz-go admin client.
 func NewAdmin(
 	fs afero.Fs, p *config.Params, cfg *config.Config, extraOpts ...kgo.Opt,
 ) (*kadm.Client, error) {
-	cl, err := NewFranzClient(fs, p, cfg, extraOpts...)
+	cl, err := NewConnectedClient(fs, p, cfg, extraOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +312,159 @@ func NewAdmin(
 	return adm, nil
 }
 
+// CheckBrokerACLVersions fetches the broker's API versions and warns if the
+// ACL APIs are incompatible with rpk per CheckACLVersions. With
+// --fail-incompatible-versions, an incompatibility is an error instead. ACL
+// commands call this once after connecting. Failing to fetch the versions is
+// not an error here: the command's own requests surface any connection
+// problem. The versions are cached for the client's later pattern type
+// checks.
+func CheckBrokerACLVersions(p *config.Params, cl *kgo.Client) error {
+	ctx, cancel := context.WithTimeout(p.Context(), 5*time.Second)
+	defer cancel()
+	versions, err := clientAPIVersions(ctx, cl)
+	if err != nil {
+		log.Debugf("unable to fetch broker API versions: %v", err)
+		return nil
+	}
+	if err := CheckACLVersions(versions); err != nil {
+		if p.FailIncompatibleVersions {
+			return fmt.Errorf("%w (--%s)", err, config.FlagFailIncompatibleVersions)
+		}
+		out.Warnf("warning: %v", err)
+	}
+	return nil
+}
+
+// dialTimeout bounds dialing a broker, including the TLS handshake, and each
+// attempt of connectWithRetries.
+const dialTimeout = 3 * time.Second
+
+// connectWithRetries pings the cluster, retrying transient connection errors
+// up to retries times with exponential backoff. This is for freshly started
+// clusters that are not yet accepting connections. Anything else, such as a
+// SASL authentication failure, fails immediately. Each attempt times out after
+// dialTimeout, so an unreachable cluster fails within a few seconds per retry.
+// The connection of a successful ping is kept for the command's requests.
+//
+// If retries is zero, this does nothing and the first request issued by the
+// client surfaces any connection error. If parent is canceled, this returns
+// out.ErrInterrupted; if parent's deadline passes, the last error is returned
+// without retrying.
+func connectWithRetries(parent context.Context, cl *kgo.Client, retries int, backoff time.Duration) error {
+	if retries <= 0 {
+		return nil
+	}
+	attempts := retries + 1
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(parent, dialTimeout)
+		err := cl.Ping(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if perr := parent.Err(); perr != nil {
+			if errors.Is(perr, context.Canceled) {
+				return out.ErrInterrupted
+			}
+			return err
+		}
+		if !isTransientConnErr(err) {
+			return err
+		}
+		if attempt == attempts {
+			return fmt.Errorf("unable to connect to the cluster after %d attempts: %w", attempts, err)
+		}
+		clientMetrics.retried()
+		select {
+		case <-time.After(backoff):
+		case <-parent.Done():
+			if errors.Is(parent.Err(), context.Canceled) {
+				return out.ErrInterrupted
+			}
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientConnErr returns whether err is a connection error that is worth
+// retrying: the broker is not listening yet, or the dial or request timed out.
+func isTransientConnErr(err error) bool {
+	var ne net.Error
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &ne) && ne.Timeout()
+}
+
+type requestTimeoutKey struct{}
+
+// RequestContext returns a context that times out after the params'
+// RequestTimeout and that is canceled if the params' Context is canceled,
+// i.e. if rpk is interrupted. Errors from requests issued with this context
+// should be passed through RequestErr.
+//
+// This marks the command as out.Interruptible: the first interrupt cancels
+// the context rather than exiting, so a command must issue all of its
+// requests with a RequestContext once it uses one.
+func RequestContext(p *config.Params) (context.Context, context.CancelFunc) {
+	out.Interruptible()
+	if p.RequestTimeout <= 0 {
+		return context.WithCancel(p.Context())
+	}
+	ctx := context.WithValue(p.Context(), requestTimeoutKey{}, p.RequestTimeout)
+	return context.WithTimeout(ctx, p.RequestTimeout)
+}
+
+// MultiRequestContext is RequestContext for functions that issue several
+// requests one after another, such as ExistingACLs: the returned context does
+// not time out, but it carries the params' RequestTimeout, and such functions
+// bound each of their requests by it. Errors should be passed through
+// RequestErr.
+func MultiRequestContext(p *config.Params) (context.Context, context.CancelFunc) {
+	out.Interruptible()
+	ctx := p.Context()
+	if p.RequestTimeout > 0 {
+		ctx = context.WithValue(ctx, requestTimeoutKey{}, p.RequestTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// perRequestContext returns a context for one request of a function that
+// issues several: if ctx is from MultiRequestContext, the request times out
+// after the request timeout.
+func perRequestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// RequestErr returns a clear "operation timed out" error if err is because a
+// RequestContext timed out, out.ErrInterrupted if the request was canceled
+// because rpk was interrupted, otherwise err.
+func RequestErr(ctx context.Context, err error) error {
+	if err != nil && errors.Is(err, context.Canceled) && errors.Is(ctx.Err(), context.Canceled) {
+		return out.ErrInterrupted
+	}
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if d, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok {
+		return &timeoutError{d}
+	}
+	return err
+}
+
+type timeoutError struct{ d time.Duration }
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("operation timed out after %s (see --%s)", e.d, config.FlagRequestTimeout)
+}
+func (*timeoutError) Unwrap() error { return context.DeadlineExceeded }
+
 // MetaString returns what we will print within rpk for kgo.BrokerMetadata.
 func MetaString(meta kgo.BrokerMetadata) string {
 	return fmt.Sprintf("%s (%d)", net.JoinHostPort(meta.Host, strconv.Itoa(int(meta.Port))), meta.NodeID)
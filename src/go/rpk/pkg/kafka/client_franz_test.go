@@ -0,0 +1,248 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka/kafkatest"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestConnectWithRetries(t *testing.T) {
+	// We grab a free port and close the listener so that connecting
+	// is refused.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	cl, err := kgo.NewClient(kgo.SeedBrokers(addr))
+	require.NoError(t, err)
+	defer cl.Close()
+
+	err = connectWithRetries(context.Background(), cl, 2, time.Millisecond)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "after 3 attempts")
+
+	require.NoError(t, connectWithRetries(context.Background(), cl, 0, time.Millisecond), "zero retries should not connect")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = connectWithRetries(ctx, cl, 2, time.Hour)
+	require.ErrorIs(t, err, out.ErrInterrupted, "an interrupt should stop retrying")
+
+	// The overall deadline passing stops retrying, and is not an
+	// interrupt.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = connectWithRetries(ctx, cl, 2, time.Hour)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, out.ErrInterrupted)
+	require.NotContains(t, err.Error(), "attempts")
+	require.Less(t, time.Since(start), time.Minute)
+}
+
+func TestIsTransientConnErr(t *testing.T) {
+	require.True(t, isTransientConnErr(&net.OpError{Op: "dial", Err: timeoutErr{}}))
+	require.False(t, isTransientConnErr(kerr.SaslAuthenticationFailed))
+}
+
+func TestRequestErr(t *testing.T) {
+	ctx, cancel := RequestContext(&config.Params{RequestTimeout: time.Millisecond})
+	defer cancel()
+	<-ctx.Done()
+
+	err := RequestErr(ctx, fmt.Errorf("unable to issue request: %w", ctx.Err()))
+	require.EqualError(t, err, "operation timed out after 1ms (see --request-timeout)")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "timeout error should still be a deadline error")
+
+	other := errors.New("other")
+	require.Equal(t, other, RequestErr(ctx, other))
+	require.NoError(t, RequestErr(ctx, nil))
+
+	// Without a timeout, the context never expires.
+	ctx, cancel = RequestContext(&config.Params{})
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	require.False(t, hasDeadline)
+
+	// Canceling the params' context, as an interrupt does, cancels the
+	// request context.
+	parent, interrupt := context.WithCancel(context.Background())
+	ctx, cancel = RequestContext((&config.Params{RequestTimeout: time.Minute}).WithContext(parent))
+	defer cancel()
+	interrupt()
+	<-ctx.Done()
+	err = RequestErr(ctx, fmt.Errorf("unable to issue request: %w", ctx.Err()))
+	require.ErrorIs(t, err, out.ErrInterrupted)
+	require.Equal(t, out.ExitInterrupted, out.ExitCode(err))
+}
+
+func TestMultiRequestContext(t *testing.T) {
+	ctx, cancel := MultiRequestContext(&config.Params{RequestTimeout: time.Minute})
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	require.False(t, hasDeadline, "the timeout bounds each request, not all of them")
+
+	reqCtx, reqCancel := perRequestContext(ctx)
+	defer reqCancel()
+	_, hasDeadline = reqCtx.Deadline()
+	require.True(t, hasDeadline)
+
+	// One request timing out is reported as our timeout.
+	ctx, cancel = MultiRequestContext(&config.Params{RequestTimeout: time.Millisecond})
+	defer cancel()
+	reqCtx, reqCancel = perRequestContext(ctx)
+	defer reqCancel()
+	<-reqCtx.Done()
+	err := RequestErr(ctx, fmt.Errorf("unable to issue request: %w", reqCtx.Err()))
+	require.EqualError(t, err, "operation timed out after 1ms (see --request-timeout)")
+}
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestRedact(t *testing.T) {
+	require.Equal(t, "[REDACTED]", redact("hunter2"))
+	require.Equal(t, "(empty)", redact(""))
+}
+
+func TestNoBrokers(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	p := &config.Params{Retries: 1, RetryBackoff: time.Millisecond}
+	cfg := &config.Config{} // no brokers, and not defaulted by Load
+
+	_, err := NewFranzClient(fs, p, cfg)
+	var nb *config.NoBrokersError
+	require.ErrorAs(t, err, &nb)
+	require.Contains(t, err.Error(), "--brokers")
+	require.Equal(t, out.ExitError, out.ExitCode(err), "no brokers is a usage error")
+
+	_, err = NewAdmin(fs, p, cfg)
+	require.ErrorAs(t, err, &nb, "the admin client must fail before connecting")
+}
+
+func TestPickSASLMechanism(t *testing.T) {
+	scram := []string{"SCRAM-SHA-512", "SCRAM-SHA-256"}
+
+	got, err := pickSASLMechanism([]string{"SCRAM-SHA-256", "SCRAM-SHA-512"}, scram)
+	require.NoError(t, err)
+	require.Equal(t, "SCRAM-SHA-512", got, "SCRAM-SHA-512 is preferred")
+
+	got, err = pickSASLMechanism([]string{"PLAIN", "scram-sha-256"}, scram)
+	require.NoError(t, err)
+	require.Equal(t, "SCRAM-SHA-256", got)
+
+	_, err = pickSASLMechanism([]string{"PLAIN", "GSSAPI"}, scram)
+	require.EqualError(t, err, "the broker does not support any SASL mechanism that rpk can use with the given credentials: the broker supports PLAIN, GSSAPI, rpk supports SCRAM-SHA-512, SCRAM-SHA-256")
+
+	_, err = pickSASLMechanism(nil, scram)
+	require.ErrorContains(t, err, "the broker supports none")
+}
+
+func TestNegotiateSASLMechanismWithoutCredentials(t *testing.T) {
+	// Without credentials, auto does not dial the (here unusable) broker
+	// and disables SASL.
+	got, err := negotiateSASLMechanism([]kgo.Opt{kgo.SeedBrokers("127.0.0.1:1")}, &config.SASL{Mechanism: config.SASLMechanismAuto})
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestNewFranzClientKeepsSASL(t *testing.T) {
+	// auto without credentials disables SASL for the client, but the
+	// caller's config must be left as is.
+	cfg := &config.Config{}
+	cfg.Rpk.KafkaAPI.Brokers = []string{"127.0.0.1:1"}
+	cfg.Rpk.KafkaAPI.SASL = &config.SASL{Mechanism: config.SASLMechanismAuto}
+	cl, err := NewFranzClient(afero.NewMemMapFs(), &config.Params{}, cfg)
+	require.NoError(t, err)
+	cl.Close()
+
+	require.Equal(t, &config.SASL{Mechanism: config.SASLMechanismAuto}, cfg.Rpk.KafkaAPI.SASL)
+	require.Empty(t, SASLMechanism(&cfg.Rpk.KafkaAPI))
+}
+
+func TestNewFranzClientPromptsPassword(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Rpk.KafkaAPI.Brokers = []string{"127.0.0.1:1"}
+	for _, test := range []struct {
+		name       string
+		sasl       config.SASL
+		expPrompts int
+	}{
+		{"user without password", config.SASL{User: "bob"}, 1},
+		{"user with password", config.SASL{User: "bob", Password: "secret"}, 0},
+		{"oauth", config.SASL{Mechanism: config.SASLMechanismOAuth, Token: "t"}, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			sasl := test.sasl
+			cfg.Rpk.KafkaAPI.SASL = &sasl
+			var prompts int
+			p := &config.Params{PromptPassword: func(user string) (string, error) {
+				prompts++
+				require.Equal(t, "bob", user)
+				return "prompted", nil
+			}}
+			cl, err := NewFranzClient(afero.NewMemMapFs(), p, cfg)
+			require.NoError(t, err)
+			cl.Close()
+			require.Equal(t, test.expPrompts, prompts)
+			require.Equal(t, test.sasl, *cfg.Rpk.KafkaAPI.SASL)
+		})
+	}
+}
+
+func TestShuffleSeeds(t *testing.T) {
+	seeds := []string{"b0:9092", "b1:9092", "b2:9092", "b3:9092"}
+	orig := append([]string(nil), seeds...)
+
+	shuffled := shuffleSeeds(seeds)
+	require.ElementsMatch(t, seeds, shuffled, "every seed must still be tried")
+	require.Equal(t, orig, seeds, "the input must not be modified")
+}
+
+func TestSeedsAllTried(t *testing.T) {
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		t.Errorf("unexpected %s request", kmsg.NameForKey(req.Key()))
+		return req.ResponseKind()
+	})
+	// Nothing listens on a closed listener's address, so the first seed
+	// fails and the client must move on to the next.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	dead := l.Addr().String()
+	l.Close()
+
+	for _, noShuffle := range []bool{true, false} {
+		cfg := &config.Config{}
+		cfg.Rpk.KafkaAPI.Brokers = []string{dead, b.Addr()}
+		cl, err := NewFranzClient(afero.NewMemMapFs(), &config.Params{NoShuffle: noShuffle}, cfg)
+		require.NoError(t, err)
+		_, err = Ping(context.Background(), cl)
+		cl.Close()
+		require.NoError(t, err, "no shuffle %v", noShuffle)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+// ACL writes are handled by the controller. While the controller is being
+// elected, brokers reply to CreateACLs and DeleteACLs with NOT_CONTROLLER for
+// every creation or filter, which kgo does not retry for these requests. We
+// retry the failed parts of the write a bounded number of times, refreshing
+// metadata before every retry so that we wait for and log the new controller.
+// Retries and backoffs are bounded by the context, i.e. by --request-timeout.
+var (
+	notControllerRetries = 5
+	notControllerBackoff = 250 * time.Millisecond
+)
+
+func isNotController(err error) bool {
+	return errors.Is(err, kerr.NotController)
+}
+
+// retryNotController calls attempt until it reports that nothing failed with
+// NOT_CONTROLLER, or until notControllerRetries retries have been made, or
+// the context is done. Before every retry, this backs off and refreshes
+// metadata. Attempt errors are returned immediately; if the context is done
+// while backing off, the context error is returned.
+func retryNotController(
+	ctx context.Context,
+	adm *kadm.Client,
+	what string,
+	attempt func() (failed int, err error),
+) error {
+	backoff := notControllerBackoff
+	for retry := 0; ; retry++ {
+		failed, err := attempt()
+		if err != nil || failed == 0 || retry == notControllerRetries {
+			return err
+		}
+		log.Debugf("%d %s failed with NOT_CONTROLLER, retrying in %s (retry %d of %d)", failed, what, backoff, retry+1, notControllerRetries)
+		clientMetrics.retried()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		m, err := adm.BrokerMetadata(ctx)
+		switch {
+		case err != nil:
+			log.Debugf("unable to refresh metadata to find the new controller: %v", err)
+		case m.Controller < 0:
+			log.Debugf("the cluster has no controller yet")
+		default:
+			log.Debugf("the controller is now broker %d", m.Controller)
+		}
+	}
+}
+
+// deleteACLs is adm.DeleteACLs, retrying the filters that fail with
+// NOT_CONTROLLER. Retries issue the whole builder again: ACLs deleted by
+// filters that succeeded earlier no longer exist, and we keep the earlier
+// results of those filters.
+func deleteACLs(ctx context.Context, adm *kadm.Client, b *kadm.ACLBuilder) (kadm.DeleteACLsResults, error) {
+	var results kadm.DeleteACLsResults
+	err := retryNotController(ctx, adm, "ACL delete filters", func() (int, error) {
+		retried, err := adm.DeleteACLs(ctx, b)
+		if err != nil {
+			return 0, err
+		}
+		results = mergeDeleteResults(results, retried)
+		var failed int
+		for _, r := range results {
+			if isNotController(r.Err) {
+				failed++
+			}
+		}
+		return failed, nil
+	})
+	return results, err
+}
+
+// mergeDeleteResults replaces the results in prior that failed with
+// NOT_CONTROLLER with the same filter's result in retried. If the filters of
+// the two do not line up, which should not happen because the same builder
+// is issued, retried is returned.
+func mergeDeleteResults(prior, retried kadm.DeleteACLsResults) kadm.DeleteACLsResults {
+	if prior == nil {
+		return retried
+	}
+	if len(prior) != len(retried) {
+		return retried
+	}
+	for i := range prior {
+		if !sameDeleteFilter(prior[i], retried[i]) {
+			return retried
+		}
+	}
+	for i := range prior {
+		if isNotController(prior[i].Err) {
+			prior[i] = retried[i]
+		}
+	}
+	return prior
+}
+
+func sameDeleteFilter(l, r kadm.DeleteACLsResult) bool {
+	eq := func(l, r *string) bool {
+		return l == nil && r == nil || l != nil && r != nil && *l == *r
+	}
+	return eq(l.Principal, r.Principal) &&
+		eq(l.Host, r.Host) &&
+		l.Type == r.Type &&
+		eq(l.Name, r.Name) &&
+		l.Pattern == r.Pattern &&
+		l.Operation == r.Operation &&
+		l.Permission == r.Permission
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka/kafkatest"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestCreateACLsNotController(t *testing.T) {
+	defer func(b time.Duration) { notControllerBackoff = b }(notControllerBackoff)
+	notControllerBackoff = time.Millisecond
+
+	var (
+		mu   sync.Mutex
+		sent [][]string // principals of every CreateACLs request
+		b    *kafkatest.Broker
+	)
+	b = kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		creq := req.(*kmsg.CreateACLsRequest)
+		resp := creq.ResponseKind().(*kmsg.CreateACLsResponse)
+		var principals []string
+		for _, c := range creq.Creations {
+			principals = append(principals, c.Principal)
+			r := kmsg.NewCreateACLsResponseResult()
+			// The controller changes after the first request, which
+			// only User:a made it through.
+			if len(sent) == 0 && c.Principal != "User:a" {
+				r.ErrorCode = kerr.NotController.Code
+			}
+			resp.Results = append(resp.Results, r)
+		}
+		if len(sent) == 0 {
+			b.SetController(-1)
+		} else if len(sent) == 1 {
+			b.SetController(0)
+		}
+		sent = append(sent, principals)
+		return resp
+	})
+
+	creation := func(principal string) kmsg.CreateACLsRequestCreation {
+		c := kmsg.NewCreateACLsRequestCreation()
+		c.Principal = principal
+		return c
+	}
+	creations := []kmsg.CreateACLsRequestCreation{creation("User:a"), creation("User:b"), creation("User:c")}
+	results, err := CreateACLs(context.Background(), b.Client(), creations)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	for i, r := range results {
+		require.Equal(t, creations[i], r.Creation)
+		require.NoError(t, r.Err)
+	}
+	// Only the creations that failed with NOT_CONTROLLER are resent.
+	require.Equal(t, [][]string{{"User:a", "User:b", "User:c"}, {"User:b", "User:c"}}, sent)
+}
+
+func TestCreateACLsNotControllerExhausted(t *testing.T) {
+	defer func(b time.Duration) { notControllerBackoff = b }(notControllerBackoff)
+	notControllerBackoff = time.Millisecond
+
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		creq := req.(*kmsg.CreateACLsRequest)
+		resp := creq.ResponseKind().(*kmsg.CreateACLsResponse)
+		for range creq.Creations {
+			r := kmsg.NewCreateACLsResponseResult()
+			r.ErrorCode = kerr.NotController.Code
+			resp.Results = append(resp.Results, r)
+		}
+		return resp
+	})
+	results, err := CreateACLs(context.Background(), b.Client(), []kmsg.CreateACLsRequestCreation{kmsg.NewCreateACLsRequestCreation()})
+	require.NoError(t, err)
+	require.ErrorIs(t, results[0].Err, kerr.NotController)
+	mu.Lock()
+	require.Equal(t, notControllerRetries+1, requests)
+	mu.Unlock()
+
+	// Retries stop at the context deadline.
+	notControllerBackoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = CreateACLs(ctx, b.Client(), []kmsg.CreateACLsRequestCreation{kmsg.NewCreateACLsRequestCreation()})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDeleteACLsNotController(t *testing.T) {
+	defer func(b time.Duration) { notControllerBackoff = b }(notControllerBackoff)
+	notControllerBackoff = time.Millisecond
+
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		dreq := req.(*kmsg.DeleteACLsRequest)
+		resp := dreq.ResponseKind().(*kmsg.DeleteACLsResponse)
+		for _, f := range dreq.Filters {
+			r := kmsg.NewDeleteACLsResponseResult()
+			match := kmsg.NewDeleteACLsResponseResultMatchingACL()
+			match.ResourceType = f.ResourceType
+			match.ResourceName = *f.ResourceName
+			match.Principal = "User:a"
+			switch {
+			case *f.ResourceName == "foo" && requests == 1:
+				// Deleted on the first request, so there is
+				// nothing to match on the retry.
+				r.MatchingACLs = append(r.MatchingACLs, match)
+			case *f.ResourceName == "bar" && requests == 1:
+				r.ErrorCode = kerr.NotController.Code
+			case *f.ResourceName == "bar":
+				r.MatchingACLs = append(r.MatchingACLs, match)
+			}
+			resp.Results = append(resp.Results, r)
+		}
+		return resp
+	})
+
+	results, err := DeleteACLs(context.Background(), b.Client(), ACLFilter{Topics: []string{"foo", "bar"}})
+	require.NoError(t, err)
+	mu.Lock()
+	require.Equal(t, 2, requests)
+	mu.Unlock()
+	deleted := make(map[string]int)
+	for _, r := range results {
+		require.NoError(t, r.Err)
+		for _, d := range r.Deleted {
+			deleted[d.ResourceName]++
+		}
+	}
+	require.Equal(t, map[string]int{"foo": 1, "bar": 1}, deleted)
+}
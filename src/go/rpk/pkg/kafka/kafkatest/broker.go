@@ -0,0 +1,186 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+// Package kafkatest provides a fake Kafka broker for usage in tests.
+package kafkatest
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Broker is a single broker cluster that answers ApiVersions and Metadata
+// itself and every other request with the handle function it is created
+// with. It is just enough of the Kafka protocol for kgo and kadm to talk to
+// it.
+type Broker struct {
+	t      *testing.T
+	l      net.Listener
+	handle func(kmsg.Request) kmsg.Response
+
+	mu          sync.Mutex
+	controller  int32
+	maxVersions map[int16]int16
+	requests    map[int16]int
+}
+
+// NewBroker returns a Broker listening on a local port that answers requests
+// with handle. The broker is closed when the test finishes.
+func NewBroker(t *testing.T, handle func(kmsg.Request) kmsg.Response) *Broker {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	b := &Broker{t: t, l: l, handle: handle}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				b.serve(c)
+			}()
+		}
+	}()
+	return b
+}
+
+// Addr returns the host:port the broker listens on.
+func (b *Broker) Addr() string { return b.l.Addr().String() }
+
+// SetController sets the controller ID returned in Metadata responses.
+func (b *Broker) SetController(id int32) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.controller = id
+}
+
+// LimitVersion makes the broker advertise at most max for the key in
+// ApiVersions, as an older broker would.
+func (b *Broker) LimitVersion(key kmsg.Key, max int16) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxVersions == nil {
+		b.maxVersions = make(map[int16]int16)
+	}
+	b.maxVersions[int16(key)] = max
+}
+
+// Requests returns how many requests of the key the broker has received,
+// including those that clients send on their own, such as the ApiVersions
+// request of every new connection.
+func (b *Broker) Requests(key kmsg.Key) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.requests[int16(key)]
+}
+
+// Client returns a client for the broker, which is closed when the test
+// finishes.
+func (b *Broker) Client() *kgo.Client {
+	cl, err := kgo.NewClient(kgo.SeedBrokers(b.Addr()))
+	require.NoError(b.t, err)
+	b.t.Cleanup(cl.Close)
+	return cl
+}
+
+func (b *Broker) serve(c net.Conn) {
+	for {
+		var size int32
+		if err := binary.Read(c, binary.BigEndian, &size); err != nil {
+			return
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(c, buf); err != nil {
+			return
+		}
+		key := int16(binary.BigEndian.Uint16(buf))
+		version := int16(binary.BigEndian.Uint16(buf[2:]))
+		corr := buf[4:8]
+		clientIDLen := int16(binary.BigEndian.Uint16(buf[8:]))
+		body := buf[10:]
+		if clientIDLen > 0 {
+			body = body[clientIDLen:]
+		}
+
+		req := kmsg.RequestForKey(key)
+		req.SetVersion(version)
+		if req.IsFlexible() {
+			body = body[1:] // no header tags
+		}
+		if err := req.ReadFrom(body); err != nil {
+			b.t.Errorf("unable to read %s request: %v", kmsg.NameForKey(key), err)
+			return
+		}
+
+		resp := b.respond(req)
+		resp.SetVersion(version)
+		out := append([]byte(nil), corr...)
+		// ApiVersions responses never have a flexible header.
+		if resp.IsFlexible() && key != int16(kmsg.ApiVersions) {
+			out = append(out, 0)
+		}
+		out = resp.AppendTo(out)
+		if err := binary.Write(c, binary.BigEndian, int32(len(out))); err != nil {
+			return
+		}
+		if _, err := c.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+func (b *Broker) respond(req kmsg.Request) kmsg.Response {
+	b.mu.Lock()
+	if b.requests == nil {
+		b.requests = make(map[int16]int)
+	}
+	b.requests[req.Key()]++
+	b.mu.Unlock()
+
+	switch req := req.(type) {
+	case *kmsg.ApiVersionsRequest:
+		resp := req.ResponseKind().(*kmsg.ApiVersionsResponse)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for k := int16(0); k <= kmsg.MaxKey; k++ {
+			if r := kmsg.RequestForKey(k); r != nil {
+				max := r.MaxVersion()
+				if limit, ok := b.maxVersions[k]; ok {
+					max = limit
+				}
+				resp.ApiKeys = append(resp.ApiKeys, kmsg.ApiVersionsResponseApiKey{ApiKey: k, MaxVersion: max})
+			}
+		}
+		return resp
+	case *kmsg.MetadataRequest:
+		host, port, _ := net.SplitHostPort(b.Addr())
+		p, _ := strconv.Atoi(port)
+		resp := req.ResponseKind().(*kmsg.MetadataResponse)
+		resp.Brokers = []kmsg.MetadataResponseBroker{{NodeID: 0, Host: host, Port: int32(p)}}
+		resp.ClusterID = kmsg.StringPtr("fake")
+		b.mu.Lock()
+		resp.ControllerID = b.controller
+		b.mu.Unlock()
+		return resp
+	default:
+		return b.handle(req)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// processStart approximates when rpk started, for the wall time of
+// --metrics.
+var processStart = time.Now()
+
+// clientMetrics collects what every client from NewFranzClient does if
+// --metrics is specified, and is nil otherwise. The hook is only added to
+// clients with the flag, and retried is a no-op on a nil *metrics, so that
+// collecting costs nothing when the flag is off.
+var clientMetrics *metrics
+
+// metrics is a kgo hook that counts connections and requests and times every
+// request, per request type. Retries count the attempts that kgo retries
+// internally until its retry timeout, and rpk's own retries of the initial
+// connection and of NOT_CONTROLLER errors. kgo does not report its retries,
+// so a failed connection or request counts as retried once another
+// connection, or another request of the same type, follows it; failures that
+// are returned without being retried are not retries.
+type metrics struct {
+	mu          sync.Mutex
+	dials       int
+	failedDials int
+	retries     int
+	byKey       map[int16]*OperationMetrics
+
+	// The failures that no later attempt has retried yet.
+	pendingDials int
+	pendingKeys  map[int16]int
+}
+
+// OperationMetrics is the summary of one request type for --metrics.
+type OperationMetrics struct {
+	Operation string        `json:"operation" yaml:"operation"`
+	Requests  int           `json:"requests" yaml:"requests"`
+	Errors    int           `json:"errors" yaml:"errors"`
+	Total     time.Duration `json:"-" yaml:"-"`
+	Max       time.Duration `json:"-" yaml:"-"`
+
+	TotalMillis float64 `json:"totalMillis" yaml:"totalMillis"`
+	AvgMillis   float64 `json:"avgMillis" yaml:"avgMillis"`
+	MaxMillis   float64 `json:"maxMillis" yaml:"maxMillis"`
+}
+
+// MetricsSummary is what --metrics prints.
+type MetricsSummary struct {
+	Requests          int                `json:"requests" yaml:"requests"`
+	FailedRequests    int                `json:"failedRequests" yaml:"failedRequests"`
+	Retries           int                `json:"retries" yaml:"retries"`
+	Connections       int                `json:"connections" yaml:"connections"`
+	FailedConnections int                `json:"failedConnections" yaml:"failedConnections"`
+	WallTimeMillis    float64            `json:"wallTimeMillis" yaml:"wallTimeMillis"`
+	Operations        []OperationMetrics `json:"operations" yaml:"operations"`
+
+	wall time.Duration
+}
+
+var enableMetrics sync.Once
+
+// metricsHook returns the hook option to add to every client if --metrics
+// is specified. The first call registers printing the summary when rpk
+// exits.
+func metricsHook(p *config.Params) (kgo.Opt, bool) {
+	if !p.Metrics {
+		return nil, false
+	}
+	enableMetrics.Do(func() {
+		clientMetrics = &metrics{byKey: make(map[int16]*OperationMetrics)}
+		f := p.Formatter
+		out.AtExit(func() { printMetrics(os.Stderr, f, clientMetrics.summary(time.Since(processStart))) })
+	})
+	return kgo.WithHooks(clientMetrics), true
+}
+
+// OnBrokerConnect implements kgo.HookBrokerConnect.
+func (m *metrics) OnBrokerConnect(_ kgo.BrokerMetadata, _ time.Duration, _ net.Conn, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dials++
+	if m.pendingDials > 0 {
+		m.pendingDials--
+		m.retries++
+	}
+	if err != nil {
+		m.failedDials++
+		m.pendingDials++
+	}
+}
+
+// OnBrokerE2E implements kgo.HookBrokerE2E.
+func (m *metrics) OnBrokerE2E(_ kgo.BrokerMetadata, key int16, e2e kgo.BrokerE2E) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op := m.byKey[key]
+	if op == nil {
+		op = &OperationMetrics{Operation: kmsg.NameForKey(key)}
+		m.byKey[key] = op
+	}
+	op.Requests++
+	if m.pendingKeys[key] > 0 {
+		m.pendingKeys[key]--
+		m.retries++
+	}
+	if e2e.Err() != nil {
+		op.Errors++
+		if m.pendingKeys == nil {
+			m.pendingKeys = make(map[int16]int)
+		}
+		m.pendingKeys[key]++
+	}
+	d := e2e.DurationE2E()
+	op.Total += d
+	if d > op.Max {
+		op.Max = d
+	}
+}
+
+// retried counts one of rpk's own retries of a request. The failures that rpk
+// retries are not counted again when the next attempt connects or requests.
+func (m *metrics) retried() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+	m.pendingDials = 0
+	m.pendingKeys = nil
+}
+
+// summary returns what has been collected, with operations sorted by total
+// time descending.
+func (m *metrics) summary(wall time.Duration) MetricsSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := MetricsSummary{
+		Retries:           m.retries,
+		Connections:       m.dials,
+		FailedConnections: m.failedDials,
+		WallTimeMillis:    millis(wall),
+		Operations:        []OperationMetrics{},
+		wall:              wall,
+	}
+	for _, op := range m.byKey {
+		o := *op
+		o.TotalMillis = millis(o.Total)
+		o.AvgMillis = millis(o.Total / time.Duration(o.Requests))
+		o.MaxMillis = millis(o.Max)
+		s.Requests += o.Requests
+		s.FailedRequests += o.Errors
+		s.Operations = append(s.Operations, o)
+	}
+	sort.Slice(s.Operations, func(i, j int) bool {
+		l, r := s.Operations[i], s.Operations[j]
+		if l.Total != r.Total {
+			return l.Total > r.Total
+		}
+		return l.Operation < r.Operation
+	})
+	return s
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Round(time.Microsecond)) / float64(time.Millisecond)
+}
+
+// printMetrics prints the summary to w, as text or nested under a "metrics"
+// key in the formatter's format. This goes to stderr so that it never mixes
+// with a command's output.
+func printMetrics(w io.Writer, f out.Formatter, s MetricsSummary) {
+	if !f.IsText() {
+		if err := f.PrintTo(w, map[string]MetricsSummary{"metrics": s}); err != nil {
+			fmt.Fprintf(w, "unable to print metrics: %v\n", err)
+		}
+		return
+	}
+	fmt.Fprintf(w, "\nKafka requests: %d (%d failed), retries: %d, connections: %d (%d failed), wall time: %s\n",
+		s.Requests, s.FailedRequests, s.Retries, s.Connections, s.FailedConnections, s.wall.Round(time.Millisecond))
+	if len(s.Operations) == 0 {
+		return
+	}
+	tw := out.NewTableTo(w, "Operation", "Requests", "Errors", "Total", "Avg", "Max")
+	defer tw.Flush()
+	round := func(d time.Duration) time.Duration { return d.Round(10 * time.Microsecond) }
+	for _, o := range s.Operations {
+		tw.Print(o.Operation, o.Requests, o.Errors, round(o.Total), round(o.Total/time.Duration(o.Requests)), round(o.Max))
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka/kafkatest"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/out"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestMetrics(t *testing.T) {
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		t.Errorf("unexpected %s request", kmsg.NameForKey(req.Key()))
+		return req.ResponseKind()
+	})
+	m := &metrics{byKey: make(map[int16]*OperationMetrics)}
+	cl, err := kgo.NewClient(kgo.SeedBrokers(b.Addr()), kgo.WithHooks(m))
+	require.NoError(t, err)
+	defer cl.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err = Ping(context.Background(), cl)
+		require.NoError(t, err)
+	}
+	m.OnBrokerConnect(kgo.BrokerMetadata{}, 0, nil, &net.OpError{Op: "dial"})
+	m.retried()
+
+	s := m.summary(time.Second)
+	// kgo connects to the seed, and then to the broker it discovers.
+	require.Equal(t, 1, s.FailedConnections)
+	require.GreaterOrEqual(t, s.Connections, 2)
+	require.Equal(t, 1, s.Retries, "the rpk retry of the failed connection counts once")
+	require.Equal(t, 0, s.FailedRequests)
+
+	var (
+		byOp  = make(map[string]OperationMetrics)
+		total int
+	)
+	for _, o := range s.Operations {
+		byOp[o.Operation] = o
+		total += o.Requests
+	}
+	require.Equal(t, 2, byOp["Metadata"].Requests)
+	require.NotZero(t, byOp["ApiVersions"].Requests, "every connection issues ApiVersions")
+	require.Equal(t, total, s.Requests)
+
+	var buf bytes.Buffer
+	printMetrics(&buf, out.Formatter{Kind: "json"}, s)
+	var parsed map[string]MetricsSummary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &parsed))
+	require.Equal(t, s.Requests, parsed["metrics"].Requests)
+	require.Equal(t, 1000.0, parsed["metrics"].WallTimeMillis)
+
+	buf.Reset()
+	printMetrics(&buf, out.Formatter{}, s)
+	require.Contains(t, buf.String(), fmt.Sprintf("Kafka requests: %d (0 failed), retries: 1, connections: %d (1 failed), wall time: 1s", s.Requests, s.Connections))
+	require.Contains(t, buf.String(), "Metadata")
+
+	// rpk's retry loops must not need the flag.
+	var off *metrics
+	off.retried()
+}
+
+func TestMetricsRetries(t *testing.T) {
+	m := &metrics{byKey: make(map[int16]*OperationMetrics)}
+	var (
+		failed = kgo.BrokerE2E{ReadErr: errors.New("connection reset")}
+		ok     = kgo.BrokerE2E{}
+		meta   = int16(kmsg.Metadata)
+		apiV   = int16(kmsg.ApiVersions)
+		dial   = &net.OpError{Op: "dial"}
+	)
+
+	// A failure that is returned without another attempt is no retry.
+	m.OnBrokerE2E(kgo.BrokerMetadata{}, meta, failed)
+	m.OnBrokerConnect(kgo.BrokerMetadata{}, 0, nil, dial)
+	require.Zero(t, m.summary(0).Retries)
+
+	// A later attempt retries one failure of the same request type, and
+	// any connection retries a failed connection.
+	m.OnBrokerE2E(kgo.BrokerMetadata{}, apiV, ok)
+	require.Zero(t, m.summary(0).Retries, "different request types are not retries")
+	m.OnBrokerE2E(kgo.BrokerMetadata{}, meta, failed)
+	m.OnBrokerE2E(kgo.BrokerMetadata{}, meta, ok)
+	m.OnBrokerConnect(kgo.BrokerMetadata{}, 0, nil, nil)
+	s := m.summary(0)
+	require.Equal(t, 3, s.Retries)
+	require.Equal(t, 2, s.FailedRequests)
+	require.Equal(t, 1, s.FailedConnections)
+
+	m.OnBrokerE2E(kgo.BrokerMetadata{}, meta, ok)
+	require.Equal(t, 3, m.summary(0).Retries, "every failure is retried at most once")
+}
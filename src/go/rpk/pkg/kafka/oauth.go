@@ -0,0 +1,67 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/oauth"
+)
+
+// oauthTokenCommandTimeout bounds how long we wait for --oauth-token-command.
+const oauthTokenCommandTimeout = 30 * time.Second
+
+// oauthMechanism returns the OAUTHBEARER mechanism for the given (validated)
+// SASL settings. The client asks for a token every time it opens a
+// connection, so a token from a command is refreshed on every reconnect.
+func oauthMechanism(s config.SASL) sasl.Mechanism {
+	if s.TokenCommand != "" {
+		log.Debugf("using SASL mechanism %s with token from command %q", config.SASLMechanismOAuth, s.TokenCommand)
+	} else {
+		log.Debugf("using SASL mechanism %s with token %s", config.SASLMechanismOAuth, redact(s.Token))
+	}
+	return oauth.Oauth(func(ctx context.Context) (oauth.Auth, error) {
+		token, err := oauthToken(ctx, &s)
+		return oauth.Auth{Token: token}, err
+	})
+}
+
+// oauthToken returns the static token, or runs the token command through the
+// shell and returns what it printed to stdout, trimmed.
+func oauthToken(ctx context.Context, s *config.SASL) (string, error) {
+	if s.TokenCommand == "" {
+		return s.Token, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, oauthTokenCommandTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.TokenCommand)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("unable to run oauth token command %q: %v: %s", s.TokenCommand, err, msg)
+		}
+		return "", fmt.Errorf("unable to run oauth token command %q: %v", s.TokenCommand, err)
+	}
+	token := strings.TrimSpace(string(stdout))
+	if token == "" {
+		return "", fmt.Errorf("oauth token command %q did not print a token", s.TokenCommand)
+	}
+	return token, nil
+}
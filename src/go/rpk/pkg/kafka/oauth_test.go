@@ -0,0 +1,58 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthToken(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		sasl   config.SASL
+		exp    string
+		expErr string
+	}{
+		{
+			name: "static token",
+			sasl: config.SASL{Token: "abc"},
+			exp:  "abc",
+		},
+		{
+			name: "command output is trimmed",
+			sasl: config.SASL{TokenCommand: "echo '  abc  '"},
+			exp:  "abc",
+		},
+		{
+			name:   "failing command includes stderr",
+			sasl:   config.SASL{TokenCommand: "echo expired >&2; exit 1"},
+			expErr: "expired",
+		},
+		{
+			name:   "empty output is an error",
+			sasl:   config.SASL{TokenCommand: "true"},
+			expErr: "did not print a token",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := oauthToken(context.Background(), &test.sasl)
+			if test.expErr != "" {
+				require.ErrorContains(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, token)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// PingResult is what a successful Ping learned about the cluster.
+type PingResult struct {
+	// Broker is the broker that answered. If it is a seed broker, the
+	// node ID is negative; Brokers contains the real IDs.
+	Broker    kgo.BrokerMetadata
+	ClusterID string
+	Brokers   []kmsg.MetadataResponseBroker
+	Latency   time.Duration
+}
+
+// Ping issues one metadata request for no topics to any broker, which
+// requires dialing, the TLS handshake, and SASL authentication to succeed.
+// The request does not create topics or change anything in the cluster.
+func Ping(ctx context.Context, cl *kgo.Client) (PingResult, error) {
+	req := kmsg.NewPtrMetadataRequest()
+	req.Topics = []kmsg.MetadataRequestTopic{} // nil means all topics
+	start := time.Now()
+	shard := cl.RequestSharded(ctx, req)[0]
+	if shard.Err != nil {
+		return PingResult{}, shard.Err
+	}
+	resp := shard.Resp.(*kmsg.MetadataResponse)
+	r := PingResult{
+		Broker:  shard.Meta,
+		Brokers: resp.Brokers,
+		Latency: time.Since(start),
+	}
+	if resp.ClusterID != nil {
+		r.ClusterID = *resp.ClusterID
+	}
+	return r, nil
+}
+
+// The categories of ConnErrCategory.
+const (
+	ConnErrAuth    = "authentication failed"
+	ConnErrTLS     = "TLS handshake failed"
+	ConnErrProxy   = "unable to connect through the proxy"
+	ConnErrTimeout = "timed out"
+	ConnErrDial    = "unable to connect"
+	ConnErrRequest = "request failed"
+)
+
+// ConnErrCategory returns a short description of why connecting to the
+// cluster failed with err, for reporting failures of Ping.
+func ConnErrCategory(err error) string {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalidCert      x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		recordHeader     tls.RecordHeaderError
+		proxy            *ProxyError
+		ne               net.Error
+	)
+	switch {
+	case errors.Is(err, kerr.SaslAuthenticationFailed),
+		errors.Is(err, kerr.UnsupportedSaslMechanism),
+		errors.Is(err, kerr.IllegalSaslState):
+		return ConnErrAuth
+	case errors.As(err, &unknownAuthority),
+		errors.As(err, &invalidCert),
+		errors.As(err, &hostname),
+		errors.As(err, &recordHeader):
+		return ConnErrTLS
+	case errors.As(err, &proxy):
+		return ConnErrProxy
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &ne) && ne.Timeout():
+		return ConnErrTimeout
+	case errors.As(err, &ne), isTransientConnErr(err):
+		return ConnErrDial
+	default:
+		return ConnErrRequest
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka/kafkatest"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func TestPing(t *testing.T) {
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		t.Errorf("unexpected %s request", kmsg.NameForKey(req.Key()))
+		return req.ResponseKind()
+	})
+	r, err := Ping(context.Background(), b.Client())
+	require.NoError(t, err)
+	require.Equal(t, "fake", r.ClusterID)
+	require.Len(t, r.Brokers, 1)
+	require.Equal(t, int32(0), r.Brokers[0].NodeID)
+	require.Equal(t, r.Brokers[0].Port, r.Broker.Port)
+}
+
+func TestConnErrCategory(t *testing.T) {
+	for _, test := range []struct {
+		err error
+		exp string
+	}{
+		{kerr.SaslAuthenticationFailed, ConnErrAuth},
+		{fmt.Errorf("wrapped: %w", kerr.UnsupportedSaslMechanism), ConnErrAuth},
+		{x509.UnknownAuthorityError{}, ConnErrTLS},
+		{x509.HostnameError{Host: "foo"}, ConnErrTLS},
+		{&ProxyError{Proxy: "proxy:1080", Err: errors.New("refused")}, ConnErrProxy},
+		{context.DeadlineExceeded, ConnErrTimeout},
+		{syscall.ECONNREFUSED, ConnErrDial},
+		{errors.New("something else"), ConnErrRequest},
+	} {
+		require.Equal(t, test.exp, ConnErrCategory(test.err), "error: %v", test.err)
+	}
+}
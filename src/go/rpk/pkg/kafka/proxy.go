@@ -0,0 +1,285 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// ProxyError is returned when dialing a broker through a proxy fails. If
+// ReachedProxy is false, rpk could not connect to the proxy itself;
+// otherwise, the proxy could not connect to the broker.
+type ProxyError struct {
+	Proxy        string
+	Broker       string
+	ReachedProxy bool
+	Err          error
+}
+
+func (e *ProxyError) Error() string {
+	if !e.ReachedProxy {
+		return fmt.Sprintf("unable to connect to proxy %s: %v", e.Proxy, e.Err)
+	}
+	return fmt.Sprintf("proxy %s was unable to connect to broker %s: %v", e.Proxy, e.Broker, e.Err)
+}
+
+func (e *ProxyError) Unwrap() error { return e.Err }
+
+// Timeout and Temporary make a ProxyError a net.Error, so that failing to
+// connect through a proxy is a connection error like failing to connect
+// directly.
+func (e *ProxyError) Timeout() bool {
+	var ne net.Error
+	return errors.As(e.Err, &ne) && ne.Timeout()
+}
+
+func (*ProxyError) Temporary() bool { return false }
+
+// proxyFunc returns which proxy, if any, to dial a broker address through.
+// The --proxy flag is used for every broker. Otherwise, ALL_PROXY or
+// HTTPS_PROXY (or their lowercase forms) is used, except for brokers matching
+// NO_PROXY and loopback brokers, per Go's usual proxy environment handling.
+// This returns nil if no proxy is configured.
+func proxyFunc(flag string) (func(addr string) (*url.URL, error), error) {
+	if flag != "" {
+		u, err := parseProxyURL(flag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", config.FlagProxy, err)
+		}
+		return func(string) (*url.URL, error) { return u, nil }, nil
+	}
+	env := func(names ...string) string {
+		for _, name := range names {
+			if v := os.Getenv(name); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	cfg := httpproxy.Config{
+		HTTPSProxy: env("ALL_PROXY", "all_proxy", "HTTPS_PROXY", "https_proxy"),
+		NoProxy:    env("NO_PROXY", "no_proxy"),
+	}
+	if cfg.HTTPSProxy == "" {
+		return nil, nil
+	}
+	if _, err := parseProxyURL(cfg.HTTPSProxy); err != nil {
+		return nil, fmt.Errorf("invalid proxy in the environment: %v", err)
+	}
+	fn := cfg.ProxyFunc()
+	return func(addr string) (*url.URL, error) {
+		u, err := fn(&url.URL{Scheme: "https", Host: addr})
+		if u == nil || err != nil {
+			return nil, err
+		}
+		return parseProxyURL(u.String())
+	}, nil
+}
+
+// parseProxyURL parses a socks5:// or http:// proxy URL, defaulting the port
+// to 1080 or 80. A URL without a scheme is an HTTP proxy.
+func parseProxyURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %q has no host", s)
+	}
+	port := "80"
+	switch u.Scheme {
+	case "http":
+	case "socks5", "socks5h":
+		port = "1080"
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q in %q, supported: socks5, http", u.Scheme, s)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, nil
+}
+
+// newDialer returns a dial function for kgo.Dialer that connects to brokers
+// through the proxy chosen by proxyFunc(flag), if any, and then performs the
+// TLS handshake if tc is non-nil. With a proxy, the handshake is with the
+// broker, through the proxy tunnel. This returns nil if there is neither a
+// proxy nor TLS, in which case kgo's default dialer is used. Every dial is
+// bounded by timeout.
+func newDialer(flag string, tc *tls.Config, timeout time.Duration) (dialFunc, error) {
+	proxyFor, err := proxyFunc(flag)
+	if err != nil || proxyFor == nil && tc == nil {
+		return nil, err
+	}
+	direct := &net.Dialer{Timeout: timeout}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		var (
+			u    *url.URL
+			conn net.Conn
+			err  error
+		)
+		if proxyFor != nil {
+			if u, err = proxyFor(addr); err != nil {
+				return nil, err
+			}
+		}
+		if u == nil {
+			conn, err = direct.DialContext(ctx, network, addr)
+		} else {
+			log.Debugf("dialing broker %s through proxy %s", addr, u.Redacted())
+			conn, err = dialThroughProxy(ctx, direct.DialContext, u, network, addr)
+		}
+		if err != nil || tc == nil {
+			return conn, err
+		}
+
+		// As with kgo.DialTLSConfig, we default the server name to the
+		// broker's host.
+		cfg := tc.Clone()
+		if cfg.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("unable to split host:port for dialing: %w", err)
+			}
+			cfg.ServerName = host
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, config.ExplainTLSError(err, config.FlagTLSServerName)
+		}
+		return tlsConn, nil
+	}, nil
+}
+
+// dialThroughProxy connects to addr through the proxy at u, returning a
+// *ProxyError on failure.
+func dialThroughProxy(ctx context.Context, forward dialFunc, u *url.URL, network, addr string) (net.Conn, error) {
+	proxyErr := func(reached bool, err error) error {
+		return &ProxyError{Proxy: u.Redacted(), Broker: addr, ReachedProxy: reached, Err: err}
+	}
+
+	// We dial the proxy ourselves so that we can tell a proxy that is
+	// unreachable from a proxy that cannot reach the broker.
+	var dialErr error
+	trackedForward := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := forward(ctx, network, addr)
+		dialErr = err
+		return conn, err
+	}
+
+	if u.Scheme == "http" {
+		return dialHTTPConnect(ctx, trackedForward, u, addr, proxyErr)
+	}
+
+	var auth *proxy.Auth
+	if u.User != nil {
+		auth = &proxy.Auth{User: u.User.Username()}
+		auth.Password, _ = u.User.Password()
+	}
+	d, err := proxy.SOCKS5("tcp", u.Host, auth, forwardDialer(trackedForward))
+	if err != nil {
+		return nil, proxyErr(false, err)
+	}
+	conn, err := d.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, proxyErr(dialErr == nil, err)
+	}
+	return conn, nil
+}
+
+// forwardDialer adapts a dialFunc to a proxy.ContextDialer.
+type forwardDialer dialFunc
+
+func (f forwardDialer) Dial(network, addr string) (net.Conn, error) {
+	return f(context.Background(), network, addr)
+}
+
+func (f forwardDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return f(ctx, network, addr)
+}
+
+// dialHTTPConnect opens a tunnel to addr with an HTTP CONNECT request.
+func dialHTTPConnect(
+	ctx context.Context, forward dialFunc, u *url.URL, addr string, proxyErr func(bool, error) error,
+) (net.Conn, error) {
+	conn, err := forward(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, proxyErr(false, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, proxyErr(false, err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, proxyErr(false, fmt.Errorf("unable to read CONNECT response: %w", err))
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, proxyErr(true, fmt.Errorf("CONNECT responded %s", resp.Status))
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{conn, br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a conn whose first bytes were read into a buffer.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) { return c.r.Read(b) }
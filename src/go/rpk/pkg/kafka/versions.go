@@ -0,0 +1,252 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// APIVersion is the range of versions that a broker supports for one API,
+// alongside the range that rpk supports.
+type APIVersion struct {
+	Key       int16  `json:"key" yaml:"key"`
+	Name      string `json:"name" yaml:"name"`
+	BrokerMin int16  `json:"brokerMin" yaml:"brokerMin"`
+	BrokerMax int16  `json:"brokerMax" yaml:"brokerMax"`
+
+	// RpkMin and RpkMax are -1 if rpk does not know the API.
+	RpkMin int16 `json:"rpkMin" yaml:"rpkMin"`
+	RpkMax int16 `json:"rpkMax" yaml:"rpkMax"`
+}
+
+// Known returns whether rpk knows the API.
+func (v APIVersion) Known() bool { return v.RpkMax >= 0 }
+
+// Compatible returns whether the broker and rpk have a version in common.
+func (v APIVersion) Compatible() bool {
+	return v.Known() && v.BrokerMax >= v.RpkMin && v.BrokerMin <= v.RpkMax
+}
+
+func (v APIVersion) String() string {
+	return fmt.Sprintf("the broker supports %s v%d to v%d, but rpk supports v%d to v%d", v.Name, v.BrokerMin, v.BrokerMax, v.RpkMin, v.RpkMax)
+}
+
+// FetchAPIVersions issues one ApiVersions request to any broker and returns
+// every API the broker supports, sorted by key.
+func FetchAPIVersions(ctx context.Context, cl *kgo.Client) ([]APIVersion, error) {
+	resp, err := kmsg.NewPtrApiVersionsRequest().RequestWith(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	return apiVersions(resp), nil
+}
+
+// clientVersions caches the API versions that clientAPIVersions fetched, by
+// the *kgo.Client they were fetched with.
+var clientVersions sync.Map
+
+// clientAPIVersions is FetchAPIVersions for version checks: the versions are
+// fetched once per client and then reused, so that a command that checks
+// several features asks the broker once. A failed fetch is not cached.
+func clientAPIVersions(ctx context.Context, cl *kgo.Client) ([]APIVersion, error) {
+	if vs, ok := clientVersions.Load(cl); ok {
+		return vs.([]APIVersion), nil
+	}
+	vs, err := FetchAPIVersions(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+	clientVersions.Store(cl, vs)
+	return vs, nil
+}
+
+func apiVersions(resp *kmsg.ApiVersionsResponse) []APIVersion {
+	versions := make([]APIVersion, 0, len(resp.ApiKeys))
+	for _, k := range resp.ApiKeys {
+		v := APIVersion{
+			Key:       k.ApiKey,
+			Name:      kmsg.NameForKey(k.ApiKey),
+			BrokerMin: k.MinVersion,
+			BrokerMax: k.MaxVersion,
+			RpkMin:    -1,
+			RpkMax:    -1,
+		}
+		if req := kmsg.RequestForKey(k.ApiKey); req != nil {
+			v.RpkMin, v.RpkMax = 0, req.MaxVersion()
+		} else {
+			v.Name = fmt.Sprintf("Unknown(%d)", k.ApiKey)
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Key < versions[j].Key })
+	return versions
+}
+
+// CheckACLVersions returns an error naming every API of FeatureACLs that the
+// broker does not support, or supports only at versions that rpk cannot use,
+// or nil if all ACL APIs are compatible. Pattern types are not checked here:
+// requests that use them are gated by CheckFeature instead.
+func CheckACLVersions(versions []APIVersion) error {
+	byKey := make(map[int16]APIVersion, len(versions))
+	for _, v := range versions {
+		byKey[v.Key] = v
+	}
+	aclKeys := make([]kmsg.Key, 0, len(featureGates[FeatureACLs].versions))
+	for k := range featureGates[FeatureACLs].versions {
+		aclKeys = append(aclKeys, k)
+	}
+	sort.Slice(aclKeys, func(i, j int) bool { return aclKeys[i] < aclKeys[j] })
+	var problems []string
+	for _, k := range aclKeys {
+		v, ok := byKey[int16(k)]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("the broker does not support %s", k.Name()))
+		case !v.Compatible():
+			problems = append(problems, v.String())
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("broker ACL API versions are incompatible with rpk, ACL commands may fail or behave unexpectedly: %s; use an rpk version that matches the broker", strings.Join(problems, "; "))
+}
+
+// Feature is something rpk does that brokers support only from a certain
+// version of the APIs it uses on. Older brokers do not reject such requests
+// but silently drop the fields they do not know, so rpk checks the broker's
+// versions first and fails before sending anything.
+type Feature int
+
+const (
+	// FeatureACLs is using the ACL APIs at all, as every 'rpk acl'
+	// command does.
+	FeatureACLs Feature = iota
+	// FeaturePrefixedACLs is creating, listing, or deleting ACLs with the
+	// prefixed pattern type.
+	FeaturePrefixedACLs
+	// FeatureMatchACLFilters is listing or deleting ACLs with the match
+	// pattern type.
+	FeatureMatchACLFilters
+)
+
+// featureGate is the minimum version of every API that supports a feature.
+type featureGate struct {
+	name     string
+	versions map[kmsg.Key]int16
+}
+
+// featureGates is the one table of what every feature requires. Requests of
+// an API that is not listed for a feature are not gated. Pattern types were
+// added in v1 of every ACL API (KIP-290); a broker that only supports v0
+// treats every ACL as literal, which is only wrong for the features that
+// need v1.
+var featureGates = map[Feature]featureGate{
+	FeatureACLs: {
+		name: "ACLs",
+		versions: map[kmsg.Key]int16{
+			kmsg.CreateACLs:   0,
+			kmsg.DescribeACLs: 0,
+			kmsg.DeleteACLs:   0,
+		},
+	},
+	FeaturePrefixedACLs: {
+		name: "prefixed ACL pattern types",
+		versions: map[kmsg.Key]int16{
+			kmsg.CreateACLs:   1,
+			kmsg.DescribeACLs: 1,
+			kmsg.DeleteACLs:   1,
+		},
+	},
+	FeatureMatchACLFilters: {
+		name: "the match ACL pattern type",
+		versions: map[kmsg.Key]int16{
+			kmsg.DescribeACLs: 1,
+			kmsg.DeleteACLs:   1,
+		},
+	},
+}
+
+// CheckFeature returns an error if the broker, per its API versions, does
+// not support the feature in requests of the key API.
+func CheckFeature(versions []APIVersion, key kmsg.Key, f Feature) error {
+	g, ok := featureGates[f]
+	if !ok {
+		return nil
+	}
+	min, gated := g.versions[key]
+	if !gated {
+		return nil
+	}
+	for _, v := range versions {
+		if v.Key != int16(key) {
+			continue
+		}
+		if v.BrokerMax >= min {
+			return nil
+		}
+		return fmt.Errorf("your broker does not support %s (requires %s v%d or newer, but the broker supports at most v%d)", g.name, key.Name(), min, v.BrokerMax)
+	}
+	return fmt.Errorf("your broker does not support %s (requires %s v%d or newer, but the broker does not support %s)", g.name, key.Name(), min, key.Name())
+}
+
+// aclPatternFeature returns the feature that ACLs or filters with the pattern
+// type require, if any.
+func aclPatternFeature(pattern kmsg.ACLResourcePatternType) (Feature, bool) {
+	switch pattern {
+	case kmsg.ACLResourcePatternTypePrefixed:
+		return FeaturePrefixedACLs, true
+	case kmsg.ACLResourcePatternTypeMatch:
+		return FeatureMatchACLFilters, true
+	default:
+		return 0, false
+	}
+}
+
+// checkACLPatterns returns an error if the broker does not support every
+// pattern type in requests of the key API. Failing to fetch the versions is
+// not an error here: the request itself surfaces any connection problem.
+func checkACLPatterns(versions func() ([]APIVersion, error), key kmsg.Key, patterns ...kmsg.ACLResourcePatternType) error {
+	var (
+		fetched bool
+		vs      []APIVersion
+	)
+	for _, pattern := range patterns {
+		f, gated := aclPatternFeature(pattern)
+		if !gated {
+			continue
+		}
+		if !fetched {
+			var err error
+			vs, err = versions()
+			if err != nil {
+				log.Debugf("unable to check broker support of ACL pattern types: %v", err)
+				return nil
+			}
+			fetched = true
+		}
+		if err := CheckFeature(vs, key, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/config"
+	"github.com/redpanda-data/redpanda/src/go/rpk/pkg/kafka/kafkatest"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func aclVersionsResp(min, max int16, keys ...kmsg.Key) *kmsg.ApiVersionsResponse {
+	resp := kmsg.NewPtrApiVersionsResponse()
+	for _, k := range keys {
+		resp.ApiKeys = append(resp.ApiKeys, kmsg.ApiVersionsResponseApiKey{ApiKey: int16(k), MinVersion: min, MaxVersion: max})
+	}
+	return resp
+}
+
+func TestAPIVersions(t *testing.T) {
+	resp := aclVersionsResp(0, 2, kmsg.DescribeACLs, kmsg.Produce)
+	resp.ApiKeys = append(resp.ApiKeys, kmsg.ApiVersionsResponseApiKey{ApiKey: 10000, MinVersion: 0, MaxVersion: 1})
+
+	versions := apiVersions(resp)
+	require.Equal(t, []APIVersion{
+		{Key: 0, Name: "Produce", BrokerMin: 0, BrokerMax: 2, RpkMin: 0, RpkMax: kmsg.NewPtrProduceRequest().MaxVersion()},
+		{Key: 29, Name: "DescribeACLs", BrokerMin: 0, BrokerMax: 2, RpkMin: 0, RpkMax: kmsg.NewPtrDescribeACLsRequest().MaxVersion()},
+		{Key: 10000, Name: "Unknown(10000)", BrokerMin: 0, BrokerMax: 1, RpkMin: -1, RpkMax: -1},
+	}, versions)
+	require.True(t, versions[1].Compatible())
+	require.False(t, versions[2].Known())
+	require.False(t, versions[2].Compatible())
+}
+
+func TestCheckACLVersions(t *testing.T) {
+	all := []kmsg.Key{kmsg.CreateACLs, kmsg.DescribeACLs, kmsg.DeleteACLs}
+	for _, test := range []struct {
+		name   string
+		resp   *kmsg.ApiVersionsResponse
+		expErr []string
+	}{
+		{
+			name: "compatible",
+			resp: aclVersionsResp(0, 3, all...),
+		},
+		{
+			// Only pattern types need v1, and they are gated
+			// per request by CheckFeature.
+			name: "broker without pattern types",
+			resp: aclVersionsResp(0, 0, all...),
+		},
+		{
+			name:   "broker newer than rpk",
+			resp:   aclVersionsResp(100, 101, kmsg.CreateACLs, kmsg.DescribeACLs, kmsg.DeleteACLs),
+			expErr: []string{"the broker supports CreateACLs v100 to v101"},
+		},
+		{
+			name:   "missing API",
+			resp:   aclVersionsResp(0, 3, kmsg.CreateACLs, kmsg.DescribeACLs),
+			expErr: []string{"the broker does not support DeleteACLs"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckACLVersions(apiVersions(test.resp))
+			if len(test.expErr) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, exp := range test.expErr {
+				require.Contains(t, err.Error(), exp)
+			}
+		})
+	}
+}
+
+func TestCheckFeature(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		resp   *kmsg.ApiVersionsResponse
+		key    kmsg.Key
+		f      Feature
+		expErr string
+	}{
+		{
+			name: "supported",
+			resp: aclVersionsResp(0, 3, kmsg.CreateACLs),
+			key:  kmsg.CreateACLs,
+			f:    FeaturePrefixedACLs,
+		},
+		{
+			name:   "too old",
+			resp:   aclVersionsResp(0, 0, kmsg.CreateACLs),
+			key:    kmsg.CreateACLs,
+			f:      FeaturePrefixedACLs,
+			expErr: "your broker does not support prefixed ACL pattern types (requires CreateACLs v1 or newer, but the broker supports at most v0)",
+		},
+		{
+			name:   "missing API",
+			resp:   aclVersionsResp(0, 3, kmsg.CreateACLs),
+			key:    kmsg.DescribeACLs,
+			f:      FeatureMatchACLFilters,
+			expErr: "your broker does not support the match ACL pattern type (requires DescribeACLs v1 or newer, but the broker does not support DescribeACLs)",
+		},
+		{
+			name: "API not gated for the feature",
+			resp: aclVersionsResp(0, 0, kmsg.CreateACLs),
+			key:  kmsg.CreateACLs,
+			f:    FeatureMatchACLFilters,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckFeature(apiVersions(test.resp), test.key, test.f)
+			if test.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.expErr)
+		})
+	}
+}
+
+func TestACLPatternsGated(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		handled []string
+	)
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, kmsg.NameForKey(req.Key()))
+		switch req := req.(type) {
+		case *kmsg.CreateACLsRequest:
+			resp := req.ResponseKind().(*kmsg.CreateACLsResponse)
+			for range req.Creations {
+				resp.Results = append(resp.Results, kmsg.NewCreateACLsResponseResult())
+			}
+			return resp
+		default:
+			return req.ResponseKind()
+		}
+	})
+	for _, k := range []kmsg.Key{kmsg.CreateACLs, kmsg.DescribeACLs, kmsg.DeleteACLs} {
+		b.LimitVersion(k, 0)
+	}
+	cl := b.Client()
+	ctx := context.Background()
+
+	creation := func(pattern kmsg.ACLResourcePatternType) kmsg.CreateACLsRequestCreation {
+		c := kmsg.NewCreateACLsRequestCreation()
+		c.ResourceType = kmsg.ACLResourceTypeTopic
+		c.ResourceName = "orders-"
+		c.ResourcePatternType = pattern
+		c.Principal = "User:a"
+		c.Host = "*"
+		c.Operation = kmsg.ACLOperationRead
+		c.PermissionType = kmsg.ACLPermissionTypeAllow
+		return c
+	}
+
+	// One prefixed ACL fails the whole batch before anything is sent.
+	_, err := CreateACLs(ctx, cl, []kmsg.CreateACLsRequestCreation{
+		creation(kmsg.ACLResourcePatternTypeLiteral),
+		creation(kmsg.ACLResourcePatternTypePrefixed),
+	})
+	require.EqualError(t, err, "your broker does not support prefixed ACL pattern types (requires CreateACLs v1 or newer, but the broker supports at most v0)")
+	// The versions are fetched once for the client, not once per check.
+	apiVersionsSent := b.Requests(kmsg.ApiVersions)
+	_, err = ExistingACLs(ctx, cl, []kmsg.CreateACLsRequestCreation{creation(kmsg.ACLResourcePatternTypePrefixed)})
+	require.ErrorContains(t, err, "requires DescribeACLs v1")
+
+	results, err := CreateACLs(ctx, cl, []kmsg.CreateACLsRequestCreation{creation(kmsg.ACLResourcePatternTypeLiteral)})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+
+	_, err = ListACLs(ctx, cl, ACLFilter{Topics: []string{"orders-2024"}, PatternType: kmsg.ACLResourcePatternTypeMatch})
+	require.ErrorContains(t, err, "your broker does not support the match ACL pattern type (requires DescribeACLs v1")
+	_, err = DeleteACLs(ctx, cl, ACLFilter{Topics: []string{"orders-"}, PatternType: kmsg.ACLResourcePatternTypePrefixed})
+	require.ErrorContains(t, err, "your broker does not support prefixed ACL pattern types (requires DeleteACLs v1")
+	require.Equal(t, apiVersionsSent, b.Requests(kmsg.ApiVersions), "every later check must reuse the fetched versions")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"CreateACLs"}, handled, "only the supported request should reach the broker")
+}
+
+func TestCheckBrokerACLVersions(t *testing.T) {
+	b := kafkatest.NewBroker(t, func(req kmsg.Request) kmsg.Response { return req.ResponseKind() })
+	cl := b.Client()
+
+	p := new(config.Params)
+	require.NoError(t, CheckBrokerACLVersions(p, cl), "compatible versions")
+
+	// A broker without a DescribeACLs version in common with rpk. The
+	// versions are cached per client, so we need a new one.
+	b.LimitVersion(kmsg.DescribeACLs, -1)
+	require.NoError(t, CheckBrokerACLVersions(p, b.Client()), "an incompatibility is only a warning by default")
+
+	p.FailIncompatibleVersions = true
+	err := CheckBrokerACLVersions(p, b.Client())
+	require.ErrorContains(t, err, "DescribeACLs")
+	require.ErrorContains(t, err, "(--fail-incompatible-versions)")
+}
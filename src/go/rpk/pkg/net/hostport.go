@@ -30,6 +30,26 @@ func ParseHostMaybeScheme(h string) (scheme, host string, err error) {
 	return scheme, host, nil
 }
 
+// ParseBroker parses a Kafka broker address from the given input: an optional
+// scheme, a required valid hostname, and an optional port. Kafka addresses do
+// not have a scheme, so any scheme is dropped, and a missing port is replaced
+// with the given default. IPv6 hosts must be wrapped in brackets.
+//
+// This returns the host joined with the port, i.e. "[::1]:9092".
+func ParseBroker(b string, def int) (string, error) {
+	_, host, port, err := splitSchemeHostPort(b)
+	if err != nil {
+		return "", err
+	}
+	if port == "" {
+		return host + ":" + strconv.Itoa(def), nil
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return "", fmt.Errorf("invalid port %q in host %q", port, b)
+	}
+	return host + ":" + port, nil
+}
+
 // SplitHostPortDefault splits h into its host and port parts and returns the
 // port as an int. If the host has no port, the returns the default port.
 //
@@ -64,7 +84,7 @@ func splitSchemeHostPort(h string) (scheme, host, port string, err error) {
 		return
 	}
 	scheme, host, port = m[1], m[2], m[3]
-	if !isDomain(host) && !isIP(host) {
+	if !IsDomain(host) && !isIP(host) {
 		setErr()
 		return
 	}
@@ -92,7 +112,7 @@ func splitSchemeHostPort(h string) (scheme, host, port string, err error) {
 //   - index 2: the host
 //   - index 3: the port, if present
 //
-// We then validate the host against isDomain / net.ParseIP.
+// We then validate the host against IsDomain / net.ParseIP.
 //
 // For schemes, we relax RFC3986 section 3.1 by also allowing underscores after
 // the first alphabetic character. This allows us to parse "PLAINTEXT_HOST",
@@ -100,6 +120,8 @@ func splitSchemeHostPort(h string) (scheme, host, port string, err error) {
 // https://datatracker.ietf.org/doc/html/rfc3986#section-3.1
 var schemeHostPortRe = regexp.MustCompile(`^(?:([a-zA-Z][a-zA-Z0-9+._-]*)://)?(.*?)(?::(\d+))?(?:/)?$`)
 
+// IsDomain returns whether d is a valid domain name.
+//
 // https://serverfault.com/a/638270
 // https://datatracker.ietf.org/doc/html/rfc3986#section-3.2.2
 // https://stackoverflow.com/questions/9071279/number-in-the-top-level-domain
@@ -114,7 +136,7 @@ var schemeHostPortRe = regexp.MustCompile(`^(?:([a-zA-Z][a-zA-Z0-9+._-]*)://)?(.
 // resolve single label hosts, as well as underscores (which are technically
 // only valid in DNS names, not hostnames). However, we do require that the
 // final label must start with a letter and be more than 1 byte long.
-func isDomain(d string) bool {
+func IsDomain(d string) bool {
 	if len(d) > 255 {
 		return false
 	}
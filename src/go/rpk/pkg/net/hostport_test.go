@@ -1,6 +1,7 @@
 package net
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -134,6 +135,46 @@ func TestParseHostMaybeScheme(t *testing.T) {
 	}
 }
 
+func TestParseBroker(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		input string
+
+		exp    string
+		expErr bool
+	}{
+		{"ipv4 with port", "10.0.0.1:9093", "10.0.0.1:9093", false},
+		{"ipv4 without port", "10.0.0.1", "10.0.0.1:9092", false},
+		{"ipv6 with port", "[::1]:9093", "[::1]:9093", false},
+		{"ipv6 without port", "[fe80::1]", "[fe80::1]:9092", false},
+		{"hostname only", "redpanda", "redpanda:9092", false},
+		{"hostname with port", "redpanda.local:19092", "redpanda.local:19092", false},
+		{"scheme is dropped", "redpanda://foo.com:9093", "foo.com:9093", false},
+		{"scheme without port", "PLAINTEXT://foo.com", "foo.com:9092", false},
+		{"ipv6 with scheme", "redpanda://[::1]", "[::1]:9092", false},
+
+		{"unbracketed ipv6", "::1", "", true},
+		{"unbracketed ipv6 with port", "fe80::1:9092", "", true},
+		{"double colon", "foo::9092", "", true},
+		{"empty port", "foo:", "", true},
+		{"port too large", "foo:65536", "", true},
+		{"port zero", "foo:0", "", true},
+		{"empty", "", "", true},
+		{"garbage", "foo bar", "", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseBroker(test.input, 9092)
+			if test.expErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), fmt.Sprintf("%q", test.input))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.exp, got)
+		})
+	}
+}
+
 func TestIsDomain(t *testing.T) {
 	for _, test := range []struct {
 		name     string
@@ -163,7 +204,7 @@ func TestIsDomain(t *testing.T) {
 		{"the entire domain cannot be more than 255 characters", strings.Repeat("a.", 125) + "abcdef", false}, // 250 + 6
 	} {
 		t.Run(test.name, func(t *testing.T) {
-			got := isDomain(test.input)
+			got := IsDomain(test.input)
 			if got != test.expValid {
 				t.Errorf("input %q: got valid? %v, expected? %v",
 					test.input, got, test.expValid)
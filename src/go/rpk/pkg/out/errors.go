@@ -0,0 +1,140 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+// StructuredError is what rpk writes to stderr, as one line of JSON, when
+// dying with --format json or jsonl. The schema is stable: fields may be
+// added, but existing fields are never renamed or removed.
+//
+//	{
+//	  "error": {
+//	    "code": 4,
+//	    "category": "partial",
+//	    "message": "1 of 2 ACLs failed to be created",
+//	    "details": [{"item": {...}, "error": "POLICY_VIOLATION"}]
+//	  }
+//	}
+type StructuredError struct {
+	Error ErrorInfo `json:"error"`
+}
+
+// ErrorInfo describes why a command failed.
+type ErrorInfo struct {
+	// Code is the exit code rpk exits with.
+	Code int `json:"code"`
+	// Category is the name of the exit code, per ExitCategory.
+	Category string `json:"category"`
+	// Message is the message that rpk prints in text mode.
+	Message string `json:"message"`
+	// KafkaError is the name of the Kafka error code the cluster
+	// returned, such as TOPIC_AUTHORIZATION_FAILED, if any. Batch
+	// failures have an error per detail instead.
+	KafkaError string `json:"kafkaError,omitempty"`
+	// Details are the failed items of a batch operation, if any.
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail is one failed item of a batch operation, such as one ACL
+// binding of an ACL creation. Item is what the command prints for the item
+// with --format json, and Error is the error for the item, which is the
+// Kafka error name if the cluster returned one.
+type ErrorDetail struct {
+	Item  interface{} `json:"item"`
+	Error string      `json:"error"`
+}
+
+// ExitCategory returns the category of an exit code in structured errors:
+// "error", "connection", "server", "partial", or "interrupted".
+func ExitCategory(code int) string {
+	switch code {
+	case ExitConnection:
+		return "connection"
+	case ExitServer:
+		return "server"
+	case ExitPartial:
+		return "partial"
+	case ExitInterrupted:
+		return "interrupted"
+	default:
+		return "error"
+	}
+}
+
+var structuredErrors atomic.Bool
+
+// SetErrorFormatter switches how rpk writes errors when dying: as a
+// StructuredError if f is json or jsonl, so that scripts parsing the output
+// can parse errors too, or as plain text otherwise.
+func SetErrorFormatter(f Formatter) {
+	structuredErrors.Store(f.Kind == "json" || f.Kind == "jsonl")
+}
+
+// kafkaErrorName returns the name of the Kafka error code in err's chain, or
+// an empty string if err is not a Kafka error.
+func kafkaErrorName(err error) string {
+	if ke := (*kerr.Error)(nil); errors.As(err, &ke) {
+		return ke.Message
+	}
+	return ""
+}
+
+// writeStructuredError writes a StructuredError as one line of JSON to w.
+func writeStructuredError(w io.Writer, code int, msg string, err error, details []ErrorDetail) {
+	b, merr := json.Marshal(StructuredError{ErrorInfo{
+		Code:       code,
+		Category:   ExitCategory(code),
+		Message:    msg,
+		KafkaError: kafkaErrorName(err),
+		Details:    details,
+	}})
+	if merr != nil {
+		// An item that cannot be marshaled must not lose the
+		// error, so we fall back to the text message.
+		fmt.Fprintln(w, msg)
+		return
+	}
+	fmt.Fprintf(w, "%s\n", b)
+}
+
+// firstErr returns the first error in args, which is the error that
+// MaybeDie and friends format into the message.
+func firstErr(args []interface{}) error {
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExitFailed exits with code after a batch operation in which some items
+// failed, once the command has printed every result. The message, if
+// non-empty, is written to stderr; with structured errors, the message and
+// the failed items are written as a StructuredError.
+func ExitFailed(code int, msg string, details []ErrorDetail) {
+	switch {
+	case structuredErrors.Load():
+		writeStructuredError(os.Stderr, code, msg, nil, details)
+	case msg != "":
+		fmt.Fprintf(os.Stderr, "\n%s\n", msg)
+	}
+	ExitWith(code)
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestWriteStructuredError(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		code    int
+		msg     string
+		err     error
+		details []ErrorDetail
+		exp     string
+	}{
+		{
+			name: "plain",
+			code: ExitError,
+			msg:  "invalid flag",
+			err:  errors.New("invalid flag"),
+			exp:  `{"error":{"code":1,"category":"error","message":"invalid flag"}}`,
+		},
+		{
+			name: "kafka error",
+			code: ExitServer,
+			msg:  "unable to create: TOPIC_AUTHORIZATION_FAILED",
+			err:  fmt.Errorf("unable to create: %w", kerr.TopicAuthorizationFailed),
+			exp:  `{"error":{"code":3,"category":"server","message":"unable to create: TOPIC_AUTHORIZATION_FAILED","kafkaError":"TOPIC_AUTHORIZATION_FAILED"}}`,
+		},
+		{
+			name: "details",
+			code: ExitPartial,
+			msg:  "1 of 2 ACLs failed to be created",
+			details: []ErrorDetail{{
+				Item:  map[string]string{"principal": "User:a"},
+				Error: "POLICY_VIOLATION",
+			}},
+			exp: `{"error":{"code":4,"category":"partial","message":"1 of 2 ACLs failed to be created","details":[{"item":{"principal":"User:a"},"error":"POLICY_VIOLATION"}]}}`,
+		},
+		{
+			name: "unmarshalable detail",
+			code: ExitServer,
+			msg:  "failed",
+			details: []ErrorDetail{{
+				Item: make(chan int),
+			}},
+			exp: "failed",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var b bytes.Buffer
+			writeStructuredError(&b, test.code, test.msg, test.err, test.details)
+			require.Equal(t, test.exp+"\n", b.String())
+		})
+	}
+}
+
+func TestExitCategory(t *testing.T) {
+	for code, exp := range map[int]string{
+		ExitError:       "error",
+		ExitConnection:  "connection",
+		ExitServer:      "server",
+		ExitPartial:     "partial",
+		ExitInterrupted: "interrupted",
+		99:              "error",
+	} {
+		require.Equal(t, exp, ExitCategory(code), "code %d", code)
+	}
+}
+
+func TestFirstErr(t *testing.T) {
+	err := errors.New("boom")
+	require.Equal(t, err, firstErr([]interface{}{"topic", 3, err, errors.New("other")}))
+	require.Nil(t, firstErr([]interface{}{"topic", 3}))
+}
@@ -0,0 +1,161 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+// Exit codes that rpk uses when dying, so that scripts can tell apart why a
+// command failed.
+const (
+	// ExitError is for usage and validation errors, and for any error
+	// that does not fall in a more specific category.
+	ExitError = 1
+	// ExitConnection is for failures to connect or authenticate to the
+	// cluster.
+	ExitConnection = 2
+	// ExitServer is for operations that the cluster rejected.
+	ExitServer = 3
+	// ExitPartial is for batch operations where some, but not all, of the
+	// operations in the batch failed.
+	ExitPartial = 4
+	// ExitInterrupted is for commands that were interrupted with SIGINT
+	// or SIGTERM, following the shell convention of 128+SIGINT.
+	ExitInterrupted = 130
+)
+
+// ErrInterrupted is the error for requests that were canceled because rpk
+// received SIGINT or SIGTERM.
+var ErrInterrupted = errors.New("interrupted")
+
+// ExitCodeError is an error that carries the exit code rpk should exit with.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string { return e.Err.Error() }
+func (e *ExitCodeError) Unwrap() error { return e.Err }
+
+// ErrWithCode returns err annotated with the exit code to use when dying with
+// it, or nil if err is nil.
+func ErrWithCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitCodeError{code, err}
+}
+
+// ExitCode returns the exit code rpk exits with for err:
+//
+//   - the code of any ExitCodeError in the error chain
+//   - ExitInterrupted for ErrInterrupted
+//   - ExitConnection for network errors, TLS handshake and certificate
+//     verification failures, and SASL authentication failures
+//   - ExitServer for any other Kafka error returned by the cluster
+//   - ExitError otherwise
+//
+// ExitCode returns 0 for a nil error.
+func ExitCode(err error) int {
+	var (
+		ce *ExitCodeError
+		ke *kerr.Error
+		ne net.Error
+
+		unknownAuthority x509.UnknownAuthorityError
+		invalidCert      x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		recordHeader     tls.RecordHeaderError
+	)
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &ce):
+		return ce.Code
+	case errors.Is(err, ErrInterrupted):
+		return ExitInterrupted
+	case errors.Is(err, kerr.SaslAuthenticationFailed),
+		errors.Is(err, kerr.UnsupportedSaslMechanism),
+		errors.Is(err, kerr.IllegalSaslState):
+		return ExitConnection
+	case errors.As(err, &ke):
+		return ExitServer
+	case errors.As(err, &ne),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &unknownAuthority),
+		errors.As(err, &invalidCert),
+		errors.As(err, &hostname),
+		errors.As(err, &recordHeader):
+		return ExitConnection
+	default:
+		return ExitError
+	}
+}
+
+// DieCode is Die, but exits with the given code. With structured errors, see
+// SetErrorFormatter, the message is written as a StructuredError, with the
+// Kafka error of the first error in args.
+func DieCode(code int, msg string, args ...interface{}) {
+	text := fmt.Sprintf(msg+"\n", args...)
+	if structuredErrors.Load() {
+		writeStructuredError(os.Stderr, code, strings.TrimSuffix(text, "\n"), firstErr(args), nil)
+	} else {
+		os.Stderr.WriteString(text)
+	}
+	ExitWith(code)
+}
+
+var (
+	atExitMu sync.Mutex
+	atExit   []func()
+)
+
+// AtExit registers fn to run when rpk exits through this package or
+// ExitWith, such as to print a summary after a command. Functions run once,
+// in the order they were registered. They do not run if rpk is killed or
+// interrupted without being Interruptible.
+func AtExit(fn func()) {
+	atExitMu.Lock()
+	defer atExitMu.Unlock()
+	atExit = append(atExit, fn)
+}
+
+// RunAtExit runs the functions registered with AtExit without exiting, for
+// when rpk returns from main normally.
+func RunAtExit() {
+	atExitMu.Lock()
+	fns := atExit
+	atExit = nil
+	atExitMu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// ExitWith runs the functions registered with AtExit and exits with code.
+// Commands must exit through this rather than os.Exit for AtExit to work.
+func ExitWith(code int) {
+	RunAtExit()
+	os.Exit(code)
+}
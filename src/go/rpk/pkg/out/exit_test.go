@@ -0,0 +1,59 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		exp  int
+	}{
+		{"nil", nil, 0},
+		{"plain", errors.New("invalid flag"), ExitError},
+		{"explicit code", ErrWithCode(ExitPartial, errors.New("some failed")), ExitPartial},
+		{"wrapped explicit code", fmt.Errorf("wrapped: %w", ErrWithCode(ExitServer, errors.New("failed"))), ExitServer},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, ExitConnection},
+		{"wrapped connection refused", fmt.Errorf("unable to connect: %w", syscall.ECONNREFUSED), ExitConnection},
+		{"unknown certificate authority", fmt.Errorf("unable to dial: %w", x509.UnknownAuthorityError{}), ExitConnection},
+		{"certificate hostname mismatch", &net.OpError{Op: "remote error", Err: x509.HostnameError{Host: "broker"}}, ExitConnection},
+		{"not tls", tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, ExitConnection},
+		{"sasl auth failure", kerr.SaslAuthenticationFailed, ExitConnection},
+		{"server error", kerr.TopicAuthorizationFailed, ExitServer},
+		{"wrapped server error", fmt.Errorf("unable to create: %w", kerr.InvalidRequest), ExitServer},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.exp, ExitCode(test.err))
+		})
+	}
+	require.Nil(t, ErrWithCode(ExitServer, nil))
+}
+
+func TestRunAtExit(t *testing.T) {
+	var ran []int
+	AtExit(func() { ran = append(ran, 1) })
+	AtExit(func() { ran = append(ran, 2) })
+	RunAtExit()
+	require.Equal(t, []int{1, 2}, ran)
+	RunAtExit()
+	require.Equal(t, []int{1, 2}, ran, "functions run once")
+}
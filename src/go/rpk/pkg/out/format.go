@@ -0,0 +1,139 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatKinds are the output formats a Formatter supports.
+var FormatKinds = []string{"text", "json", "jsonl", "yaml"}
+
+// Formatter formats command output in a machine readable format, as chosen by
+// the user with --format. The default "text" kind is left to the command
+// itself, which usually prints a table.
+//
+// Structured output is written to stdout. Errors are always written to
+// stderr: as a StructuredError for json and jsonl, once the formatter is set
+// with SetErrorFormatter, and as plain text otherwise.
+type Formatter struct {
+	Kind string
+}
+
+// Validate returns an error if the formatter kind is unknown.
+func (f Formatter) Validate() error {
+	if f.Kind == "" {
+		return nil
+	}
+	for _, k := range FormatKinds {
+		if f.Kind == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown --format %q, supported: %s", f.Kind, strings.Join(FormatKinds, ", "))
+}
+
+// IsText returns whether the formatter is the default text formatter, in
+// which case commands print their normal human readable output.
+func (f Formatter) IsText() bool {
+	return f.Kind == "" || f.Kind == "text"
+}
+
+// Format marshals v per the formatter kind. This must not be used if the
+// formatter IsText.
+//
+// For "jsonl" (JSON Lines), each element of a slice is marshaled on its own
+// line, and anything else is marshaled as one line. Commands that print many
+// values should prefer streaming them with a JSONLWriter.
+func (f Formatter) Format(v interface{}) ([]byte, error) {
+	switch f.Kind {
+	case "json":
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case "jsonl":
+		b := new(bytes.Buffer)
+		w := NewJSONLWriter(b)
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+			for i := 0; i < rv.Len(); i++ {
+				if err := w.Write(rv.Index(i).Interface()); err != nil {
+					return nil, err
+				}
+			}
+		} else if err := w.Write(v); err != nil {
+			return nil, err
+		}
+		if err := w.Flush(); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	case "yaml":
+		return yaml.Marshal(v)
+	default:
+		return nil, fmt.Errorf("unable to format %T as %q", v, f.Kind)
+	}
+}
+
+// Print formats v and writes it to stdout. Formatted output is explicitly
+// requested, so it is written even with --quiet.
+func (f Formatter) Print(v interface{}) error {
+	return f.PrintTo(os.Stdout, v)
+}
+
+// IsJSONL returns whether the formatter is "jsonl", for commands that stream
+// their output with a JSONLWriter.
+func (f Formatter) IsJSONL() bool {
+	return f.Kind == "jsonl"
+}
+
+// JSONLWriter streams JSON Lines: every value is marshaled as JSON on its own
+// line. Writes are buffered; Flush writes out everything written so far, so
+// that readers such as jq see values as they are produced.
+type JSONLWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLWriter returns a JSONLWriter writing to w.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	bw := bufio.NewWriter(w)
+	return &JSONLWriter{bw, json.NewEncoder(bw)}
+}
+
+// Write marshals v as one line. The encoder adds the trailing newline.
+func (j *JSONLWriter) Write(v interface{}) error {
+	return j.enc.Encode(v)
+}
+
+// Flush writes any buffered lines.
+func (j *JSONLWriter) Flush() error {
+	return j.w.Flush()
+}
+
+// PrintTo formats v and writes it to w.
+func (f Formatter) PrintTo(w io.Writer, v interface{}) error {
+	b, err := f.Format(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatter(t *testing.T) {
+	type row struct {
+		Name  string `json:"name" yaml:"name"`
+		Count int    `json:"count" yaml:"count"`
+	}
+	in := []row{{"foo", 1}, {"bar", 2}}
+
+	for _, test := range []struct {
+		kind   string
+		isText bool
+		exp    string
+		expErr bool
+	}{
+		{kind: "", isText: true},
+		{kind: "text", isText: true},
+		{kind: "json", exp: `[{"name":"foo","count":1},{"name":"bar","count":2}]` + "\n"},
+		{kind: "jsonl", exp: `{"name":"foo","count":1}` + "\n" + `{"name":"bar","count":2}` + "\n"},
+		{kind: "yaml", exp: "- name: foo\n  count: 1\n- name: bar\n  count: 2\n"},
+		{kind: "xml", expErr: true},
+	} {
+		t.Run(test.kind, func(t *testing.T) {
+			f := Formatter{test.kind}
+			err := f.Validate()
+			require.Equal(t, test.expErr, err != nil, "validate error mismatch, got: %v", err)
+			if test.expErr {
+				return
+			}
+			require.Equal(t, test.isText, f.IsText())
+			if test.isText {
+				return
+			}
+			b := new(bytes.Buffer)
+			require.NoError(t, f.PrintTo(b, in))
+			require.Equal(t, test.exp, b.String())
+		})
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	b := new(bytes.Buffer)
+	w := NewJSONLWriter(b)
+	require.NoError(t, w.Write(map[string]int{"a": 1}))
+	require.Empty(t, b.String(), "writes should be buffered until flushed")
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.Write([]int{1, 2}))
+	require.NoError(t, w.Flush())
+	require.Equal(t, "{\"a\":1}\n[1,2]\n", b.String())
+}
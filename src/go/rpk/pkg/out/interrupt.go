@@ -0,0 +1,65 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	interruptMu     sync.Mutex
+	interruptCancel context.CancelFunc
+	interruptOnce   sync.Once
+)
+
+// Interruptible marks the running command as handling interrupts. Rather than
+// exiting on the first SIGINT or SIGTERM, rpk then cancels the context from
+// InterruptContext so that the command can stop its requests, report what it
+// already did, and exit with ExitInterrupted. A second signal always exits
+// immediately.
+//
+// The signal handler is only installed by this, so commands that never call
+// this keep the default signal behavior, and commands with their own handler
+// (such as topic consume draining on Ctrl-C) do not race ours.
+func Interruptible() {
+	interruptOnce.Do(func() {
+		sigs := make(chan os.Signal, 2)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigs
+			fmt.Fprintln(os.Stderr, "\nInterrupted, canceling; interrupt again to exit immediately.")
+			interruptMu.Lock()
+			cancel := interruptCancel
+			interruptMu.Unlock()
+			if cancel != nil {
+				cancel()
+			}
+			<-sigs
+			os.Exit(ExitInterrupted)
+		}()
+	})
+}
+
+// InterruptContext returns a context that is canceled when the process
+// receives SIGINT or SIGTERM once the command is Interruptible. This is meant
+// to be called once, with the returned context used as the root command's
+// context.
+func InterruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	interruptMu.Lock()
+	defer interruptMu.Unlock()
+	interruptCancel = cancel
+	return ctx
+}
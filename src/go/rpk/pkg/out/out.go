@@ -24,6 +24,36 @@ import (
 	"github.com/twmb/franz-go/pkg/kadm"
 )
 
+var quiet bool
+
+// SetQuiet sets whether rpk is running with --quiet. In quiet mode,
+// informational chatter is discarded: everything written through Infof,
+// Warnf, and Exit. Errors are still written to stderr, and the data a command
+// prints, such as tables or --format output, is still written to stdout.
+func SetQuiet(q bool) { quiet = q }
+
+// IsQuiet returns whether rpk is running with --quiet.
+func IsQuiet() bool { return quiet }
+
+// Infof formats the message with a suffixed newline to stdout, unless running
+// with --quiet.
+func Infof(msg string, args ...interface{}) {
+	if quiet {
+		return
+	}
+	fmt.Printf(msg+"\n", args...)
+}
+
+// Warnf formats the message with a suffixed newline to stderr, unless running
+// with --quiet. This is for warnings that do not fail the command; errors
+// should use Die and friends, which are never quiet.
+func Warnf(msg string, args ...interface{}) {
+	if quiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, msg+"\n", args...)
+}
+
 // Confirm prompts the user to confirm the formatted message and returns the
 // confirmation result or an error.
 func Confirm(msg string, args ...interface{}) (bool, error) {
@@ -48,32 +78,73 @@ func Pick(options []string, msg string, args ...interface{}) (string, error) {
 	return options[selected], nil
 }
 
+// PickMany prompts the user to pick at least one of many options, returning
+// the selected options or an error.
+func PickMany(options []string, msg string, args ...interface{}) ([]string, error) {
+	var selected []string
+	return selected, survey.AskOne(&survey.MultiSelect{
+		Message: fmt.Sprintf(msg, args...),
+		Options: options,
+	}, &selected, survey.WithValidator(survey.Required))
+}
+
+// Input prompts the user for a line of input, returning the input or an
+// error. If validate is non-nil, the user is prompted again until validate
+// returns no error. An empty input returns def.
+func Input(def string, validate func(string) error, msg string, args ...interface{}) (string, error) {
+	var input string
+	var opts []survey.AskOpt
+	if validate != nil {
+		opts = append(opts, survey.WithValidator(func(ans interface{}) error {
+			s, _ := ans.(string)
+			if s == "" {
+				s = def
+			}
+			return validate(s)
+		}))
+	}
+	err := survey.AskOne(&survey.Input{
+		Message: fmt.Sprintf(msg, args...),
+		Default: def,
+	}, &input, opts...)
+	return input, err
+}
+
+// Password prompts the user for a password, hiding the input, and returns the
+// password or an error.
+func Password(msg string, args ...interface{}) (string, error) {
+	var password string
+	return password, survey.AskOne(&survey.Password{
+		Message: fmt.Sprintf(msg, args...),
+	}, &password)
+}
+
 // Die formats the message with a suffixed newline to stderr and exits the
 // process with 1.
 func Die(msg string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, msg+"\n", args...)
-	os.Exit(1)
+	DieCode(ExitError, msg, args...)
 }
 
-// MaybeDie calls Die if err is non-nil.
+// MaybeDie calls Die if err is non-nil, exiting with the ExitCode of err.
 func MaybeDie(err error, msg string, args ...interface{}) {
 	if err != nil {
-		Die(msg, args...)
+		DieCode(ExitCode(err), msg, args...)
 	}
 }
 
-// MaybeDieErr calls Die if err is non-nil, with just the err as the message.
+// MaybeDieErr calls Die if err is non-nil, with just the err as the message,
+// exiting with the ExitCode of err.
 func MaybeDieErr(err error) {
 	if err != nil {
-		Die("%v", err)
+		DieCode(ExitCode(err), "%v", err)
 	}
 }
 
-// Exit formats the message with a suffixed newline to stdout and exist
-// successfully with 0.
+// Exit formats the message with a suffixed newline to stdout, unless running
+// with --quiet, and exits successfully with 0.
 func Exit(msg string, args ...interface{}) {
-	fmt.Printf(msg+"\n", args...)
-	os.Exit(0)
+	Infof(msg, args...)
+	ExitWith(0)
 }
 
 // HandleShardError prints a message and potentially exits depending on the
@@ -88,17 +159,17 @@ func HandleShardError(name string, err error) {
 	case errors.As(err, &se):
 		if se.AllFailed {
 			fmt.Printf("all %d %s request failures, first error: %s\n", len(se.Errs), se.Name, se.Errs[0].Err)
-			os.Exit(1)
+			ExitWith(ExitCode(se.Errs[0].Err))
 		}
 		fmt.Printf("%d %s request failures, first error: %s\n", len(se.Errs), se.Name, se.Errs[0].Err)
 
 	case errors.As(err, &ae):
 		fmt.Printf("%s authorization problem: %s\n", name, err)
-		os.Exit(1)
+		ExitWith(ExitServer)
 
 	default:
 		fmt.Printf("unable to issue %s request: %s\n", name, err)
-		os.Exit(1)
+		ExitWith(ExitCode(err))
 	}
 }
 
@@ -126,6 +197,10 @@ func SectionFn(header string, fn func()) {
 // TabWriter writes tab delimited output.
 type TabWriter struct {
 	*tabwriter.Writer
+
+	// style is non-nil for tables from NewStyledTable, which buffer rows
+	// and render them on Flush.
+	style *tableStyle
 }
 
 // NewTable returns a TabWriter that is meant to output a "table". The headers
@@ -155,12 +230,16 @@ func NewTabWriter() *TabWriter {
 
 // NewTabWriterTo returns a TabWriter that writes to w.
 func NewTabWriterTo(w io.Writer) *TabWriter {
-	return &TabWriter{tabwriter.NewWriter(w, 6, 4, 2, ' ', 0)}
+	return &TabWriter{Writer: tabwriter.NewWriter(w, 6, 4, 2, ' ', 0)}
 }
 
 // Print stringifies the arguments and prints them tab-delimited and
 // newline-suffixed to the tab writer.
 func (t *TabWriter) Print(args ...interface{}) {
+	if t.style != nil {
+		t.style.add(args2strings(args), nil)
+		return
+	}
 	fmt.Fprint(t.Writer, strings.Join(args2strings(args), "\t")+"\n")
 }
 
@@ -171,6 +250,10 @@ func (t *TabWriter) Print(args ...interface{}) {
 // put those arguments in your helper struct to *ensure* there are no breaking
 // output changes if any field changes types.
 func (t *TabWriter) PrintStructFields(s interface{}) {
+	t.Print(structFields(s)...)
+}
+
+func structFields(s interface{}) []interface{} {
 	v := reflect.ValueOf(s)
 	if v.Kind() == reflect.Ptr {
 		v = reflect.Indirect(v)
@@ -186,7 +269,7 @@ func (t *TabWriter) PrintStructFields(s interface{}) {
 		}
 		fields = append(fields, v.Field(i).Interface())
 	}
-	t.Print(fields...)
+	return fields
 }
 
 // PrintColumn is the same as Print, but prints header uppercased as the first
@@ -198,5 +281,19 @@ func (t *TabWriter) PrintColumn(header string, args ...interface{}) {
 
 // Line prints a newline in our tab writer. This will reset tab spacing.
 func (t *TabWriter) Line(sprint ...interface{}) {
+	if t.style != nil {
+		t.style.add([]string{fmt.Sprint(sprint...)}, nil)
+		return
+	}
 	fmt.Fprint(t.Writer, append(sprint, "\n")...)
 }
+
+// Flush flushes all buffered output.
+func (t *TabWriter) Flush() error {
+	if t.style != nil {
+		if err := t.style.render(); err != nil {
+			return err
+		}
+	}
+	return t.Writer.Flush()
+}
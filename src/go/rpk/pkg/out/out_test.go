@@ -2,6 +2,8 @@ package out
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -44,3 +46,54 @@ func TestPrintStructFields(t *testing.T) {
 		require.Equal(t, test.exp, got, "not equal!")
 	}
 }
+
+// captureStd swaps stdout and stderr with temporary files while fn runs and
+// returns what was written to each.
+func captureStd(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	dir := t.TempDir()
+	open := func(name string) *os.File {
+		f, err := os.Create(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return f
+	}
+	outf, errf := open("stdout"), open("stderr")
+	origOut, origErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outf, errf
+	defer func() { os.Stdout, os.Stderr = origOut, origErr }()
+
+	fn()
+
+	read := func(f *os.File) string {
+		f.Close()
+		b, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		return string(b)
+	}
+	return read(outf), read(errf)
+}
+
+func TestQuiet(t *testing.T) {
+	for _, q := range []bool{false, true} {
+		SetQuiet(q)
+		stdout, stderr := captureStd(t, func() {
+			Infof("created %d", 1)
+			Warnf("warning: %s", "dup")
+			tw := NewTable("name")
+			tw.Print("foo")
+			tw.Flush()
+			Section("filters")
+			Formatter{Kind: "json"}.Print(map[string]int{"created": 1})
+		})
+		if q {
+			// Only the chatter is dropped: tables and sections
+			// are the data the command was asked for.
+			require.Equal(t, "NAME\nfoo\nFILTERS\n=======\n{\"created\":1}\n", stdout)
+			require.Equal(t, "", stderr)
+		} else {
+			require.Equal(t, "created 1\nNAME\nfoo\nFILTERS\n=======\n{\"created\":1}\n", stdout)
+			require.Equal(t, "warning: dup\n", stderr)
+		}
+	}
+	SetQuiet(false)
+}
@@ -0,0 +1,212 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// The --color modes for NewStyledTable.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// ColorModes are the supported --color modes.
+var ColorModes = []string{ColorAuto, ColorAlways, ColorNever}
+
+// ValidateColor returns an error if mode is not one of ColorModes. An empty
+// mode is auto.
+func ValidateColor(mode string) error {
+	if mode == "" {
+		return nil
+	}
+	for _, m := range ColorModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown --color %q, supported: %s", mode, strings.Join(ColorModes, ", "))
+}
+
+// NewStyledTable returns a table that renders depending on whether stdout is
+// a terminal. In a terminal, columns are aligned with spaces and rows printed
+// with PrintColor are colored. Otherwise, columns are separated with a single
+// tab so that the output is easy to process with cut or awk, and nothing is
+// colored.
+//
+// The color mode overrides only coloring: "always" colors rows even if stdout
+// is not a terminal, and "never" never colors. In "auto" mode, color is also
+// disabled if the NO_COLOR env var is set.
+//
+// Rows are buffered and rendered on Flush. Structured (--format json or yaml)
+// output should not use tables at all.
+func NewStyledTable(mode string, headers ...string) *TabWriter {
+	tty := term.IsTerminal(int(os.Stdout.Fd()))
+	width := 0
+	if tty {
+		if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			width = w
+		}
+	}
+	return newStyledTableTo(os.Stdout, tty, width, mode, headers...)
+}
+
+func newStyledTableTo(w io.Writer, tty bool, width int, mode string, headers ...string) *TabWriter {
+	var colored bool
+	switch mode {
+	case ColorAlways:
+		colored = true
+	case ColorNever:
+	default:
+		colored = tty && !color.NoColor
+	}
+	t := &TabWriter{
+		Writer: NewTabWriterTo(w).Writer,
+		style: &tableStyle{
+			w:       w,
+			aligned: tty,
+			colored: colored,
+			width:   width,
+		},
+	}
+	var iheaders []interface{}
+	for _, header := range headers {
+		iheaders = append(iheaders, strings.ToUpper(header))
+	}
+	t.Print(iheaders...)
+	return t
+}
+
+// PrintColor is Print, but the row is printed in the given color if the
+// table is colored; color.Reset prints the row uncolored. For tables that are
+// not from NewStyledTable, this is Print.
+func (t *TabWriter) PrintColor(c color.Attribute, args ...interface{}) {
+	if t.style == nil || c == color.Reset {
+		t.Print(args...)
+		return
+	}
+	t.style.add(args2strings(args), &c)
+}
+
+// PrintStructFieldsColor is PrintStructFields, but the row is printed in the
+// given color if the table is colored.
+func (t *TabWriter) PrintStructFieldsColor(c color.Attribute, s interface{}) {
+	t.PrintColor(c, structFields(s)...)
+}
+
+// tableStyle buffers the rows of a styled table.
+type tableStyle struct {
+	w       io.Writer
+	aligned bool
+	colored bool
+	width   int // terminal width, or 0 if unknown
+
+	rows   [][]string
+	colors []*color.Attribute
+}
+
+func (s *tableStyle) add(row []string, c *color.Attribute) {
+	s.rows = append(s.rows, row)
+	s.colors = append(s.colors, c)
+}
+
+// render writes all buffered rows. When aligned, every column but the last
+// is padded to its widest cell plus two spaces (and at least six), as
+// NewTable does; if that would be wider than the terminal, columns are padded
+// with only one space. Cells are never truncated.
+func (s *tableStyle) render() error {
+	defer func() { s.rows, s.colors = nil, nil }()
+
+	var widths []int
+	if s.aligned {
+		for _, row := range s.rows {
+			if len(row) == 0 {
+				continue
+			}
+			for i, cell := range row[:len(row)-1] {
+				if i == len(widths) {
+					widths = append(widths, 0)
+				}
+				if n := utf8.RuneCountInString(cell); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+		padding, minWidth := 2, 6
+		if s.width > 0 && s.rowWidth(widths, padding, minWidth) > s.width {
+			padding, minWidth = 1, 0
+		}
+		for i := range widths {
+			widths[i] = padded(widths[i], padding, minWidth)
+		}
+	}
+
+	var sb strings.Builder
+	for i, row := range s.rows {
+		var line string
+		if s.aligned {
+			var lb strings.Builder
+			for j, cell := range row {
+				lb.WriteString(cell)
+				if j < len(row)-1 {
+					lb.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)))
+				}
+			}
+			line = lb.String()
+		} else {
+			line = strings.Join(row, "\t")
+		}
+		if c := s.colors[i]; c != nil && s.colored {
+			cc := color.New(*c)
+			cc.EnableColor()
+			line = cc.Sprint(line)
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(s.w, sb.String())
+	return err
+}
+
+func padded(width, padding, minWidth int) int {
+	if width += padding; width < minWidth {
+		return minWidth
+	}
+	return width
+}
+
+// rowWidth returns the width of the widest row if every column but the last
+// is padded.
+func (s *tableStyle) rowWidth(widths []int, padding, minWidth int) int {
+	var max int
+	for _, row := range s.rows {
+		var n int
+		for i, cell := range row {
+			if i < len(row)-1 {
+				n += padded(widths[i], padding, minWidth)
+			} else {
+				n += utf8.RuneCountInString(cell)
+			}
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return max
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 Redpanda Data, Inc.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.md
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0
+
+package out
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStyledTable(t *testing.T) {
+	rows := [][]interface{}{
+		{"User:alice", "ALLOW", ""},
+		{"User:bob", "DENY", "some error"},
+	}
+	print := func(tw *TabWriter) {
+		tw.PrintColor(color.FgGreen, rows[0]...)
+		tw.PrintColor(color.FgRed, rows[1]...)
+		tw.Flush()
+	}
+
+	// In a terminal without color, the output matches NewTable.
+	exp := new(bytes.Buffer)
+	tw := NewTableTo(exp, "principal", "permission", "error")
+	for _, row := range rows {
+		tw.Print(row...)
+	}
+	tw.Flush()
+
+	for _, test := range []struct {
+		name  string
+		tty   bool
+		width int
+		mode  string
+		exp   string
+	}{
+		{
+			name: "terminal without color is aligned",
+			tty:  true,
+			mode: ColorNever,
+			exp:  exp.String(),
+		},
+		{
+			name:  "narrow terminal shrinks padding",
+			tty:   true,
+			width: 20,
+			mode:  ColorNever,
+			exp: "PRINCIPAL  PERMISSION ERROR\n" +
+				"User:alice ALLOW      \n" +
+				"User:bob   DENY       some error\n",
+		},
+		{
+			name: "not a terminal is tab separated",
+			mode: ColorAuto,
+			exp: "PRINCIPAL\tPERMISSION\tERROR\n" +
+				"User:alice\tALLOW\t\n" +
+				"User:bob\tDENY\tsome error\n",
+		},
+		{
+			name: "always colors rows but not the header",
+			mode: ColorAlways,
+			exp: "PRINCIPAL\tPERMISSION\tERROR\n" +
+				"\x1b[32mUser:alice\tALLOW\t\x1b[0m\n" +
+				"\x1b[31mUser:bob\tDENY\tsome error\x1b[0m\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b := new(bytes.Buffer)
+			print(newStyledTableTo(b, test.tty, test.width, test.mode, "principal", "permission", "error"))
+			require.Equal(t, test.exp, b.String())
+		})
+	}
+}
+
+func TestValidateColor(t *testing.T) {
+	for _, mode := range []string{"", ColorAuto, ColorAlways, ColorNever} {
+		require.NoError(t, ValidateColor(mode))
+	}
+	require.Error(t, ValidateColor("sometimes"))
+}